@@ -0,0 +1,103 @@
+package ansible
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"golang.org/x/net/context"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/errored"
+	"github.com/contiv/executor"
+)
+
+// Facts are the facts of a host as gathered by ansible's setup module, keyed
+// by the fact's name like `ansible_memtotal_mb`
+type Facts map[string]interface{}
+
+// FactsRunner facilitates gathering the facts of the hosts in an inventory
+type FactsRunner struct {
+	inventory   Inventory
+	user        string
+	privKeyFile string
+	ctxt        context.Context
+}
+
+// NewFactsRunner returns an instance of FactsRunner for specified inventory.
+// The ctxt can be used to control the runner's state, like with Runner.
+func NewFactsRunner(inventory Inventory, user, privKeyFile string, ctxt context.Context) *FactsRunner {
+	return &FactsRunner{
+		inventory:   inventory,
+		user:        user,
+		privKeyFile: privKeyFile,
+		ctxt:        ctxt,
+	}
+}
+
+// Run gathers and returns the facts of the hosts, keyed by the host's alias.
+// The facts of the hosts where they were gathered are returned even if the
+// gathering failed on other hosts, along with the error.
+func (r *FactsRunner) Run(stdout, stderr io.Writer) (map[string]Facts, error) {
+	hostsFile, err := NewInventoryFile(r.inventory)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(hostsFile.Name())
+
+	treeDir, err := ioutil.TempDir("", "facts")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(treeDir)
+
+	logrus.Debugf("going to gather facts with hosts file: %q", hostsFile.Name())
+	cmd := exec.Command("ansible", "all", "-i", hostsFile.Name(), "--user", r.user,
+		"--private-key", r.privKeyFile, "-m", "setup", "--tree", treeDir)
+	// turn off host key checking as we are in non-interactive mode
+	cmd.Env = append(cmd.Env, "ANSIBLE_HOST_KEY_CHECKING=false")
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	e := executor.New(cmd)
+	res, runErr := e.Run(r.ctxt)
+	logrus.Debugf("executor result: %s", res)
+
+	facts, err := readFactsTree(treeDir)
+	if err != nil {
+		return nil, err
+	}
+	return facts, runErr
+}
+
+// readFactsTree reads the facts from the tree directory that ansible writes the
+// output of each host to, in a file named after the host. The hosts whose
+// output doesn't have the facts, like the unreachable ones, are skipped.
+func readFactsTree(dir string) (map[string]Facts, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	facts := map[string]Facts{}
+	for _, f := range files {
+		out, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		hostOut := struct {
+			Facts Facts `json:"ansible_facts"`
+		}{}
+		if err := json.Unmarshal(out, &hostOut); err != nil {
+			return nil, errored.Errorf("failed to parse the facts of host %q. Error: %v", f.Name(), err)
+		}
+		if hostOut.Facts == nil {
+			logrus.Debugf("no facts were gathered for host %q: %s", f.Name(), out)
+			continue
+		}
+		facts[f.Name()] = hostOut.Facts
+	}
+	return facts, nil
+}
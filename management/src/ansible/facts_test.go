@@ -0,0 +1,34 @@
+// +build unittest
+
+package ansible
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *ansibleSuite) TestReadFactsTree(c *C) {
+	dir, err := ioutil.TempDir("", "facts")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+
+	outs := map[string]string{
+		"node1": `{"ansible_facts": {"ansible_memtotal_mb": 1024, "ansible_distribution": "CentOS"}, "changed": false}`,
+		"node2": `{"msg": "SSH Error: data could not be sent to the remote host", "unreachable": true}`,
+	}
+	for host, out := range outs {
+		c.Assert(ioutil.WriteFile(filepath.Join(dir, host), []byte(out), 0644), IsNil)
+	}
+	facts, err := readFactsTree(dir)
+	c.Assert(err, IsNil)
+	c.Assert(facts, DeepEquals, map[string]Facts{
+		"node1": {"ansible_memtotal_mb": float64(1024), "ansible_distribution": "CentOS"},
+	})
+
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "node3"), []byte("invalid"), 0644), IsNil)
+	_, err = readFactsTree(dir)
+	c.Assert(err, ErrorMatches, `.*failed to parse the facts of host "node3".*`)
+}
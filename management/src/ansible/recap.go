@@ -0,0 +1,115 @@
+package ansible
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// recapHeader is the prefix of the line that starts the play recap in playbook output
+const recapHeader = "PLAY RECAP"
+
+var (
+	// recapHostRe matches a host's line in the play recap, like:
+	// node1  : ok=3    changed=1    unreachable=0    failed=0
+	recapHostRe = regexp.MustCompile(`^\s*(\S+)\s+:\s+(.*)$`)
+	// ansiEscapeRe matches the color codes, if any, in playbook output
+	ansiEscapeRe = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+// HostRecap is the summary of a playbook run on a host, as reported in the play recap
+type HostRecap struct {
+	OK          int `json:"ok"`
+	Changed     int `json:"changed"`
+	Unreachable int `json:"unreachable"`
+	Failed      int `json:"failed"`
+	Skipped     int `json:"skipped"`
+}
+
+// RecapParser parses the play recap from the output of a playbook run. It
+// satisfies the io.Writer interface so the output can be written to it as it
+// is generated, only the recap is kept.
+type RecapParser struct {
+	sync.Mutex
+	partial []byte
+	inRecap bool
+	recaps  map[string]HostRecap
+}
+
+// NewRecapParser instantiates and returns RecapParser
+func NewRecapParser() *RecapParser {
+	return &RecapParser{
+		recaps: map[string]HostRecap{},
+	}
+}
+
+// Write parses the complete lines in the output and holds back the partial line, if any
+func (p *RecapParser) Write(b []byte) (int, error) {
+	p.Lock()
+	defer p.Unlock()
+	p.partial = append(p.partial, b...)
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			break
+		}
+		p.parseLine(string(p.partial[:i]))
+		p.partial = p.partial[i+1:]
+	}
+	return len(b), nil
+}
+
+func (p *RecapParser) parseLine(line string) {
+	line = strings.TrimSpace(ansiEscapeRe.ReplaceAllString(line, ""))
+	if strings.HasPrefix(line, recapHeader) {
+		p.inRecap = true
+		return
+	}
+	if !p.inRecap || line == "" {
+		return
+	}
+
+	m := recapHostRe.FindStringSubmatch(line)
+	if m == nil {
+		// the recap ends at the first line that is not a host's summary
+		p.inRecap = false
+		return
+	}
+	recap := HostRecap{}
+	for _, field := range strings.Fields(m[2]) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		val, err := strconv.Atoi(kv[1])
+		if err != nil {
+			continue
+		}
+		switch kv[0] {
+		case "ok":
+			recap.OK = val
+		case "changed":
+			recap.Changed = val
+		case "unreachable":
+			recap.Unreachable = val
+		case "failed":
+			recap.Failed = val
+		case "skipped":
+			recap.Skipped = val
+		}
+	}
+	p.recaps[m[1]] = recap
+}
+
+// Recaps returns the summary of the playbook run per host, as parsed so far
+func (p *RecapParser) Recaps() map[string]HostRecap {
+	p.Lock()
+	defer p.Unlock()
+	recaps := map[string]HostRecap{}
+	for host, recap := range p.recaps {
+		recaps[host] = recap
+	}
+	return recaps
+}
@@ -0,0 +1,44 @@
+// +build unittest
+
+package ansible
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *ansibleSuite) TestRecapParser(c *C) {
+	out := []string{
+		"PLAY [service-master] *********************************************************\n",
+		"\n",
+		"TASK: [provision] *************************************************************\n",
+		"changed: [node1]\n",
+		"failed: [node2] => {\"failed\": true}\n",
+		"\n",
+		"PLAY RECAP ********************************************************************\n",
+		"node1                      : ok=2    changed=1    unreachable=0    failed=0\n",
+		"\x1b[0;31mnode2\x1b[0m                      : ok=1    changed=0    unreachable=0    failed=1   skipped=2\n",
+		"node3                      : ok=0    changed=0",
+		"    unreachable=1    failed=0\n",
+		"\n",
+	}
+	exptdRecaps := map[string]HostRecap{
+		"node1": {OK: 2, Changed: 1},
+		"node2": {OK: 1, Failed: 1, Skipped: 2},
+		"node3": {Unreachable: 1},
+	}
+
+	p := NewRecapParser()
+	for _, o := range out {
+		n, err := p.Write([]byte(o))
+		c.Assert(err, IsNil)
+		c.Assert(n, Equals, len(o))
+	}
+	c.Assert(p.Recaps(), DeepEquals, exptdRecaps)
+}
+
+func (s *ansibleSuite) TestRecapParserNoRecap(c *C) {
+	p := NewRecapParser()
+	_, err := p.Write([]byte("ERROR: playbook not found\nnode1 : ok=1\n"))
+	c.Assert(err, IsNil)
+	c.Assert(p.Recaps(), DeepEquals, map[string]HostRecap{})
+}
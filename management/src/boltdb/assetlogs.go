@@ -0,0 +1,96 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+)
+
+const (
+	assetLogsBucket = "assetlogs"
+	// maxAssetLogs is the number of log entries kept for an asset, the oldest
+	// entries are deleted beyond it
+	maxAssetLogs = 1000
+)
+
+// assetSeqKey returns the key for an entry in the bucket of an asset, like a log
+// entry. The sequence is encoded in big endian, like the job ids, so that the
+// entries are iterated in their order.
+func assetSeqKey(seq uint64) []byte {
+	return jobKey(seq)
+}
+
+// AddAssetLog creates a log entry for an asset. The entries of an asset are kept
+// in their own bucket, keyed by a sequence so that they are iterated in the
+// order they were added.
+func (c *Client) AddAssetLog(tag, mtype, message string) error {
+	val, err := json.Marshal(inventory.AssetLog{
+		Time:    time.Now(),
+		Type:    mtype,
+		Message: message,
+	})
+	if err != nil {
+		return errored.Errorf("failed to marshal. Error: %v", err)
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket([]byte(assetLogsBucket)).CreateBucketIfNotExists([]byte(tag))
+		if err != nil {
+			return err
+		}
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := b.Put(assetSeqKey(seq), val); err != nil {
+			return err
+		}
+		// trim the oldest entries
+		if seq > maxAssetLogs {
+			return b.Delete(assetSeqKey(seq - maxAssetLogs))
+		}
+		return nil
+	})
+}
+
+// GetAssetLogs queries and returns the log entries of an asset, oldest first
+func (c *Client) GetAssetLogs(tag string) ([]inventory.AssetLog, error) {
+	var vals [][]byte
+
+	if err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(assetLogsBucket)).Bucket([]byte(tag))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			vals = append(vals, append([]byte{}, v...))
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	logs := []inventory.AssetLog{}
+	for _, val := range vals {
+		var l inventory.AssetLog
+		if err := json.Unmarshal(val, &l); err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+
+	return logs, nil
+}
+
+// deleteAssetBucket deletes the bucket of an asset, like the one with it's log
+// entries, from the specified bucket, if it exists
+func deleteAssetBucket(tx *bolt.Tx, bucket, tag string) error {
+	b := tx.Bucket([]byte(bucket))
+	if b.Bucket([]byte(tag)) == nil {
+		return nil
+	}
+	return b.DeleteBucket([]byte(tag))
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/boltdb/bolt"
+	"github.com/contiv/cluster/management/src/inventory"
 	"github.com/contiv/errored"
 )
 
@@ -30,6 +31,14 @@ type Asset struct {
 	Status    string `json:"status"`
 	State     string `json:"state"`
 	StateDesc string `json:"state_desc"`
+	// the configuration state of the asset
+	HostGroup string            `json:"host_group,omitempty"`
+	HostAddr  string            `json:"host_addr,omitempty"`
+	HostVars  map[string]string `json:"host_vars,omitempty"`
+	// the hardware facts of the asset
+	Facts *inventory.AssetFacts `json:"facts,omitempty"`
+	// the attributes of the asset, like the labels set by the operator
+	Attributes map[string]string `json:"attributes,omitempty"`
 }
 
 // Client denotes state for a boltdb client
@@ -46,8 +55,12 @@ func NewClientFromConfig(config Config) (*Client, error) {
 	}
 
 	if err := db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(assetsBucket))
-		return err
+		for _, bucket := range []string{assetsBucket, jobsBucket, jobLogsBucket, maintenanceBucket, specBucket, assetLogsBucket, assetTransitionsBucket} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(bucket)); err != nil {
+				return err
+			}
+		}
+		return nil
 	}); err != nil {
 		return nil, err
 	}
@@ -114,7 +127,6 @@ func (c *Client) GetAsset(tag string) (Asset, error) {
 func (c *Client) GetAllAssets() (interface{}, error) {
 	var (
 		vals   [][]byte
-		a      Asset
 		assets []Asset
 	)
 
@@ -128,6 +140,9 @@ func (c *Client) GetAllAssets() (interface{}, error) {
 	})
 
 	for _, val := range vals {
+		// a new asset is used for each value, as unmarshalling into a
+		// previously filled asset would merge the host variables
+		var a Asset
 		if err := json.Unmarshal(val, &a); err != nil {
 			return nil, err
 		}
@@ -142,11 +157,6 @@ func (c *Client) CreateState(name, description, status string) error {
 	return nil
 }
 
-// AddAssetLog creates a log entry for an asset
-func (c *Client) AddAssetLog(tag, mtype, message string) error {
-	return errored.Errorf("not implemented")
-}
-
 // SetAssetStatus sets the status of an asset
 func (c *Client) SetAssetStatus(tag, status, state, reason string) error {
 	a, err := c.GetAsset(tag)
@@ -157,6 +167,60 @@ func (c *Client) SetAssetStatus(tag, status, state, reason string) error {
 	a.State = state
 	a.StateDesc = reason
 
+	return c.putAsset(a)
+}
+
+// SetAssetConfig sets the configuration state of an asset
+func (c *Client) SetAssetConfig(tag, group, addr string, vars map[string]string) error {
+	a, err := c.GetAsset(tag)
+	if err != nil {
+		return err
+	}
+	a.HostGroup = group
+	a.HostAddr = addr
+	a.HostVars = vars
+
+	return c.putAsset(a)
+}
+
+// SetAssetFacts sets the hardware facts of an asset
+func (c *Client) SetAssetFacts(tag string, facts inventory.AssetFacts) error {
+	a, err := c.GetAsset(tag)
+	if err != nil {
+		return err
+	}
+	a.Facts = &facts
+
+	return c.putAsset(a)
+}
+
+// SetAssetAttributes sets the attributes of an asset
+func (c *Client) SetAssetAttributes(tag string, attrs map[string]string) error {
+	a, err := c.GetAsset(tag)
+	if err != nil {
+		return err
+	}
+	a.Attributes = attrs
+
+	return c.putAsset(a)
+}
+
+// DeleteAsset deletes an asset along with it's logs and transition history, if it exists
+func (c *Client) DeleteAsset(tag string) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(assetsBucket))
+		if err := b.Delete([]byte(tag)); err != nil {
+			return err
+		}
+		if err := deleteAssetBucket(tx, assetLogsBucket, tag); err != nil {
+			return err
+		}
+		return deleteAssetBucket(tx, assetTransitionsBucket, tag)
+	})
+}
+
+// putAsset writes the info of an asset
+func (c *Client) putAsset(a Asset) error {
 	val, err := json.Marshal(a)
 	if err != nil {
 		return errored.Errorf("failed to marshal. Error: %v", err)
@@ -164,7 +228,7 @@ func (c *Client) SetAssetStatus(tag, status, state, reason string) error {
 
 	if err := c.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(assetsBucket))
-		err := b.Put([]byte(tag), val)
+		err := b.Put([]byte(a.Name), val)
 		return err
 	}); err != nil {
 		return err
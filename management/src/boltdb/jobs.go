@@ -0,0 +1,143 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/contiv/errored"
+)
+
+const (
+	jobsBucket    = "jobs"
+	jobLogsBucket = "joblogs"
+)
+
+// Job denotes the job related information as read and stored in boltdb.
+type Job struct {
+	ID        uint64            `json:"id"`
+	Desc      string            `json:"desc"`
+	Task      string            `json:"task"`
+	Nodes     []string          `json:"nodes"`
+	Status    string            `json:"status"`
+	Error     string            `json:"error"`
+	StartTime time.Time         `json:"start_time"`
+	EndTime   time.Time         `json:"end_time"`
+	Results   map[string]string `json:"results,omitempty"`
+	// Kind, ExtraVars and HostGroups are the parameters of the job's event,
+	// used to re-run the job when it is interrupted by a clusterm restart.
+	// HostGroups are the target host-groups of the nodes, keyed by node name.
+	Kind       string            `json:"kind,omitempty"`
+	ExtraVars  string            `json:"extra_vars,omitempty"`
+	HostGroups map[string]string `json:"host_groups,omitempty"`
+}
+
+// jobKey returns the key for a job. The ID is encoded in big endian so that
+// the jobs are iterated in the order of their IDs.
+func jobKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// SetJob creates or updates the info of a job
+func (c *Client) SetJob(j Job) error {
+	val, err := json.Marshal(j)
+	if err != nil {
+		return errored.Errorf("failed to marshal. Error: %v", err)
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(jobsBucket))
+		return b.Put(jobKey(j.ID), val)
+	})
+}
+
+// SetJobLogs creates or updates the logs of a job
+func (c *Client) SetJobLogs(id uint64, logs []byte) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(jobLogsBucket))
+		return b.Put(jobKey(id), logs)
+	})
+}
+
+// DeleteJob deletes the info and the logs of a job with specified id, if any
+func (c *Client) DeleteJob(id uint64) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket([]byte(jobsBucket)).Delete(jobKey(id)); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(jobLogsBucket)).Delete(jobKey(id))
+	})
+}
+
+// GetJob queries and returns a job with specified id
+func (c *Client) GetJob(id uint64) (Job, error) {
+	var (
+		val []byte
+		j   Job
+	)
+
+	if err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(jobsBucket))
+		v := b.Get(jobKey(id))
+		if v == nil {
+			return errored.Errorf("No job found for id: %d", id)
+		}
+		// the value is only valid during the transaction, so make a copy
+		val = append([]byte{}, v...)
+		return nil
+	}); err != nil {
+		return j, err
+	}
+
+	if err := json.Unmarshal(val, &j); err != nil {
+		return j, err
+	}
+
+	return j, nil
+}
+
+// GetJobLogs queries and returns the logs of a job with specified id
+func (c *Client) GetJobLogs(id uint64) ([]byte, error) {
+	var logs []byte
+
+	if err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(jobLogsBucket))
+		logs = append([]byte{}, b.Get(jobKey(id))...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// GetAllJobs queries and returns all the jobs, ordered by their ids
+func (c *Client) GetAllJobs() ([]Job, error) {
+	var (
+		vals [][]byte
+		jobs []Job
+	)
+
+	if err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(jobsBucket))
+		return b.ForEach(func(k, v []byte) error {
+			vals = append(vals, append([]byte{}, v...))
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, val := range vals {
+		var j Job
+		if err := json.Unmarshal(val, &j); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,71 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/contiv/errored"
+)
+
+const (
+	maintenanceBucket = "maintenance"
+)
+
+// Maintenance denotes the info of a node put in maintenance by the operator, as
+// read and stored in boltdb. A zero Expiry means that the maintenance doesn't expire.
+type Maintenance struct {
+	Name      string    `json:"name"`
+	Reason    string    `json:"reason"`
+	StartTime time.Time `json:"start_time"`
+	Expiry    time.Time `json:"expiry"`
+}
+
+// SetMaintenance creates or updates the maintenance info of a node
+func (c *Client) SetMaintenance(m Maintenance) error {
+	val, err := json.Marshal(m)
+	if err != nil {
+		return errored.Errorf("failed to marshal. Error: %v", err)
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(maintenanceBucket))
+		return b.Put([]byte(m.Name), val)
+	})
+}
+
+// DeleteMaintenance deletes the maintenance info of a node, if any
+func (c *Client) DeleteMaintenance(name string) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(maintenanceBucket))
+		return b.Delete([]byte(name))
+	})
+}
+
+// GetAllMaintenance queries and returns the maintenance info of all the nodes in maintenance
+func (c *Client) GetAllMaintenance() ([]Maintenance, error) {
+	var (
+		vals  [][]byte
+		maint []Maintenance
+	)
+
+	if err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(maintenanceBucket))
+		return b.ForEach(func(k, v []byte) error {
+			vals = append(vals, append([]byte{}, v...))
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, val := range vals {
+		var m Maintenance
+		if err := json.Unmarshal(val, &m); err != nil {
+			return nil, err
+		}
+		maint = append(maint, m)
+	}
+
+	return maint, nil
+}
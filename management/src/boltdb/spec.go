@@ -0,0 +1,43 @@
+package boltdb
+
+import (
+	"github.com/boltdb/bolt"
+)
+
+const (
+	specBucket = "spec"
+	specKey    = "cluster"
+)
+
+// SetSpec creates or updates the desired state of the cluster, as applied by
+// the operator. The spec is stored as is, it's encoding is up to the caller.
+func (c *Client) SetSpec(spec []byte) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(specBucket))
+		return b.Put([]byte(specKey), spec)
+	})
+}
+
+// DeleteSpec deletes the desired state of the cluster, if any
+func (c *Client) DeleteSpec() error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(specBucket))
+		return b.Delete([]byte(specKey))
+	})
+}
+
+// GetSpec queries and returns the desired state of the cluster. It returns nil
+// if no spec is stored.
+func (c *Client) GetSpec() ([]byte, error) {
+	var spec []byte
+	if err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(specBucket))
+		if val := b.Get([]byte(specKey)); val != nil {
+			spec = append([]byte{}, val...)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
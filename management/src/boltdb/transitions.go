@@ -0,0 +1,79 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+)
+
+const (
+	assetTransitionsBucket = "assettransitions"
+	// maxAssetTransitions is the number of transitions kept for an asset, the
+	// oldest transitions are deleted beyond it
+	maxAssetTransitions = 10000
+)
+
+// AddAssetTransition appends a transition to the transition history of an
+// asset. Like the logs, the transitions of an asset are kept in their own
+// bucket, keyed by a sequence.
+func (c *Client) AddAssetTransition(tag string, t inventory.AssetTransition) error {
+	val, err := json.Marshal(t)
+	if err != nil {
+		return errored.Errorf("failed to marshal. Error: %v", err)
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket([]byte(assetTransitionsBucket)).CreateBucketIfNotExists([]byte(tag))
+		if err != nil {
+			return err
+		}
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := b.Put(assetSeqKey(seq), val); err != nil {
+			return err
+		}
+		// trim the oldest transitions
+		if seq > maxAssetTransitions {
+			return b.Delete(assetSeqKey(seq - maxAssetTransitions))
+		}
+		return nil
+	})
+}
+
+// GetAssetTransitions queries and returns the transitions of an asset within
+// the time range, oldest first. A zero time leaves that end of the range open.
+func (c *Client) GetAssetTransitions(tag string, from, to time.Time) ([]inventory.AssetTransition, error) {
+	var vals [][]byte
+
+	if err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(assetTransitionsBucket)).Bucket([]byte(tag))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			vals = append(vals, append([]byte{}, v...))
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	transitions := []inventory.AssetTransition{}
+	for _, val := range vals {
+		var t inventory.AssetTransition
+		if err := json.Unmarshal(val, &t); err != nil {
+			return nil, err
+		}
+		if (!from.IsZero() && t.Time.Before(from)) || (!to.IsZero() && t.Time.After(to)) {
+			continue
+		}
+		transitions = append(transitions, t)
+	}
+
+	return transitions, nil
+}
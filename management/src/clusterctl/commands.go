@@ -39,16 +39,212 @@ var (
 		},
 	}
 
+	listJobFlags = []cli.Flag{
+		jsonFlag,
+		cli.StringFlag{
+			Name:  "status, s",
+			Value: "",
+			Usage: "list only the jobs with specified status. Possible values: Queued, Running, Complete, Errored or Cancelled",
+		},
+		cli.StringFlag{
+			Name:  "node, n",
+			Value: "",
+			Usage: "list only the jobs that were run on the specified node",
+		},
+		cli.IntFlag{
+			Name:  "offset, o",
+			Value: 0,
+			Usage: "number of most recent jobs to skip",
+		},
+		cli.IntFlag{
+			Name:  "limit, l",
+			Value: 0,
+			Usage: "maximum number of jobs to list. 0 lists all jobs",
+		},
+	}
+
+	nodesGetFlags = []cli.Flag{
+		jsonFlag,
+		cli.StringFlag{
+			Name:  "status, s",
+			Value: "",
+			Usage: "get only the nodes with specified inventory status, like Allocated or Unallocated",
+		},
+		cli.StringFlag{
+			Name:  "state",
+			Value: "",
+			Usage: "get only the nodes with specified inventory state. Possible values: Discovered, Disappeared or Degraded",
+		},
+		cli.StringFlag{
+			Name:  "group, g",
+			Value: "",
+			Usage: "get only the nodes in specified host-group",
+		},
+		cli.StringFlag{
+			Name:  "monitor-label",
+			Value: "",
+			Usage: "get only the node with specified label, usually the hostname, in the monitoring system",
+		},
+		cli.StringFlag{
+			Name:  "addr, a",
+			Value: "",
+			Usage: "get only the node with specified management address",
+		},
+		cli.StringFlag{
+			Name:  "selector, l",
+			Value: "",
+			Usage: "get only the nodes whose labels match the selector, like rack=r1,owner!=team1",
+		},
+		cli.StringFlag{
+			Name:  "sort",
+			Value: "",
+			Usage: "sort the nodes by name, status, state, host_group, monitor_label or addr. Prefix with '-' for descending order. Default is name",
+		},
+		cli.StringFlag{
+			Name:  "fields, f",
+			Value: "",
+			Usage: "comma separated fields of the node info to get: monitoring_state, inventory_state, configuration_state or maintenance_state. Default is all",
+		},
+		cli.IntFlag{
+			Name:  "offset, o",
+			Value: 0,
+			Usage: "number of nodes to skip",
+		},
+		cli.IntFlag{
+			Name:  "limit",
+			Value: 0,
+			Usage: "maximum number of nodes to get. 0 gets all nodes",
+		},
+	}
+
+	transitionsFlags = []cli.Flag{
+		jsonFlag,
+		cli.StringFlag{
+			Name:  "from",
+			Value: "",
+			Usage: "get only the transitions at or after the specified time, in RFC3339 format like 2016-03-01T10:00:00Z",
+		},
+		cli.StringFlag{
+			Name:  "to",
+			Value: "",
+			Usage: "get only the transitions at or before the specified time, in RFC3339 format like 2016-03-01T10:00:00Z",
+		},
+	}
+
+	priorityFlag = cli.IntFlag{
+		Name:  "priority, p",
+		Value: 0,
+		Usage: "priority of the job, when it is queued behind other jobs on the same node(s). Jobs with higher priority are run first",
+	}
+
+	waitFlag = cli.BoolFlag{
+		Name:  "wait, w",
+		Usage: "wait for the job to be done and exit with an error if the job fails",
+	}
+
 	postFlags = []cli.Flag{
 		extraVarsFlag,
 	}
 
+	postJobFlags = []cli.Flag{
+		extraVarsFlag,
+		priorityFlag,
+		waitFlag,
+	}
+
+	hostGroupFlag = cli.StringFlag{
+		Name:  "host-group, g",
+		Value: "",
+		Usage: "host-group of the node(s), as defined in clusterm's configuration. Default ones: service-master or service-worker",
+	}
+
 	postHostGroupFlags = []cli.Flag{
 		extraVarsFlag,
+		priorityFlag,
+		waitFlag,
+		hostGroupFlag,
+	}
+
+	selectorFlag = cli.StringFlag{
+		Name:  "selector, l",
+		Value: "",
+		Usage: "act on the nodes whose labels match the selector instead of the nodes specified as args. It is a comma separated list of key=value or key!=value, like rack=r1,owner!=team1",
+	}
+
+	postDecommissionFlags = []cli.Flag{
+		extraVarsFlag,
+		priorityFlag,
+		waitFlag,
+		cli.BoolFlag{
+			Name:  "force, f",
+			Usage: "decommission the node(s) even if they are not reachable. The cleanup is skipped on the unreachable node(s) and it's failure is ignored",
+		},
+	}
+
+	postUpdateFlags = []cli.Flag{
+		extraVarsFlag,
+		priorityFlag,
+		waitFlag,
+		hostGroupFlag,
+		cli.BoolFlag{
+			Name:  "force, f",
+			Usage: "update the reachable node(s) even if some of the node(s) are not reachable. The unreachable node(s) are left out and set as Unallocated, and the failure of the first cleanup is ignored",
+		},
+	}
+
+	postUpgradeFlags = []cli.Flag{
+		extraVarsFlag,
+		priorityFlag,
+		waitFlag,
+		cli.IntFlag{
+			Name:  "batch-size, b",
+			Value: 1,
+			Usage: "number of nodes to upgrade at a time",
+		},
+		cli.IntFlag{
+			Name:  "max-unavailable, m",
+			Value: 0,
+			Usage: "maximum number of nodes in the cluster that can be unavailable during the upgrade, including the batch being upgraded. 0 means no limit",
+		},
+	}
+
+	maintenanceEnterFlags = []cli.Flag{
+		waitFlag,
+		cli.StringFlag{
+			Name:  "reason, r",
+			Value: "",
+			Usage: "reason for putting the node(s) in maintenance",
+		},
 		cli.StringFlag{
-			Name:  "host-group, g",
+			Name:  "expiry, x",
 			Value: "",
-			Usage: "host-group of the node(s). Possible values: service-master or service-worker",
+			Usage: "duration, like 30m or 4h, after which the node(s) exit maintenance. Empty means no expiry",
+		},
+	}
+
+	maintenanceExitFlags = []cli.Flag{
+		waitFlag,
+	}
+
+	purgeFlags = []cli.Flag{
+		waitFlag,
+		cli.BoolFlag{
+			Name:  "force, f",
+			Usage: "purge the node even if it is not decommissioned",
+		},
+	}
+
+	// the flags of the commands that act on a set of nodes specified either as
+	// args or by a label selector
+	nodesCommissionFlags   = append([]cli.Flag{selectorFlag}, postHostGroupFlags...)
+	nodesDecommissionFlags = append([]cli.Flag{selectorFlag}, postDecommissionFlags...)
+	nodesUpdateFlags       = append([]cli.Flag{selectorFlag}, postUpdateFlags...)
+
+	specFileFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "file, f",
+			Value: "",
+			Usage: "path to the file containing the JSON spec. Use '-' to read the spec from stdin",
 		},
 	}
 
@@ -70,14 +266,51 @@ var (
 					Aliases: []string{"d"},
 					Usage:   "decommission a node",
 					Action:  doAction(newPostActioner(validateOneArg, nodeDecommission)),
-					Flags:   postFlags,
+					Flags:   postDecommissionFlags,
 				},
 				{
 					Name:    "update",
 					Aliases: []string{"u"},
 					Usage:   "update a node",
 					Action:  doAction(newPostActioner(validateOneArg, nodeUpdate)),
-					Flags:   postHostGroupFlags,
+					Flags:   postUpdateFlags,
+				},
+				{
+					Name:    "replace",
+					Aliases: []string{"r"},
+					Usage:   "replace a commissioned node by a spare node, specified as <node-name> <spare-node-name>. The spare node gets the node's host-group and host variables and the node is decommissioned once the spare node is commissioned, in one job",
+					Action:  doAction(newPostActioner(validateTwoArgs, nodeReplace)),
+					Flags:   postJobFlags,
+				},
+				{
+					Name:    "maintenance",
+					Aliases: []string{"m"},
+					Usage:   "put a commissioned node in maintenance or take it out of maintenance. No playbooks are run",
+					Subcommands: []cli.Command{
+						{
+							Name:   "enter",
+							Usage:  "put a commissioned node in maintenance",
+							Action: doAction(newPostActioner(validateOneArg, nodesMaintenanceEnter)),
+							Flags:  maintenanceEnterFlags,
+						},
+						{
+							Name:   "exit",
+							Usage:  "take a node out of maintenance",
+							Action: doAction(newPostActioner(validateOneArg, nodesMaintenanceExit)),
+							Flags:  maintenanceExitFlags,
+						},
+					},
+				},
+				{
+					Name:   "label",
+					Usage:  "set the labels of a node, like it's rack, zone or owner, specified as <node-name> <key>=<value> [<key>=<value>...]. A label is removed when it's value is empty, like <key>=",
+					Action: doAction(newPostActioner(validateNodeLabels, nodeLabel)),
+				},
+				{
+					Name:   "purge",
+					Usage:  "remove a decommissioned node from clusterm and the inventory. No playbooks are run",
+					Action: doAction(newPostActioner(validateOneArg, nodePurge)),
+					Flags:  purgeFlags,
 				},
 				{
 					Name:    "get",
@@ -86,6 +319,18 @@ var (
 					Action:  doAction(newGetActioner(nodeGet)),
 					Flags:   getFlags,
 				},
+				{
+					Name:   "log",
+					Usage:  "get node's event logs, like it's status transitions and the jobs run on it along with their failures",
+					Action: doAction(newGetActioner(nodeLogGet)),
+					Flags:  getFlags,
+				},
+				{
+					Name:   "transitions",
+					Usage:  "get node's status transition history, along with the job or event that caused each transition and it's actor",
+					Action: doAction(newGetActioner(nodeTransitionsGet)),
+					Flags:  transitionsFlags,
+				},
 			},
 		},
 		{
@@ -96,30 +341,56 @@ var (
 				{
 					Name:    "commission",
 					Aliases: []string{"c"},
-					Usage:   "commission a set of nodes",
-					Action:  doAction(newPostActioner(validateMultiNodeNames, nodesCommission)),
-					Flags:   postHostGroupFlags,
+					Usage:   "commission a set of nodes. The nodes may be specified as <node-name>=<host-group> to commission them in different host-groups in one job",
+					Action:  doAction(newPostActioner(validateAnyArgs, nodesCommission)),
+					Flags:   nodesCommissionFlags,
 				},
 				{
 					Name:    "decommission",
 					Aliases: []string{"d"},
 					Usage:   "decommission a set of nodes",
-					Action:  doAction(newPostActioner(validateMultiNodeNames, nodesDecommission)),
-					Flags:   postFlags,
+					Action:  doAction(newPostActioner(validateAnyArgs, nodesDecommission)),
+					Flags:   nodesDecommissionFlags,
 				},
 				{
 					Name:    "update",
 					Aliases: []string{"u"},
 					Usage:   "update a set of nodes",
-					Action:  doAction(newPostActioner(validateMultiNodeNames, nodesUpdate)),
-					Flags:   postFlags,
+					Action:  doAction(newPostActioner(validateAnyArgs, nodesUpdate)),
+					Flags:   nodesUpdateFlags,
+				},
+				{
+					Name:    "upgrade",
+					Aliases: []string{"p"},
+					Usage:   "upgrade a set of commissioned nodes in batches. The rollout stops when a batch fails",
+					Action:  doAction(newPostActioner(validateMultiNodeNames, nodesUpgrade)),
+					Flags:   postUpgradeFlags,
+				},
+				{
+					Name:    "maintenance",
+					Aliases: []string{"m"},
+					Usage:   "put a set of commissioned nodes in maintenance or take them out of maintenance. No playbooks are run",
+					Subcommands: []cli.Command{
+						{
+							Name:   "enter",
+							Usage:  "put a set of commissioned nodes in maintenance",
+							Action: doAction(newPostActioner(validateMultiNodeNames, nodesMaintenanceEnter)),
+							Flags:  maintenanceEnterFlags,
+						},
+						{
+							Name:   "exit",
+							Usage:  "take a set of nodes out of maintenance",
+							Action: doAction(newPostActioner(validateMultiNodeNames, nodesMaintenanceExit)),
+							Flags:  maintenanceExitFlags,
+						},
+					},
 				},
 				{
 					Name:    "get",
 					Aliases: []string{"g"},
-					Usage:   "get status information for all nodes",
+					Usage:   "get status information for all nodes, or the nodes that match the filters",
 					Action:  doAction(newGetActioner(nodesGet)),
-					Flags:   getFlags,
+					Flags:   nodesGetFlags,
 				},
 			},
 		},
@@ -152,10 +423,36 @@ var (
 				{
 					Name:    "get",
 					Aliases: []string{"g"},
-					Usage:   "get job info. Expects an arg with value 'active' or 'last'",
+					Usage:   "get job info. Expects an arg with value 'active', 'last' or the id of a job. There can be more than one active job",
 					Action:  doAction(newGetActioner(jobGet)),
 					Flags:   getJobFlags,
 				},
+				{
+					Name:    "list",
+					Aliases: []string{"l"},
+					Usage:   "list jobs from job history, most recent job first",
+					Action:  doAction(newGetActioner(jobsList)),
+					Flags:   listJobFlags,
+				},
+				{
+					Name:    "queue",
+					Aliases: []string{"q"},
+					Usage:   "list the jobs waiting in the job queue, in the order they shall be run",
+					Action:  doAction(newGetActioner(jobQueueGet)),
+					Flags:   getFlags,
+				},
+				{
+					Name:    "dequeue",
+					Aliases: []string{"d"},
+					Usage:   "remove a job from the job queue. Expects an arg with the id of a queued job",
+					Action:  doAction(newPostActioner(validateOneArg, jobDequeue)),
+				},
+				{
+					Name:    "cancel",
+					Aliases: []string{"c"},
+					Usage:   "cancel a running job. Expects an arg with value 'active' or the id of an active job. The nodes are cleaned up as appropriate for the job",
+					Action:  doAction(newPostActioner(validateOneArg, jobCancel)),
+				},
 			},
 		},
 		{
@@ -163,7 +460,7 @@ var (
 			Aliases: []string{"d"},
 			Usage:   "provision one or more nodes for discovery",
 			Action:  doAction(newPostActioner(validateMultiNodeAddrs, nodesDiscover)),
-			Flags:   postFlags,
+			Flags:   postJobFlags,
 		},
 		{
 			Name:    "config",
@@ -185,6 +482,37 @@ var (
 				},
 			},
 		},
+		{
+			Name:   "apply",
+			Usage:  "apply the spec i.e. the desired state of the cluster. The cluster is reconciled to the spec till another spec is applied or the spec is deleted. Prints the actions that converge the cluster to the spec",
+			Action: doAction(newPostActioner(validateZeroArgs, specApply)),
+			Flags:  specFileFlags,
+		},
+		{
+			Name:   "plan",
+			Usage:  "print the actions that converge the cluster to the spec, without acting on them",
+			Action: doAction(newPostActioner(validateZeroArgs, specPlan)),
+			Flags:  specFileFlags,
+		},
+		{
+			Name:  "spec",
+			Usage: "get/delete the applied spec",
+			Subcommands: []cli.Command{
+				{
+					Name:    "get",
+					Aliases: []string{"g"},
+					Usage:   "get the applied spec along with the actions that converge the cluster to it",
+					Action:  doAction(newGetActioner(specGet)),
+					Flags:   getFlags,
+				},
+				{
+					Name:    "delete",
+					Aliases: []string{"d"},
+					Usage:   "delete the applied spec. The cluster is not reconciled anymore, the nodes are left as is",
+					Action:  doAction(newPostActioner(validateZeroArgs, specDelete)),
+				},
+			},
+		},
 	}
 )
 
@@ -192,15 +520,56 @@ func errUnexpectedArgCount(exptd string, rcvd int) error {
 	return errored.Errorf("command expects %s arg(s) but received %d", exptd, rcvd)
 }
 
+func errJobFailed(id interface{}, errVal interface{}) error {
+	return errored.Errorf("job %v failed. Error: %v", id, errVal)
+}
+
+func errInvalidNodeHostGroup(arg string) error {
+	return errored.Errorf("%q should be of the form <node-name>=<host-group>, when the host-group of a node is specified", arg)
+}
+
+func errInvalidLabelArg(arg string) error {
+	return errored.Errorf("%q should be of the form <key>=<value>", arg)
+}
+
+func errNodesAndSelector() error {
+	return errored.Errorf("either the node names or a label selector should be specified, not both")
+}
+
 func errInvalidIPAddr(a string) error {
 	return errored.Errorf("failed to parse ip address %q", a)
 }
 
+func errInvalidTime(name, val string) error {
+	return errored.Errorf("%s time %q should be in RFC3339 format, like 2016-03-01T10:00:00Z", name, val)
+}
+
 type parsedFlags struct {
-	extraVars  string
-	hostGroup  string
-	jsonOutput bool
-	streamLogs bool
+	extraVars      string
+	hostGroup      string
+	priority       int
+	wait           bool
+	batchSize      int
+	maxUnavailable int
+	reason         string
+	expiry         string
+	force          bool
+	file           string
+	selector       string
+	jsonOutput     bool
+	streamLogs     bool
+	jobStatus      string
+	jobNode        string
+	offset         int
+	limit          int
+	from           string
+	to             string
+	nodeStatus     string
+	state          string
+	monitorLabel   string
+	addr           string
+	sort           string
+	fields         string
 }
 
 type actioner interface {
@@ -215,7 +584,7 @@ func doAction(a actioner) func(*cli.Context) {
 		a.procArgs(c)
 		a.procFlags(c)
 		if err := a.action(cClient); err != nil {
-			logrus.Fatalf(err.Error())
+			logrus.Fatalf("%s", err)
 		}
 	}
 }
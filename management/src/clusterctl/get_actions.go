@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"text/template"
+	"time"
 
 	"github.com/codegangsta/cli"
 	"github.com/contiv/cluster/management/src/clusterm/manager"
@@ -14,19 +16,63 @@ import (
 )
 
 type nodeInfo struct {
-	Mon map[string]interface{} `json:"monitoring_state"`
-	Inv map[string]interface{} `json:"inventory_state"`
-	Cfg map[string]interface{} `json:"configuration_state"`
+	Name  string                 `json:"name"`
+	Mon   map[string]interface{} `json:"monitoring_state"`
+	Inv   map[string]interface{} `json:"inventory_state"`
+	Cfg   map[string]interface{} `json:"configuration_state"`
+	Maint map[string]interface{} `json:"maintenance_state"`
 }
 
-type nodesInfo map[string]nodeInfo
+type nodesPage struct {
+	Total int        `json:"total"`
+	Nodes []nodeInfo `json:"nodes"`
+}
+
+type nodeLog []struct {
+	Time    string `json:"time"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type nodeTransitions []struct {
+	Time       string `json:"time"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	FromState  string `json:"from_state"`
+	ToState    string `json:"to_state"`
+	Reason     string `json:"reason"`
+	Trigger    string `json:"trigger"`
+	Actor      string `json:"actor"`
+}
 
 type jobInfo map[string]interface{}
 
+type jobsInfo []jobInfo
+
+type jobSummary struct {
+	ID     uint64 `json:"id"`
+	Desc   string `json:"desc"`
+	Status string `json:"status"`
+}
+
+type queuedJob struct {
+	Priority int        `json:"priority"`
+	Job      jobSummary `json:"job"`
+}
+
+type jobQueue []queuedJob
+
+type jobsPage struct {
+	Total int          `json:"total"`
+	Jobs  []jobSummary `json:"jobs"`
+}
+
 type globalInfo map[string]interface{}
 
 type configInfo map[string]interface{}
 
+type specInfo map[string]interface{}
+
 // printHelper stores indent related metadat along with the value being printed
 type printHelper struct {
 	Indent string
@@ -87,6 +133,14 @@ var (
 			{{- end }}
 		{{- end }}
 	{{- end }}
+{{- end }}
+{{- define "resultsPrint" }}
+	{{- if . }}
+Results:
+		{{- range $node, $result := . }}
+    {{ $node }}	{{ $result }}
+		{{- end }}
+	{{- end }}
 {{- end }}
 	`
 	typeTemplate = template.Must(template.New("").Funcs(typeFuncs).Parse(typePrint))
@@ -100,13 +154,24 @@ var (
 	nodePrint = `
 {{- define "nodePrint" }}
 	{{- $invName := .Inv.name }}
+	{{- if .Name }}{{ $invName = .Name }}{{ end }}
 	{{- $indent := printf "%s:    " $invName }}
+	{{- if .Inv }}
 	{{- $invName }}: Inventory State{{ "\n" }}
 	{{- template "typePrint" newPrintHelper $indent .Inv }}
+	{{- end }}
+	{{- if .Mon }}
 	{{- $invName }}: Monitoring State{{ "\n" }}
 	{{- template "typePrint" newPrintHelper $indent .Mon }}
+	{{- end }}
+	{{- if .Cfg }}
 	{{- $invName }}: Configuration State{{ "\n" }}
 	{{- template "typePrint" newPrintHelper $indent .Cfg }}
+	{{- end }}
+	{{- if .Maint }}
+	{{- $invName }}: Maintenance State{{ "\n" }}
+	{{- template "typePrint" newPrintHelper $indent .Maint }}
+	{{- end }}
 {{ end }}
 `
 	nodeTemplate = template.Must(template.Must(typeTemplate.Clone()).Parse(nodePrint))
@@ -114,24 +179,63 @@ var (
 	oneNodePrint    = `{{- template "nodePrint" . }}`
 	oneNodeTemplate = template.Must(template.Must(nodeTemplate.Clone()).Parse(oneNodePrint))
 
-	multiNodePrint    = `{{- range $key, $val := . }}{{ template "nodePrint" $val }}{{ end }}`
+	multiNodePrint    = `Total: {{ .Total }}{{ "\n" }}{{- range $idx, $val := .Nodes }}{{ template "nodePrint" $val }}{{ end }}`
 	multiNodeTemplate = template.Must(template.Must(nodeTemplate.Clone()).Parse(multiNodePrint))
 
 	jobPrint = `
+{{- define "jobPrint" }}
+ID: {{ .id }}
 Description: {{ .desc }}
 Status: {{ .status }}
 Error: {{ .error }}
+{{- template "resultsPrint" .results }}
 Logs:
 {{ template "typePrint" newPrintHelper "    " .logs }}
-`
+{{ end }}
+{{- template "jobPrint" . }}`
 	jobTemplate = template.Must(template.Must(typeTemplate.Clone()).Parse(jobPrint))
 
+	multiJobPrint    = `{{- range $idx, $val := . }}{{ template "jobPrint" $val }}{{ end }}`
+	multiJobTemplate = template.Must(template.Must(jobTemplate.Clone()).Parse(multiJobPrint))
+
 	shortJobPrint = `
+{{- define "shortJobPrint" }}
+ID: {{ .id }}
 Description: {{ .desc }}
 Status: {{ .status }}
 Error: {{ .error }}
-`
+{{- template "resultsPrint" .results }}
+{{ end }}
+{{- template "shortJobPrint" . }}`
 	shortJobTemplate = template.Must(template.Must(typeTemplate.Clone()).Parse(shortJobPrint))
+
+	multiShortJobPrint    = `{{- range $idx, $val := . }}{{ template "shortJobPrint" $val }}{{ end }}`
+	multiShortJobTemplate = template.Must(template.Must(shortJobTemplate.Clone()).Parse(multiShortJobPrint))
+
+	jobsListPrint = `Total: {{ .Total }}
+{{- range $idx, $val := .Jobs }}
+{{ $val.ID }}	{{ $val.Status }}	{{ $val.Desc }}
+{{- end }}
+`
+	jobsListTemplate = template.Must(template.New("").Parse(jobsListPrint))
+
+	nodeLogPrint = `
+{{- range $idx, $val := . }}
+{{- $val.Time }}	{{ $val.Type }}	{{ $val.Message }}
+{{ end }}`
+	nodeLogTemplate = template.Must(template.New("").Parse(nodeLogPrint))
+
+	nodeTransitionsPrint = `
+{{- range $idx, $val := . }}
+{{- $val.Time }}	{{ $val.FromStatus }}/{{ $val.FromState }} -> {{ $val.ToStatus }}/{{ $val.ToState }}	{{ $val.Actor }}	{{ $val.Trigger }}	{{ $val.Reason }}
+{{ end }}`
+	nodeTransitionsTemplate = template.Must(template.New("").Parse(nodeTransitionsPrint))
+
+	jobQueuePrint = `
+{{- range $idx, $val := . }}
+{{- $val.Job.ID }}	{{ $val.Priority }}	{{ $val.Job.Desc }}
+{{ end }}`
+	jobQueueTemplate = template.Must(template.New("").Parse(jobQueuePrint))
 )
 
 type getCallback func(c *manager.Client, arg string, flags parsedFlags) error
@@ -149,6 +253,20 @@ func newGetActioner(getCb getCallback) *getActioner {
 func (nga *getActioner) procFlags(c *cli.Context) {
 	nga.flags.jsonOutput = c.Bool("json")
 	nga.flags.streamLogs = c.Bool("follow")
+	nga.flags.jobStatus = c.String("status")
+	nga.flags.jobNode = c.String("node")
+	nga.flags.offset = c.Int("offset")
+	nga.flags.limit = c.Int("limit")
+	nga.flags.from = c.String("from")
+	nga.flags.to = c.String("to")
+	nga.flags.nodeStatus = c.String("status")
+	nga.flags.state = c.String("state")
+	nga.flags.hostGroup = c.String("group")
+	nga.flags.monitorLabel = c.String("monitor-label")
+	nga.flags.addr = c.String("addr")
+	nga.flags.selector = c.String("selector")
+	nga.flags.sort = c.String("sort")
+	nga.flags.fields = c.String("fields")
 	return
 }
 
@@ -197,14 +315,84 @@ func nodeGet(c *manager.Client, nodeName string, flags parsedFlags) error {
 	return ppJSON(out)
 }
 
+func nodeLogGet(c *manager.Client, nodeName string, flags parsedFlags) error {
+	if nodeName == "" {
+		return errUnexpectedArgCount("1", 0)
+	}
+
+	out, err := c.GetNodeLog(nodeName)
+	if err != nil {
+		return err
+	}
+
+	if !flags.jsonOutput {
+		return printTemplate(out, nodeLogTemplate, &nodeLog{})
+	}
+
+	return ppJSON(out)
+}
+
+// parseTime returns the time specified in RFC3339 format, or the zero time if
+// none is specified
+func parseTime(name, val string) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, errInvalidTime(name, val)
+	}
+	return t, nil
+}
+
+func nodeTransitionsGet(c *manager.Client, nodeName string, flags parsedFlags) error {
+	if nodeName == "" {
+		return errUnexpectedArgCount("1", 0)
+	}
+
+	from, err := parseTime("from", flags.from)
+	if err != nil {
+		return err
+	}
+	to, err := parseTime("to", flags.to)
+	if err != nil {
+		return err
+	}
+
+	out, err := c.GetNodeTransitions(nodeName, from, to)
+	if err != nil {
+		return err
+	}
+
+	if !flags.jsonOutput {
+		return printTemplate(out, nodeTransitionsTemplate, &nodeTransitions{})
+	}
+
+	return ppJSON(out)
+}
+
 func nodesGet(c *manager.Client, noop string, flags parsedFlags) error {
-	out, err := c.GetAllNodes()
+	q := manager.NodesQuery{
+		Status:       flags.nodeStatus,
+		State:        flags.state,
+		HostGroup:    flags.hostGroup,
+		MonitorLabel: flags.monitorLabel,
+		Addr:         flags.addr,
+		Selector:     flags.selector,
+		Sort:         flags.sort,
+		Offset:       flags.offset,
+		Limit:        flags.limit,
+	}
+	if flags.fields != "" {
+		q.Fields = strings.Split(flags.fields, ",")
+	}
+	out, err := c.GetNodes(q)
 	if err != nil {
 		return err
 	}
 
 	if !flags.jsonOutput {
-		return printTemplate(out, multiNodeTemplate, &nodesInfo{})
+		return printTemplate(out, multiNodeTemplate, &nodesPage{})
 	}
 
 	return ppJSON(out)
@@ -233,10 +421,23 @@ func jobGet(c *manager.Client, job string, flags parsedFlags) error {
 		return err
 	}
 
+	// info of active jobs is returned as a list, as more than one job can be
+	// active at a time
+	var (
+		info      interface{} = &jobInfo{}
+		tmpl                  = jobTemplate
+		shortTmpl             = shortJobTemplate
+	)
+	if job == "active" {
+		info = &jobsInfo{}
+		tmpl = multiJobTemplate
+		shortTmpl = multiShortJobTemplate
+	}
+
 	// if streaming logs then we just print a short job info followed by the
 	// log stream
 	if flags.streamLogs {
-		if err := printTemplate(out, shortJobTemplate, &jobInfo{}); err != nil {
+		if err := printTemplate(out, shortTmpl, info); err != nil {
 			return err
 		}
 		logs, err := c.StreamLogs(job)
@@ -258,7 +459,33 @@ func jobGet(c *manager.Client, job string, flags parsedFlags) error {
 	}
 
 	if !flags.jsonOutput {
-		return printTemplate(out, jobTemplate, &jobInfo{})
+		return printTemplate(out, tmpl, info)
+	}
+
+	return ppJSON(out)
+}
+
+func jobsList(c *manager.Client, noop string, flags parsedFlags) error {
+	out, err := c.GetJobs(flags.jobStatus, flags.jobNode, flags.offset, flags.limit)
+	if err != nil {
+		return err
+	}
+
+	if !flags.jsonOutput {
+		return printTemplate(out, jobsListTemplate, &jobsPage{})
+	}
+
+	return ppJSON(out)
+}
+
+func jobQueueGet(c *manager.Client, noop string, flags parsedFlags) error {
+	out, err := c.GetJobQueue()
+	if err != nil {
+		return err
+	}
+
+	if !flags.jsonOutput {
+		return printTemplate(out, jobQueueTemplate, &jobQueue{})
 	}
 
 	return ppJSON(out)
@@ -276,3 +503,16 @@ func configGet(c *manager.Client, noop string, flags parsedFlags) error {
 
 	return ppJSON(out)
 }
+
+func specGet(c *manager.Client, noop string, flags parsedFlags) error {
+	out, err := c.GetSpec()
+	if err != nil {
+		return err
+	}
+
+	if !flags.jsonOutput {
+		return printTemplate(out, configTemplate, &specInfo{})
+	}
+
+	return ppJSON(out)
+}
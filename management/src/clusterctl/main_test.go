@@ -37,6 +37,16 @@ func (s *mainSuite) TestCommandArgValidationError(c *C) {
 			args:     []string{},
 			exptdErr: errUnexpectedArgCount(">=1", len([]string{})),
 		},
+		"node-labels": {
+			f:        validateNodeLabels,
+			args:     []string{"node1"},
+			exptdErr: errUnexpectedArgCount(">=2", len([]string{"node1"})),
+		},
+		"invalid-label": {
+			f:        validateNodeLabels,
+			args:     []string{"node1", "rack"},
+			exptdErr: errInvalidLabelArg("rack"),
+		},
 		"invalid-addr": {
 			f:        validateMultiNodeAddrs,
 			args:     []string{"1.2.3.4.5", ""},
@@ -49,3 +59,54 @@ func (s *mainSuite) TestCommandArgValidationError(c *C) {
 		c.Assert(err.Error(), Equals, test.exptdErr.Error(), Commentf("test key: %s", key))
 	}
 }
+
+func (s *mainSuite) TestParseNodeHostGroups(c *C) {
+	hostGroups, err := parseNodeHostGroups([]string{"node1", "node2"})
+	c.Assert(err, IsNil)
+	c.Assert(hostGroups, IsNil)
+
+	hostGroups, err = parseNodeHostGroups([]string{"node1=service-master", "node2=service-worker"})
+	c.Assert(err, IsNil)
+	c.Assert(hostGroups, DeepEquals, map[string]string{
+		"node1": "service-master",
+		"node2": "service-worker",
+	})
+
+	tests := map[string]struct {
+		args     []string
+		exptdErr error
+	}{
+		"mixed-args": {
+			args:     []string{"node1=service-master", "node2"},
+			exptdErr: errInvalidNodeHostGroup("node2"),
+		},
+		"empty-host-group": {
+			args:     []string{"node1="},
+			exptdErr: errInvalidNodeHostGroup("node1="),
+		},
+		"empty-node-name": {
+			args:     []string{"=service-master"},
+			exptdErr: errInvalidNodeHostGroup("=service-master"),
+		},
+	}
+	for key, test := range tests {
+		_, err := parseNodeHostGroups(test.args)
+		c.Assert(err.Error(), Equals, test.exptdErr.Error(), Commentf("test key: %s", key))
+	}
+}
+
+func (s *mainSuite) TestParseLabels(c *C) {
+	labels, err := parseLabels([]string{"rack=r1", "owner="})
+	c.Assert(err, IsNil)
+	c.Assert(labels, DeepEquals, map[string]string{"rack": "r1", "owner": ""})
+
+	_, err = parseLabels([]string{"=r1"})
+	c.Assert(err.Error(), Equals, errInvalidLabelArg("=r1").Error())
+}
+
+func (s *mainSuite) TestValidateNodesOrSelector(c *C) {
+	c.Assert(validateNodesOrSelector([]string{"node1"}, ""), IsNil)
+	c.Assert(validateNodesOrSelector([]string{}, "rack=r1"), IsNil)
+	c.Assert(validateNodesOrSelector([]string{"node1"}, "rack=r1").Error(), Equals, errNodesAndSelector().Error())
+	c.Assert(validateNodesOrSelector([]string{}, "").Error(), Equals, errUnexpectedArgCount(">=1", 0).Error())
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net"
 	"os"
+	"strings"
 
 	"github.com/codegangsta/cli"
 	"github.com/contiv/cluster/management/src/clusterm/manager"
@@ -31,6 +34,15 @@ func newPostActioner(validateCb validateCallback, postCb postCallback) *postActi
 func (npa *postActioner) procFlags(c *cli.Context) {
 	npa.flags.extraVars = c.String("extra-vars")
 	npa.flags.hostGroup = c.String("host-group")
+	npa.flags.priority = c.Int("priority")
+	npa.flags.wait = c.Bool("wait")
+	npa.flags.batchSize = c.Int("batch-size")
+	npa.flags.maxUnavailable = c.Int("max-unavailable")
+	npa.flags.reason = c.String("reason")
+	npa.flags.expiry = c.String("expiry")
+	npa.flags.force = c.Bool("force")
+	npa.flags.file = c.String("file")
+	npa.flags.selector = c.String("selector")
 }
 
 func (npa *postActioner) procArgs(c *cli.Context) {
@@ -51,19 +63,93 @@ func validateOneArg(args []string) error {
 	return nil
 }
 
+func validateTwoArgs(args []string) error {
+	if len(args) != 2 {
+		return errUnexpectedArgCount("2", len(args))
+	}
+	return nil
+}
+
 func nodeCommission(c *manager.Client, args []string, flags parsedFlags) error {
 	nodeName := args[0]
-	return c.PostNodeCommission(nodeName, flags.extraVars, flags.hostGroup)
+	return printJob(c.PostNodesCommissionWithOptions([]string{nodeName}, flags.extraVars, flags.hostGroup, jobOptions(flags)))
 }
 
 func nodeDecommission(c *manager.Client, args []string, flags parsedFlags) error {
 	nodeName := args[0]
-	return c.PostNodeDecommission(nodeName, flags.extraVars)
+	return printJob(c.PostNodesDecommissionWithOptions([]string{nodeName}, flags.extraVars, jobOptions(flags)))
+}
+
+func nodeReplace(c *manager.Client, args []string, flags parsedFlags) error {
+	nodeName, spareName := args[0], args[1]
+	return printJob(c.PostNodeReplace(nodeName, spareName, flags.extraVars, jobOptions(flags)))
 }
 
 func nodeUpdate(c *manager.Client, args []string, flags parsedFlags) error {
 	nodeName := args[0]
-	return c.PostNodeUpdate(nodeName, flags.extraVars, flags.hostGroup)
+	return printJob(c.PostNodesUpdateWithOptions([]string{nodeName}, flags.extraVars, flags.hostGroup, jobOptions(flags)))
+}
+
+func validateNodeLabels(args []string) error {
+	if len(args) < 2 {
+		return errUnexpectedArgCount(">=2", len(args))
+	}
+	_, err := parseLabels(args[1:])
+	return err
+}
+
+// parseLabels parses the args of the form <key>=<value> and returns the labels
+// keyed by their key. The value may be empty, to remove the label.
+func parseLabels(args []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errInvalidLabelArg(arg)
+		}
+		labels[kv[0]] = kv[1]
+	}
+	return labels, nil
+}
+
+func nodeLabel(c *manager.Client, args []string, flags parsedFlags) error {
+	nodeName := args[0]
+	labels, err := parseLabels(args[1:])
+	if err != nil {
+		return err
+	}
+	return c.PostNodeLabels(nodeName, labels)
+}
+
+func nodePurge(c *manager.Client, args []string, flags parsedFlags) error {
+	nodeName := args[0]
+	return printJob(c.DeleteNode(nodeName, jobOptions(flags)))
+}
+
+// jobOptions returns the options of a request that starts a job, as per the flags
+func jobOptions(flags parsedFlags) manager.JobOptions {
+	return manager.JobOptions{
+		Priority: flags.priority,
+		Wait:     flags.wait,
+		Force:    flags.force,
+	}
+}
+
+// printJob prints the info of the job that was started by a request. When the
+// request waited for the job to be done, a failed job is returned as an error.
+func printJob(out []byte, err error) error {
+	if err != nil {
+		return err
+	}
+
+	info := jobInfo{}
+	if err := printTemplate(out, shortJobTemplate, &info); err != nil {
+		return err
+	}
+	if info["status"] == manager.Errored.String() || info["status"] == manager.Cancelled.String() {
+		return errJobFailed(info["id"], info["error"])
+	}
+	return nil
 }
 
 func validateMultiNodeNames(args []string) error {
@@ -73,16 +159,103 @@ func validateMultiNodeNames(args []string) error {
 	return nil
 }
 
+// validateAnyArgs accepts any args. It is used by the commands whose args are
+// validated along with their flags, by their post callback.
+func validateAnyArgs(args []string) error {
+	return nil
+}
+
+// validateNodesOrSelector validates that either the node names are specified
+// as args or a label selector is specified, not both
+func validateNodesOrSelector(args []string, selector string) error {
+	if selector != "" {
+		if len(args) > 0 {
+			return errNodesAndSelector()
+		}
+		return nil
+	}
+	return validateMultiNodeNames(args)
+}
+
 func nodesCommission(c *manager.Client, args []string, flags parsedFlags) error {
-	return c.PostNodesCommission(args, flags.extraVars, flags.hostGroup)
+	if err := validateNodesOrSelector(args, flags.selector); err != nil {
+		return err
+	}
+	if flags.selector != "" {
+		return printJob(c.PostNodesCommissionBySelector(flags.selector, flags.extraVars, flags.hostGroup,
+			jobOptions(flags)))
+	}
+	hostGroups, err := parseNodeHostGroups(args)
+	if err != nil {
+		return err
+	}
+	if hostGroups == nil {
+		return printJob(c.PostNodesCommissionWithOptions(args, flags.extraVars, flags.hostGroup, jobOptions(flags)))
+	}
+	if flags.hostGroup != "" {
+		return errored.Errorf("host-group flag can't be specified along with the host-group of each node")
+	}
+	return printJob(c.PostNodesCommissionByHostGroup(hostGroups, flags.extraVars, jobOptions(flags)))
+}
+
+// parseNodeHostGroups parses the args of the form <node-name>=<host-group> and
+// returns the host-groups keyed by node name. It returns nil if none of the args
+// specify a host-group.
+func parseNodeHostGroups(args []string) (map[string]string, error) {
+	hostGroups := map[string]string{}
+	for _, arg := range args {
+		if !strings.Contains(arg, "=") {
+			continue
+		}
+		kv := strings.SplitN(arg, "=", 2)
+		if kv[0] == "" || kv[1] == "" {
+			return nil, errInvalidNodeHostGroup(arg)
+		}
+		hostGroups[kv[0]] = kv[1]
+	}
+	if len(hostGroups) == 0 {
+		return nil, nil
+	}
+	for _, arg := range args {
+		if !strings.Contains(arg, "=") {
+			return nil, errInvalidNodeHostGroup(arg)
+		}
+	}
+	return hostGroups, nil
 }
 
 func nodesDecommission(c *manager.Client, args []string, flags parsedFlags) error {
-	return c.PostNodesDecommission(args, flags.extraVars)
+	if err := validateNodesOrSelector(args, flags.selector); err != nil {
+		return err
+	}
+	if flags.selector != "" {
+		return printJob(c.PostNodesDecommissionBySelector(flags.selector, flags.extraVars, jobOptions(flags)))
+	}
+	return printJob(c.PostNodesDecommissionWithOptions(args, flags.extraVars, jobOptions(flags)))
 }
 
 func nodesUpdate(c *manager.Client, args []string, flags parsedFlags) error {
-	return c.PostNodesUpdate(args, flags.extraVars, flags.hostGroup)
+	if err := validateNodesOrSelector(args, flags.selector); err != nil {
+		return err
+	}
+	if flags.selector != "" {
+		return printJob(c.PostNodesUpdateBySelector(flags.selector, flags.extraVars, flags.hostGroup,
+			jobOptions(flags)))
+	}
+	return printJob(c.PostNodesUpdateWithOptions(args, flags.extraVars, flags.hostGroup, jobOptions(flags)))
+}
+
+func nodesUpgrade(c *manager.Client, args []string, flags parsedFlags) error {
+	return printJob(c.PostNodesUpgrade(args, flags.extraVars, flags.batchSize, flags.maxUnavailable,
+		jobOptions(flags)))
+}
+
+func nodesMaintenanceEnter(c *manager.Client, args []string, flags parsedFlags) error {
+	return printJob(c.PostNodesMaintenance(args, manager.MaintenanceEnter, flags.reason, flags.expiry, jobOptions(flags)))
+}
+
+func nodesMaintenanceExit(c *manager.Client, args []string, flags parsedFlags) error {
+	return printJob(c.PostNodesMaintenance(args, manager.MaintenanceExit, "", "", jobOptions(flags)))
 }
 
 func validateMultiNodeAddrs(args []string) error {
@@ -98,7 +271,7 @@ func validateMultiNodeAddrs(args []string) error {
 }
 
 func nodesDiscover(c *manager.Client, args []string, flags parsedFlags) error {
-	return c.PostNodesDiscover(args, flags.extraVars)
+	return printJob(c.PostNodesDiscoverWithOptions(args, flags.extraVars, jobOptions(flags)))
 }
 
 func validateZeroArgs(args []string) error {
@@ -112,6 +285,14 @@ func globalsSet(c *manager.Client, noop []string, flags parsedFlags) error {
 	return c.PostGlobals(flags.extraVars)
 }
 
+func jobCancel(c *manager.Client, args []string, noop parsedFlags) error {
+	return c.PostJobCancel(args[0])
+}
+
+func jobDequeue(c *manager.Client, args []string, noop parsedFlags) error {
+	return c.PostJobDequeue(args[0])
+}
+
 func configSet(c *manager.Client, args []string, noop parsedFlags) error {
 	var reader io.Reader
 
@@ -133,3 +314,64 @@ func configSet(c *manager.Client, args []string, noop parsedFlags) error {
 
 	return c.PostConfig(config)
 }
+
+// readSpec reads the JSON spec from the file, or from stdin when the file is '-'
+func readSpec(file string) (*manager.ClusterSpec, error) {
+	var reader io.Reader
+
+	switch file {
+	case "":
+		return nil, errored.Errorf("the file containing the spec should be specified")
+	case "-":
+		reader = bufio.NewReader(os.Stdin)
+	default:
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, errored.Errorf("failed to open spec file. Error: %v", err)
+		}
+		defer func() { f.Close() }()
+		reader = bufio.NewReader(f)
+	}
+
+	spec := &manager.ClusterSpec{}
+	if err := json.NewDecoder(reader).Decode(spec); err != nil {
+		return nil, errored.Errorf("failed to parse the spec. Error: %v", err)
+	}
+	return spec, nil
+}
+
+func specApply(c *manager.Client, noop []string, flags parsedFlags) error {
+	spec, err := readSpec(flags.file)
+	if err != nil {
+		return err
+	}
+	return printPlan(c.PostSpec(spec))
+}
+
+func specPlan(c *manager.Client, noop []string, flags parsedFlags) error {
+	spec, err := readSpec(flags.file)
+	if err != nil {
+		return err
+	}
+	return printPlan(c.PostSpecPlan(spec))
+}
+
+func specDelete(c *manager.Client, noop []string, flags parsedFlags) error {
+	return c.DeleteSpec()
+}
+
+// printPlan prints the actions that converge the cluster to a spec
+func printPlan(out []byte, err error) error {
+	if err != nil {
+		return err
+	}
+
+	info := specInfo{}
+	if err := printTemplate(out, configTemplate, &info); err != nil {
+		return err
+	}
+	if len(info) == 0 {
+		fmt.Println("the cluster has converged to the spec")
+	}
+	return nil
+}
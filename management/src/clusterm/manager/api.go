@@ -3,12 +3,16 @@ package manager
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/pprof"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/contiv/cluster/management/src/configuration"
@@ -36,9 +40,46 @@ type APIRequest struct {
 	Addrs     []string     `json:"addrs,omitempty"`
 	HostGroup string       `json:"host_group,omitempty"`
 	ExtraVars string       `json:"extra_vars,omitempty"`
+	Priority  int          `json:"priority,omitempty"`
 	Job       string       `json:"job,omitempty"`
 	Event     MonitorEvent `json:"monitor_event,omitempty"`
 	Config    *Config      `json:"config,omitempty"`
+	Query     url.Values   `json:"-"`
+
+	// Spec is used by the spec requests
+	Spec *ClusterSpec `json:"spec,omitempty"`
+
+	// NodeHostGroups is used by the commission request instead of Nodes and
+	// HostGroup, to commission the nodes in different host-groups. It is keyed
+	// by node name.
+	NodeHostGroups map[string]string `json:"node_host_groups,omitempty"`
+
+	// Spare is used by the replace request, to name the node that replaces the
+	// node specified in Nodes
+	Spare string `json:"spare,omitempty"`
+
+	// Force is used by the decommission and update requests, to act on the
+	// nodes even if they are not reachable
+	Force bool `json:"force,omitempty"`
+
+	// BatchSize and MaxUnavailable are used by the rolling upgrade
+	BatchSize      int `json:"batch_size,omitempty"`
+	MaxUnavailable int `json:"max_unavailable,omitempty"`
+
+	// Action, Reason and Expiry are used by the maintenance requests. Expiry is
+	// a duration like 30m or 4h, after which the nodes exit the maintenance.
+	Action string `json:"action,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Expiry string `json:"expiry,omitempty"`
+
+	// Labels is used by the node labels request. A label with empty value is
+	// removed from the node.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Selector is used by the commission, decommission and update requests
+	// instead of Nodes, to act on the nodes whose labels match it. It is a comma
+	// separated list of 'key=value' or 'key!=value'.
+	Selector string `json:"selector,omitempty"`
 }
 
 // errInvalidJSON is the error returned when an invalid json value is specified for
@@ -52,18 +93,42 @@ func errJobNotExist(job string) error {
 	return errored.Errorf("info for %q job doesn't exist", job)
 }
 
+// errMultipleActiveJobs is the error returned when the active job is
+// specified while more than one job is active
+func errMultipleActiveJobs() error {
+	return errored.Errorf("more than one job is active, please specify the id of the job")
+}
+
 // errInvalidJobLabel is the error returned when an invalid or empty job label
 // is specified as part of job info request
 func errInvalidJobLabel(job string) error {
 	return errored.Errorf("Invalid or empty job label specified: %q", job)
 }
 
+// errInvalidQueryValue is the error returned when an invalid value is specified
+// for a query parameter
+func errInvalidQueryValue(name, val string) error {
+	return errored.Errorf("Invalid value specified for query parameter %q: %q", name, val)
+}
+
 // errInvalidEventName is the error returned when an invalid or empty event name
 // is specified as part of monitor event request
 func errInvalidEventName(event string) error {
 	return errored.Errorf("Invalid or empty event name specified: %q", event)
 }
 
+// errNodesAndSelector is the error returned when both the nodes and a label
+// selector are specified as part of a request
+func errNodesAndSelector() error {
+	return errored.Errorf("either the nodes or a label selector should be specified, not both")
+}
+
+// errNodesInBody is the error returned when the nodes are specified in the body
+// of a request for the node in it's url
+func errNodesInBody() error {
+	return errored.Errorf("the node should be specified in the url only, not in the request body")
+}
+
 // errNilConfig is the error returned when a nil configuration value is
 // specified as part of clusterm configuration update request
 func errNilConfig() error {
@@ -82,11 +147,17 @@ func (m *Manager) apiLoop(servingCh chan struct{}) error {
 	}{
 		"GET": {
 			{"/" + getNodeInfo, emptyHdrs, get(m.oneNode)},
-			{"/" + GetNodesInfo, emptyHdrs, get(m.allNodes)},
+			{"/" + getNodeLog, emptyHdrs, get(m.nodeLogGet)},
+			{"/" + getNodeTransitions, emptyHdrs, get(m.nodeTransitionsGet)},
+			{"/" + GetNodesInfo, emptyHdrs, get(m.nodesGet)},
+			{"/" + GetNodesQuery, emptyHdrs, get(m.nodesQueryGet)},
 			{"/" + GetGlobals, emptyHdrs, get(m.globalsGet)},
 			{"/" + getJob, emptyHdrs, get(m.jobGet)},
+			{"/" + GetJobsInfo, emptyHdrs, get(m.jobsGet)},
+			{"/" + GetJobQueue, emptyHdrs, get(m.jobQueueGet)},
 			{"/" + getJobLog, emptyHdrs, get(m.logsGet)},
 			{"/" + GetPostConfig, emptyHdrs, get(m.configGet)},
+			{"/" + GetSpec, emptyHdrs, get(m.specGet)},
 			{"/" + getDebugPrefix + "/", emptyHdrs, pprof.Index},
 			{"/" + getDebugPrefix + "/cmdline", emptyHdrs, pprof.Cmdline},
 			{"/" + getDebugPrefix + "/profile", emptyHdrs, pprof.Profile},
@@ -95,13 +166,25 @@ func (m *Manager) apiLoop(servingCh chan struct{}) error {
 			{"/" + getDebug, emptyHdrs, pprof.Index},
 		},
 		"POST": {
-			{"/" + PostNodesCommission, jsonContentHdrs, post(m.nodesCommission)},
-			{"/" + PostNodesDecommission, jsonContentHdrs, post(m.nodesDecommission)},
-			{"/" + PostNodesUpdate, jsonContentHdrs, post(m.nodesUpdate)},
-			{"/" + PostNodesDiscover, jsonContentHdrs, post(m.nodesDiscover)},
+			{"/" + PostNodesCommission, jsonContentHdrs, postJob(m.nodesCommission)},
+			{"/" + PostNodesDecommission, jsonContentHdrs, postJob(m.nodesDecommission)},
+			{"/" + PostNodeReplace, jsonContentHdrs, postJob(m.nodeReplace)},
+			{"/" + PostNodesUpdate, jsonContentHdrs, postJob(m.nodesUpdate)},
+			{"/" + PostNodesUpgrade, jsonContentHdrs, postJob(m.nodesUpgrade)},
+			{"/" + PostNodesMaintenance, jsonContentHdrs, postJob(m.nodesMaintenance)},
+			{"/" + PostNodesDiscover, jsonContentHdrs, postJob(m.nodesDiscover)},
+			{"/" + postNodeLabels, jsonContentHdrs, post(m.nodeLabelsSet)},
 			{"/" + PostGlobals, jsonContentHdrs, post(m.globalsSet)},
 			{"/" + PostMonitorEvent, jsonContentHdrs, post(m.monitorEvent)},
 			{"/" + GetPostConfig, jsonContentHdrs, post(m.configSet)},
+			{"/" + postJobCancel, jsonContentHdrs, post(m.jobCancel)},
+			{"/" + postJobDequeue, jsonContentHdrs, post(m.jobDequeue)},
+			{"/" + PostDeleteSpec, jsonContentHdrs, postRead(m.specApply)},
+			{"/" + PostSpecPlan, jsonContentHdrs, postRead(m.specPlan)},
+		},
+		"DELETE": {
+			{"/" + PostDeleteSpec, emptyHdrs, post(m.specDelete)},
+			{"/" + deleteNode, emptyHdrs, postJob(m.nodePurge)},
 		},
 	}
 
@@ -129,52 +212,150 @@ func (m *Manager) apiLoop(servingCh chan struct{}) error {
 	return nil
 }
 
+// readPostRequest reads the request body and url variables of a POST request.
+// It is used for the DELETE requests as well.
+func readPostRequest(r *http.Request) (*APIRequest, error) {
+	// process data from request body, if any
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &APIRequest{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, req); err != nil {
+			return nil, err
+		}
+	}
+
+	// process data from url, if any
+	vars := mux.Vars(r)
+	if vars["tag"] != "" {
+		req.Nodes = append(req.Nodes, vars["tag"])
+	}
+	if vars["addr"] != "" {
+		req.Addrs = append(req.Addrs, vars["addr"])
+	}
+	if vars["job"] != "" {
+		req.Job = strings.TrimSpace(vars["job"])
+	}
+	req.Query = r.URL.Query()
+
+	// process query variables
+	req.ExtraVars, err = validateAndSanitizeEmptyExtraVars("extra_vars", req.ExtraVars)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 type postCallback func(req *APIRequest) error
 
 func post(postCb postCallback) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// process data from request body, if any
-		body, err := ioutil.ReadAll(r.Body)
+		req, err := readPostRequest(r)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		req := APIRequest{}
-		if len(body) > 0 {
-			if err := json.Unmarshal(body, &req); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
+		// call the handler
+		if err := postCb(req); err != nil {
+			http.Error(w,
+				err.Error(),
+				http.StatusInternalServerError)
+			return
 		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+}
 
-		// process data from url, if any
-		vars := mux.Vars(r)
-		if vars["tag"] != "" {
-			req.Nodes = append(req.Nodes, vars["tag"])
-		}
-		if vars["addr"] != "" {
-			req.Addrs = append(req.Addrs, vars["addr"])
+// postRead handles the POST requests that respond with a body, like the plan
+// of a spec
+func postRead(postCb getCallback) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := readPostRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
 
-		// process query variables
-		req.ExtraVars, err = validateAndSanitizeEmptyExtraVars("extra_vars", req.ExtraVars)
+		// call the handler
+		out, err := postCb(req)
 		if err != nil {
 			http.Error(w,
 				err.Error(),
 				http.StatusInternalServerError)
 			return
 		}
+		body, err := ioutil.ReadAll(out)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(body); err != nil {
+			logrus.Errorf("failed to write response bytes '%s'. Error: %v", body, err)
+		}
+	}
+}
+
+type postJobCallback func(req *APIRequest) (*Job, error)
+
+// postJob handles the POST requests that start a job. The response points to
+// the created job in it's Location header and carries the job's info in the
+// body. The response status is '202 Accepted' as the job runs asynchronously,
+// unless '?wait=true' is specified in which case the request blocks till the
+// job is done and the response carries the final status of the job.
+func postJob(postCb postJobCallback) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := readPostRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		wait := false
+		if val := req.Query.Get(postQueryWait); val != "" {
+			if wait, err = strconv.ParseBool(val); err != nil {
+				http.Error(w,
+					errInvalidQueryValue(postQueryWait, val).Error(),
+					http.StatusInternalServerError)
+				return
+			}
+		}
 
 		// call the handler
-		if err := postCb(&req); err != nil {
+		j, err := postCb(req)
+		if err != nil {
 			http.Error(w,
 				err.Error(),
 				http.StatusInternalServerError)
 			return
 		}
-		w.WriteHeader(http.StatusOK)
-		return
+
+		status := http.StatusAccepted
+		if wait {
+			j.Wait()
+			status = http.StatusOK
+		}
+
+		info := j.Info()
+		info.Logs = nil
+		out, err := json.Marshal(info)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", fmt.Sprintf("/%s/%d", GetJobPrefix, j.ID()))
+		w.WriteHeader(status)
+		if _, err := w.Write(out); err != nil {
+			logrus.Errorf("failed to write response bytes '%s'. Error: %v", out, err)
+		}
 	}
 }
 
@@ -192,26 +373,110 @@ func validateAndSanitizeEmptyExtraVars(errorPrefix, extraVars string) (string, e
 	return extraVars, nil
 }
 
-func (m *Manager) nodesCommission(req *APIRequest) error {
-	me := newWaitableEvent(newCommissionEvent(m, req.Nodes, req.ExtraVars, req.HostGroup))
+// postJobEvent posts an event that starts a job and waits for it's processing.
+// It returns the job started by the event. A job that gets queued behind other
+// jobs is not a failure of the request.
+func (m *Manager) postJobEvent(e jobEvent) (*Job, error) {
+	me := newWaitableEvent(e)
 	m.reqQ <- me
-	return me.waitForCompletion()
+	if err := me.waitForCompletion(); err != nil && err != errJobQueued {
+		return nil, err
+	}
+	return e.job(), nil
 }
 
-func (m *Manager) nodesDecommission(req *APIRequest) error {
-	me := newWaitableEvent(newDecommissionEvent(m, req.Nodes, req.ExtraVars))
-	m.reqQ <- me
-	return me.waitForCompletion()
+func (m *Manager) nodesCommission(req *APIRequest) (*Job, error) {
+	if len(req.NodeHostGroups) > 0 {
+		if len(req.Nodes) > 0 || req.HostGroup != "" || req.Selector != "" {
+			return nil, errored.Errorf("either the nodes and their host-group or the host-group of each node should be specified, not both")
+		}
+		return m.postJobEvent(newCommissionEventByHostGroup(m, req.NodeHostGroups, req.ExtraVars, req.Priority))
+	}
+	if req.Selector != "" {
+		if len(req.Nodes) > 0 {
+			return nil, errNodesAndSelector()
+		}
+		return m.postJobEvent(newCommissionEventBySelector(m, req.Selector, req.ExtraVars, req.HostGroup, req.Priority))
+	}
+	return m.postJobEvent(newCommissionEvent(m, req.Nodes, req.ExtraVars, req.HostGroup, req.Priority))
 }
 
-func (m *Manager) nodesUpdate(req *APIRequest) error {
-	me := newWaitableEvent(newUpdateEvent(m, req.Nodes, req.ExtraVars, req.HostGroup))
-	m.reqQ <- me
-	return me.waitForCompletion()
+func (m *Manager) nodesDecommission(req *APIRequest) (*Job, error) {
+	if req.Selector != "" {
+		if len(req.Nodes) > 0 {
+			return nil, errNodesAndSelector()
+		}
+		return m.postJobEvent(newDecommissionEventBySelector(m, req.Selector, req.ExtraVars, req.Priority, req.Force))
+	}
+	return m.postJobEvent(newDecommissionEvent(m, req.Nodes, req.ExtraVars, req.Priority, req.Force))
+}
+
+func (m *Manager) nodeReplace(req *APIRequest) (*Job, error) {
+	if len(req.Nodes) != 1 || req.Spare == "" {
+		return nil, errored.Errorf("exactly one node and a spare node should be specified for replace")
+	}
+	return m.postJobEvent(newReplaceEvent(m, req.Nodes[0], req.Spare, req.ExtraVars, req.Priority))
 }
 
-func (m *Manager) nodesDiscover(req *APIRequest) error {
-	me := newWaitableEvent(newDiscoverEvent(m, req.Addrs, req.ExtraVars))
+func (m *Manager) nodesUpdate(req *APIRequest) (*Job, error) {
+	if req.Selector != "" {
+		if len(req.Nodes) > 0 {
+			return nil, errNodesAndSelector()
+		}
+		return m.postJobEvent(newUpdateEventBySelector(m, req.Selector, req.ExtraVars, req.HostGroup, req.Priority, req.Force))
+	}
+	return m.postJobEvent(newUpdateEvent(m, req.Nodes, req.ExtraVars, req.HostGroup, req.Priority, req.Force))
+}
+
+func (m *Manager) nodesUpgrade(req *APIRequest) (*Job, error) {
+	return m.postJobEvent(newUpgradeEvent(m, req.Nodes, req.ExtraVars, req.BatchSize, req.MaxUnavailable, req.Priority))
+}
+
+func (m *Manager) nodesMaintenance(req *APIRequest) (*Job, error) {
+	var expiry time.Duration
+	if req.Expiry != "" {
+		var err error
+		if expiry, err = time.ParseDuration(req.Expiry); err != nil || expiry <= 0 {
+			return nil, errInvalidMaintenanceExpiry(req.Expiry)
+		}
+	}
+	return m.postJobEvent(newMaintenanceEvent(m, req.Nodes, req.Action, req.Reason, expiry))
+}
+
+// urlNode returns the node specified in the url of a request. The node from the
+// url is appended to the nodes from the request body, so the request is rejected
+// if the body specifies any nodes, which would be acted upon otherwise.
+func urlNode(req *APIRequest) (string, error) {
+	if len(req.Nodes) != 1 {
+		return "", errNodesInBody()
+	}
+	return req.Nodes[0], nil
+}
+
+func (m *Manager) nodePurge(req *APIRequest) (*Job, error) {
+	name, err := urlNode(req)
+	if err != nil {
+		return nil, err
+	}
+	force := false
+	if val := req.Query.Get(deleteQueryForce); val != "" {
+		if force, err = strconv.ParseBool(val); err != nil {
+			return nil, errInvalidQueryValue(deleteQueryForce, val)
+		}
+	}
+	return m.postJobEvent(newPurgeEvent(m, []string{name}, force))
+}
+
+func (m *Manager) nodesDiscover(req *APIRequest) (*Job, error) {
+	return m.postJobEvent(newDiscoverEvent(m, req.Addrs, req.ExtraVars, req.Priority))
+}
+
+func (m *Manager) nodeLabelsSet(req *APIRequest) error {
+	name, err := urlNode(req)
+	if err != nil {
+		return err
+	}
+	me := newWaitableEvent(newSetLabelsEvent(m, name, req.Labels))
 	m.reqQ <- me
 	return me.waitForCompletion()
 }
@@ -222,6 +487,25 @@ func (m *Manager) globalsSet(req *APIRequest) error {
 	return me.waitForCompletion()
 }
 
+func (m *Manager) jobCancel(req *APIRequest) error {
+	j, err := m.findActiveJob(req.Job)
+	if err != nil {
+		return err
+	}
+
+	// the job's done callback takes care of setting the status of the nodes
+	// once the runner has stopped
+	return j.Cancel()
+}
+
+func (m *Manager) jobDequeue(req *APIRequest) error {
+	id, err := strconv.ParseUint(req.Job, 10, 64)
+	if err != nil {
+		return errInvalidJobLabel(req.Job)
+	}
+	return m.dequeueJob(id)
+}
+
 func (m *Manager) monitorEvent(req *APIRequest) error {
 	var (
 		e     event
@@ -264,6 +548,7 @@ func get(getCb getCallback) http.HandlerFunc {
 		req := &APIRequest{
 			Nodes: []string{strings.TrimSpace(vars["tag"])},
 			Job:   strings.TrimSpace(vars["job"]),
+			Query: r.URL.Query(),
 		}
 		out, err := getCb(req)
 		if err != nil {
@@ -305,12 +590,55 @@ func (m *Manager) oneNode(req *APIRequest) (io.Reader, error) {
 	return bytes.NewReader(out), nil
 }
 
-func (m *Manager) allNodes(noop *APIRequest) (io.Reader, error) {
-	out, err := json.Marshal(m.nodes)
+func (m *Manager) nodeLogGet(req *APIRequest) (io.Reader, error) {
+	if _, err := m.findNode(req.Nodes[0]); err != nil {
+		return nil, err
+	}
+
+	logs, err := m.inventory.GetAssetLogs(req.Nodes[0])
 	if err != nil {
 		return nil, err
 	}
-	return bytes.NewReader(out), nil
+	return marshalReader(logs)
+}
+
+func (m *Manager) nodeTransitionsGet(req *APIRequest) (io.Reader, error) {
+	if _, err := m.findNode(req.Nodes[0]); err != nil {
+		return nil, err
+	}
+
+	from, err := parseQueryTime(req.Query, transitionsQueryFrom)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseQueryTime(req.Query, transitionsQueryTo)
+	if err != nil {
+		return nil, err
+	}
+	transitions, err := m.inventory.GetAssetTransitions(req.Nodes[0], from, to)
+	if err != nil {
+		return nil, err
+	}
+	return marshalReader(transitions)
+}
+
+// nodesGet returns the info of all the nodes keyed by their name
+func (m *Manager) nodesGet(noop *APIRequest) (io.Reader, error) {
+	return marshalReader(m.nodes)
+}
+
+// nodesQueryGet returns a page of the nodes that match the query, as NodesInfo
+func (m *Manager) nodesQueryGet(req *APIRequest) (io.Reader, error) {
+	q, err := parseNodesQuery(req.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := m.getNodes(q)
+	if err != nil {
+		return nil, err
+	}
+	return marshalReader(nodes)
 }
 
 func (m *Manager) globalsGet(noop *APIRequest) (io.Reader, error) {
@@ -331,21 +659,42 @@ func (m *Manager) globalsGet(noop *APIRequest) (io.Reader, error) {
 }
 
 func (m *Manager) jobGet(req *APIRequest) (io.Reader, error) {
-	var j *Job
+	var jobs interface{}
 	switch req.Job {
 	case jobLabelActive:
-		j = m.activeJob
+		activeJobs := m.getActiveJobs()
+		if len(activeJobs) == 0 {
+			return nil, errJobNotExist(req.Job)
+		}
+		jobs = activeJobs
 	case jobLabelLast:
-		j = m.lastJob
+		if lastJob := m.getLastJob(); lastJob != nil {
+			jobs = lastJob
+		} else if lastJob := m.getLastJobFromHistory(); lastJob != nil {
+			jobs = lastJob
+		} else {
+			return nil, errJobNotExist(req.Job)
+		}
 	default:
-		return nil, errInvalidJobLabel(req.Job)
+		id, err := strconv.ParseUint(req.Job, 10, 64)
+		if err != nil {
+			return nil, errInvalidJobLabel(req.Job)
+		}
+		if jobs, err = m.getJobInfo(id); err != nil {
+			return nil, err
+		}
 	}
 
-	if j == nil {
-		return nil, errJobNotExist(req.Job)
+	out, err := json.Marshal(jobs)
+	if err != nil {
+		return nil, err
 	}
 
-	out, err := json.Marshal(j)
+	return bytes.NewReader(out), nil
+}
+
+func (m *Manager) jobQueueGet(noop *APIRequest) (io.Reader, error) {
+	out, err := json.Marshal(m.getJobQueueInfo())
 	if err != nil {
 		return nil, err
 	}
@@ -353,19 +702,102 @@ func (m *Manager) jobGet(req *APIRequest) (io.Reader, error) {
 	return bytes.NewReader(out), nil
 }
 
-func (m *Manager) logsGet(req *APIRequest) (io.Reader, error) {
+// parseQueryInt returns the integer value of a query parameter or the
+// default value if the parameter is not specified
+func parseQueryInt(query url.Values, name string, defVal int) (int, error) {
+	val := query.Get(name)
+	if val == "" {
+		return defVal, nil
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil || i < 0 {
+		return 0, errInvalidQueryValue(name, val)
+	}
+	return i, nil
+}
+
+// parseQueryTime returns the time value, in RFC3339 format, of a query
+// parameter or the zero time if the parameter is not specified
+func parseQueryTime(query url.Values, name string) (time.Time, error) {
+	val := query.Get(name)
+	if val == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, errInvalidQueryValue(name, val)
+	}
+	return t, nil
+}
+
+func (m *Manager) jobsGet(req *APIRequest) (io.Reader, error) {
+	var err error
+	filter := jobsFilter{
+		status: req.Query.Get(jobsQueryStatus),
+		node:   req.Query.Get(jobsQueryNode),
+	}
+	if filter.offset, err = parseQueryInt(req.Query, jobsQueryOffset, 0); err != nil {
+		return nil, err
+	}
+	if filter.limit, err = parseQueryInt(req.Query, jobsQueryLimit, 0); err != nil {
+		return nil, err
+	}
+
+	jobs, err := m.getJobsFromHistory(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(out), nil
+}
+
+// findActiveJob returns the active job specified by label. The label can be
+// 'active', if there is only one active job, or the id of an active job
+func (m *Manager) findActiveJob(label string) (*Job, error) {
 	var j *Job
-	switch req.Job {
+	switch label {
 	case jobLabelActive:
-		j = m.activeJob
-	case jobLabelLast:
-		j = m.lastJob
+		activeJobs := m.getActiveJobs()
+		if len(activeJobs) > 1 {
+			return nil, errMultipleActiveJobs()
+		}
+		if len(activeJobs) == 1 {
+			j = activeJobs[0]
+		}
 	default:
-		return nil, errInvalidJobLabel(req.Job)
+		id, err := strconv.ParseUint(label, 10, 64)
+		if err != nil {
+			return nil, errInvalidJobLabel(label)
+		}
+		for _, aj := range m.getActiveJobs() {
+			if aj.ID() == id {
+				j = aj
+			}
+		}
 	}
 
 	if j == nil {
-		return nil, errJobNotExist(req.Job)
+		return nil, errJobNotExist(label)
+	}
+	return j, nil
+}
+
+func (m *Manager) logsGet(req *APIRequest) (io.Reader, error) {
+	var (
+		j   *Job
+		err error
+	)
+	if req.Job == jobLabelLast {
+		if j = m.getLastJob(); j == nil {
+			return nil, errJobNotExist(req.Job)
+		}
+	} else if j, err = m.findActiveJob(req.Job); err != nil {
+		return nil, err
 	}
 
 	r, w := io.Pipe()
@@ -376,6 +808,67 @@ func (m *Manager) logsGet(req *APIRequest) (io.Reader, error) {
 	return r, nil
 }
 
+// errNilSpec is the error returned when a nil spec is specified as part of
+// a spec request
+func errNilSpec() error {
+	return errored.Errorf("nil value specified for the spec")
+}
+
+func (m *Manager) specApply(req *APIRequest) (io.Reader, error) {
+	if req.Spec == nil {
+		return nil, errNilSpec()
+	}
+
+	e := newApplySpecEvent(m, req.Spec)
+	me := newWaitableEvent(e)
+	m.reqQ <- me
+	if err := me.waitForCompletion(); err != nil {
+		return nil, err
+	}
+	return marshalReader(e._plan)
+}
+
+func (m *Manager) specPlan(req *APIRequest) (io.Reader, error) {
+	if req.Spec == nil {
+		return nil, errNilSpec()
+	}
+	return m.planSpecInfo(req.Spec, true)
+}
+
+func (m *Manager) specGet(noop *APIRequest) (io.Reader, error) {
+	return m.planSpecInfo(nil, false)
+}
+
+// planSpecInfo returns the plan of the spec, or the applied spec along with
+// it's plan when spec is nil
+func (m *Manager) planSpecInfo(spec *ClusterSpec, planOnly bool) (io.Reader, error) {
+	e := newPlanSpecEvent(m, spec)
+	me := newWaitableEvent(e)
+	m.reqQ <- me
+	if err := me.waitForCompletion(); err != nil {
+		return nil, err
+	}
+	if planOnly {
+		return marshalReader(e._info.Plan)
+	}
+	return marshalReader(e._info)
+}
+
+func (m *Manager) specDelete(noop *APIRequest) error {
+	me := newWaitableEvent(newApplySpecEvent(m, nil))
+	m.reqQ <- me
+	return me.waitForCompletion()
+}
+
+// marshalReader returns a reader over the JSON encoding of the value
+func marshalReader(v interface{}) (io.Reader, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(out), nil
+}
+
 func (m *Manager) configGet(noop *APIRequest) (io.Reader, error) {
 	out, err := json.Marshal(m.config)
 	if err != nil {
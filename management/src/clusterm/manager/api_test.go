@@ -2,7 +2,17 @@
 
 package manager
 
-import . "gopkg.in/check.v1"
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
 
 type apiSuite struct {
 }
@@ -42,6 +52,49 @@ func (s *apiSuite) TestPostHandlerErrorCase(c *C) {
 			},
 			exptdErr: errNilConfig(),
 		},
+		"job-dequeue-invalid-id": {
+			cb: m.jobDequeue,
+			arg: &APIRequest{
+				Job: "active",
+			},
+			exptdErr: errInvalidJobLabel("active"),
+		},
+		"job-dequeue-non-existent": {
+			cb: m.jobDequeue,
+			arg: &APIRequest{
+				Job: "5",
+			},
+			exptdErr: errJobNotQueued("5"),
+		},
+		"job-cancel-invalid-label": {
+			cb: m.jobCancel,
+			arg: &APIRequest{
+				Job: "foo",
+			},
+			exptdErr: errInvalidJobLabel("foo"),
+		},
+		"job-cancel-non-existent": {
+			cb: m.jobCancel,
+			arg: &APIRequest{
+				Job: "active",
+			},
+			exptdErr: errJobNotExist("active"),
+		},
+		"job-cancel-non-existent-id": {
+			cb: m.jobCancel,
+			arg: &APIRequest{
+				Job: "5",
+			},
+			exptdErr: errJobNotExist("5"),
+		},
+		"labels-set-nodes-in-body": {
+			cb: m.nodeLabelsSet,
+			arg: &APIRequest{
+				Nodes:  []string{"node2", "node1"},
+				Labels: map[string]string{"rack": "r1"},
+			},
+			exptdErr: errNodesInBody(),
+		},
 	}
 
 	for key, test := range tests {
@@ -51,6 +104,67 @@ func (s *apiSuite) TestPostHandlerErrorCase(c *C) {
 	}
 }
 
+func (s *apiSuite) TestPostJobHandler(c *C) {
+	mgr := &Manager{reqQ: make(chan event, 10)}
+	tests := map[string]struct {
+		query       string
+		exptdCode   int
+		exptdStatus string
+	}{
+		"no-wait": {
+			query:       "",
+			exptdCode:   http.StatusAccepted,
+			exptdStatus: Queued.String(),
+		},
+		"wait": {
+			query:       "?" + postQueryWait + "=true",
+			exptdCode:   http.StatusOK,
+			exptdStatus: Complete.String(),
+		},
+		"invalid-wait": {
+			query:     "?" + postQueryWait + "=foo",
+			exptdCode: http.StatusInternalServerError,
+		},
+	}
+
+	for key, test := range tests {
+		var j *Job
+		hdlr := postJob(func(req *APIRequest) (*Job, error) {
+			j = mgr.newJob(key, func(cancelCh CancelChannel, logs io.Writer) error {
+				return nil
+			}, func(status JobStatus, errVal error) {}, nil)
+			if req.Query.Get(postQueryWait) != "" {
+				go j.Run()
+			}
+			return j, nil
+		})
+		r, err := http.NewRequest("POST", "/"+PostNodesCommission+test.query, strings.NewReader(""))
+		c.Assert(err, IsNil, Commentf("key: %s", key))
+		w := httptest.NewRecorder()
+		hdlr(w, r)
+		c.Assert(w.Code, Equals, test.exptdCode, Commentf("key: %s", key))
+		if test.exptdCode == http.StatusInternalServerError {
+			c.Assert(strings.TrimSpace(w.Body.String()), Equals,
+				errInvalidQueryValue(postQueryWait, "foo").Error(), Commentf("key: %s", key))
+			continue
+		}
+		c.Assert(w.Header().Get("Location"), Equals,
+			fmt.Sprintf("/%s/%d", GetJobPrefix, j.ID()), Commentf("key: %s", key))
+		info := &JobInfo{}
+		c.Assert(json.Unmarshal(w.Body.Bytes(), info), IsNil, Commentf("key: %s", key))
+		c.Assert(info.ID, Equals, j.ID(), Commentf("key: %s", key))
+		c.Assert(info.Status, Equals, test.exptdStatus, Commentf("key: %s", key))
+	}
+}
+
+func (s *apiSuite) TestNodePurgeNodesInBody(c *C) {
+	m := Manager{}
+	// the node from the url is appended to the ones from the body
+	_, err := m.nodePurge(&APIRequest{Nodes: []string{"node2", "node1"}, Query: url.Values{}})
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, errNodesInBody().Error())
+}
+
 // some Get handlers have static error checks, this test validates those
 func (s *apiSuite) TestGetHandlerErrorCase(c *C) {
 	m := Manager{}
@@ -78,6 +192,34 @@ func (s *apiSuite) TestGetHandlerErrorCase(c *C) {
 			},
 			exptdErr: errJobNotExist("active"),
 		},
+		"job-non-existent-id": {
+			cb: m.jobGet,
+			arg: &APIRequest{
+				Job: "5",
+			},
+			exptdErr: errJobIDNotExist(5),
+		},
+		"jobs-invalid-status": {
+			cb: m.jobsGet,
+			arg: &APIRequest{
+				Query: url.Values{jobsQueryStatus: []string{"foo"}},
+			},
+			exptdErr: errInvalidJobStatus("foo"),
+		},
+		"jobs-invalid-offset": {
+			cb: m.jobsGet,
+			arg: &APIRequest{
+				Query: url.Values{jobsQueryOffset: []string{"-1"}},
+			},
+			exptdErr: errInvalidQueryValue(jobsQueryOffset, "-1"),
+		},
+		"jobs-invalid-limit": {
+			cb: m.jobsGet,
+			arg: &APIRequest{
+				Query: url.Values{jobsQueryLimit: []string{"foo"}},
+			},
+			exptdErr: errInvalidQueryValue(jobsQueryLimit, "foo"),
+		},
 		"logs-invalid-label": {
 			cb: m.jobGet,
 			arg: &APIRequest{
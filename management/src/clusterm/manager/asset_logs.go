@@ -0,0 +1,52 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+)
+
+// addAssetLog records an event in the logs of a node's asset in the inventory,
+// so that the history of the node, like why it failed provisioning, can be
+// looked up later. The logs are best effort, a failure to record an entry is
+// logged and ignored. The status transitions are recorded by the inventory
+// itself.
+func (m *Manager) addAssetLog(name, mtype, format string, args ...interface{}) {
+	if m.inventory == nil || m.inventory.GetAsset(name) == nil {
+		return
+	}
+	if err := m.inventory.AddAssetLog(name, mtype, fmt.Sprintf(format, args...)); err != nil {
+		logrus.Errorf("failed to add log for asset %q. Error: %v", name, err)
+	}
+}
+
+// logJobStarted records the start of a job in the logs of it's nodes
+func (m *Manager) logJobStarted(j *Job) {
+	for _, name := range j.nodes {
+		m.addAssetLog(name, inventory.LogTypeInfo, "job %d started: %s", j.id, j.desc)
+	}
+}
+
+// logJobDone records the outcome of a job in the logs of it's nodes. A failed
+// job is recorded as an error on the nodes where it failed, as per the job's
+// results, or on all nodes when it has no results. A cancelled job is recorded
+// as a warning.
+func (m *Manager) logJobDone(j *Job) {
+	status, errVal := j.Status()
+	results := j.Results()
+	for _, name := range j.nodes {
+		result, ok := results[name]
+		switch {
+		case status == Cancelled:
+			m.addAssetLog(name, inventory.LogTypeWarning, "job %d was cancelled", j.id)
+		case status != Errored:
+			m.addAssetLog(name, inventory.LogTypeInfo, "job %d completed", j.id)
+		case ok && result == configuration.HostResultOK:
+			m.addAssetLog(name, inventory.LogTypeWarning, "job %d failed on other nodes. Error: %v", j.id, errVal)
+		default:
+			m.addAssetLog(name, inventory.LogTypeError, "job %d failed. Error: %v", j.id, errVal)
+		}
+	}
+}
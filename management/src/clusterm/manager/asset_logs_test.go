@@ -0,0 +1,147 @@
+// +build unittest
+
+package manager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/cluster/management/src/mock"
+	"github.com/contiv/errored"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type assetLogsSuite struct {
+}
+
+var _ = Suite(&assetLogsSuite{})
+
+// recordingClient is an inventory client that keeps the asset logs and the
+// transition history in memory
+type recordingClient struct {
+	*mock.MockSubsysClient
+	logs        map[string][]inventory.AssetLog
+	transitions map[string][]inventory.AssetTransition
+}
+
+func (r *recordingClient) AddAssetLog(tag, mtype, message string) error {
+	r.logs[tag] = append(r.logs[tag], inventory.AssetLog{Type: mtype, Message: message})
+	return nil
+}
+
+func (r *recordingClient) GetAssetLogs(tag string) ([]inventory.AssetLog, error) {
+	return r.logs[tag], nil
+}
+
+func (r *recordingClient) SetAssetFacts(tag string, facts inventory.AssetFacts) error {
+	return nil
+}
+
+func (r *recordingClient) AddAssetTransition(tag string, t inventory.AssetTransition) error {
+	r.transitions[tag] = append(r.transitions[tag], t)
+	return nil
+}
+
+func (r *recordingClient) GetAssetTransitions(tag string, from, to time.Time) ([]inventory.AssetTransition, error) {
+	return r.transitions[tag], nil
+}
+
+// newAssetLogsTestManager returns a manager with reachable nodes in the
+// specified status, whose inventory records the asset logs
+func newAssetLogsTestManager(c *C, ctrl *gomock.Controller, assets map[string]inventory.AssetStatus,
+	err error) (*Manager, *recordingClient) {
+	mgr := newVerifyTestManager(c, ctrl, assets, err, nil)
+	mgr.config.Ansible.VerifyPlaybook = ""
+	mClient := mock.NewMockSubsysClient(ctrl)
+	mClient.EXPECT().SetAssetStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	mClient.EXPECT().SetAssetConfig(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	client := &recordingClient{
+		MockSubsysClient: mClient,
+		logs:             map[string][]inventory.AssetLog{},
+		transitions:      map[string][]inventory.AssetTransition{},
+	}
+	mgr.inventory = inventory.NewGeneralSubsys(client)
+	mgr.inventory.SetTransitionCauser(mgr.transitionCause)
+	for name, status := range assets {
+		asset := inventory.NewAssetWithState(client, name, status, inventory.Discovered)
+		c.Assert(mgr.inventory.(*inventory.GeneralSubsys).RestoreAsset(name, asset), IsNil)
+		mgr.nodes[name].Inv = asset
+	}
+	return mgr, client
+}
+
+// logMessages returns the types and messages of the logs
+func logMessages(logs []inventory.AssetLog) []string {
+	msgs := []string{}
+	for _, l := range logs {
+		msgs = append(msgs, l.Type+": "+l.Message)
+	}
+	return msgs
+}
+
+// waitForJobReset waits for the job to be reset, after it's outcome is recorded
+func waitForJobReset(c *C, mgr *Manager, j *Job) {
+	for i := 0; i < 50 && mgr.getLastJob() != j; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	c.Assert(mgr.getLastJob(), Equals, j)
+}
+
+func (s *assetLogsSuite) TestJobFailureLogs(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr, client := newAssetLogsTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Unallocated,
+	}, errored.Errorf("test failure"))
+	e := newCommissionEvent(mgr, []string{"node1"}, configuration.DefaultValidJSON, ansibleMasterGroupName, 0)
+	c.Assert(e.process(), IsNil)
+	waitForJobReset(c, mgr, e._job)
+
+	msgs := logMessages(client.logs["node1"])
+	c.Assert(msgs, HasLen, 4)
+	c.Assert(msgs[0], Matches, `INFORMATIONAL: status changed from "Unallocated" to "Provisioning".*`)
+	c.Assert(msgs[1], Matches, `INFORMATIONAL: job [0-9]+ started: commissionEvent.*`)
+	c.Assert(msgs[2], Matches, `INFORMATIONAL: status changed from "Provisioning" to "Unallocated".*`)
+	c.Assert(msgs[3], Matches, `ERROR: job [0-9]+ failed. Error: .*test failure.*`)
+
+	// the logs are served by the REST endpoint
+	out, err := mgr.nodeLogGet(&APIRequest{Nodes: []string{"node1"}})
+	c.Assert(err, IsNil)
+	body, err := ioutil.ReadAll(out)
+	c.Assert(err, IsNil)
+	logs := []inventory.AssetLog{}
+	c.Assert(json.Unmarshal(body, &logs), IsNil)
+	c.Assert(logMessages(logs), DeepEquals, msgs)
+
+	_, err = mgr.nodeLogGet(&APIRequest{Nodes: []string{"node2"}})
+	c.Assert(err, ErrorMatches, ".*doesn't exists.*")
+}
+
+func (s *assetLogsSuite) TestJobDoneLogs(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr, client := newAssetLogsTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+		"node2": inventory.Allocated,
+		"node3": inventory.Allocated,
+	}, nil)
+	j := mgr.newJob("test job", nil, nil, []string{"node1", "node2", "node3"})
+	j.setStatus(Errored, errored.Errorf("test failure"))
+	j.setResults(map[string]configuration.HostResult{
+		"node1": configuration.HostResultOK,
+		"node2": configuration.HostResultFailed,
+	})
+	mgr.logJobDone(j)
+	c.Assert(logMessages(client.logs["node1"]), DeepEquals,
+		[]string{"WARNING: job 1 failed on other nodes. Error: test failure"})
+	c.Assert(logMessages(client.logs["node2"]), DeepEquals,
+		[]string{"ERROR: job 1 failed. Error: test failure"})
+	c.Assert(logMessages(client.logs["node3"]), DeepEquals,
+		[]string{"ERROR: job 1 failed. Error: test failure"})
+}
@@ -0,0 +1,237 @@
+package manager
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+)
+
+// autoCommissionConfig is the policy to commission the newly discovered nodes
+// automatically
+type autoCommissionConfig struct {
+	Enabled bool `json:"enabled"`
+	// LabelPattern and SerialPattern are the regular expressions that the label
+	// and serial number of a discovered node shall match for it to be
+	// auto-commissioned. An empty pattern matches all nodes.
+	LabelPattern  string `json:"label_pattern"`
+	SerialPattern string `json:"serial_pattern"`
+	// Masters is the number of master nodes to have in the cluster. The
+	// discovered nodes are commissioned as masters till there are as many,
+	// the rest are commissioned in HostGroup.
+	Masters   int    `json:"masters"`
+	HostGroup string `json:"host_group"`
+	// BatchWindow is the duration, like 30s, for which the discovered nodes
+	// are collected before they are commissioned, so that the nodes that are
+	// powered on together are commissioned together.
+	BatchWindow string `json:"batch_window"`
+}
+
+func errInvalidAutoCommissionConfig(field, val string, err error) error {
+	return errored.Errorf("invalid auto-commission %s specified: %q. Error: %v", field, val, err)
+}
+
+// autoCommissioner holds the parsed auto-commission policy along with the
+// discovered nodes that are waiting to be commissioned
+type autoCommissioner struct {
+	labelRe   *regexp.Regexp
+	serialRe  *regexp.Regexp
+	masters   int
+	hostGroup string
+	window    time.Duration
+
+	pending []string
+}
+
+// newAutoCommissioner validates the auto-commission policy against the defined
+// host-groups and returns the autoCommissioner. It returns nil if the policy is
+// not enabled.
+func newAutoCommissioner(config autoCommissionConfig, groups map[string]hostGroupConfig) (*autoCommissioner, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	var err error
+	ac := &autoCommissioner{
+		masters:   config.Masters,
+		hostGroup: config.HostGroup,
+	}
+	if ac.labelRe, err = regexp.Compile(config.LabelPattern); err != nil {
+		return nil, errInvalidAutoCommissionConfig("label pattern", config.LabelPattern, err)
+	}
+	if ac.serialRe, err = regexp.Compile(config.SerialPattern); err != nil {
+		return nil, errInvalidAutoCommissionConfig("serial pattern", config.SerialPattern, err)
+	}
+	if ac.window, err = time.ParseDuration(config.BatchWindow); err != nil {
+		return nil, errInvalidAutoCommissionConfig("batch window", config.BatchWindow, err)
+	}
+	if ac.masters < 0 {
+		return nil, errInvalidAutoCommissionConfig("masters", fmt.Sprintf("%d", ac.masters),
+			errored.Errorf("it should be atleast 0"))
+	}
+	if _, ok := groups[ac.hostGroup]; !ok {
+		return nil, errInvalidAutoCommissionConfig("host-group", ac.hostGroup,
+			errored.Errorf("it should be one of the defined host-groups %v", hostGroupNames(groups)))
+	}
+	if _, ok := groups[ansibleMasterGroupName]; !ok && ac.masters > 0 {
+		return nil, errInvalidAutoCommissionConfig("masters", fmt.Sprintf("%d", ac.masters),
+			errored.Errorf("host-group %q is not defined", ansibleMasterGroupName))
+	}
+	return ac, nil
+}
+
+// matches returns true if a node with the specified label and serial number
+// shall be auto-commissioned
+func (ac *autoCommissioner) matches(label, serial string) bool {
+	return ac.labelRe.MatchString(label) && ac.serialRe.MatchString(serial)
+}
+
+// queueAutoCommission adds a newly discovered node to the nodes waiting to be
+// auto-commissioned. The first node of a batch starts the batch window, at the
+// end of which all the nodes collected so far are commissioned.
+func (m *Manager) queueAutoCommission(name string) {
+	ac := m.autoCommission
+	ac.pending = append(ac.pending, name)
+	if len(ac.pending) > 1 {
+		return
+	}
+	logrus.Infof("node %q will be auto-commissioned in %s, along with the nodes discovered meanwhile", name, ac.window)
+	time.AfterFunc(ac.window, func() {
+		m.reqQ <- newAutoCommissionEvent(m)
+	})
+}
+
+// countMasterNodes returns the number of nodes that are commissioned, or being
+// commissioned, as masters
+func (m *Manager) countMasterNodes() int {
+	count := 0
+	for _, node := range m.nodes {
+		if node.Inv == nil || node.Cfg == nil || node.Cfg.GetGroup() != ansibleMasterGroupName {
+			continue
+		}
+		switch status, _ := node.Inv.GetStatus(); status {
+		case inventory.Allocated, inventory.Provisioning, inventory.Provisioned, inventory.Maintenance:
+			count++
+		}
+	}
+	return count
+}
+
+// autoCommissionEvent commissions the batch of discovered nodes that were
+// collected during the batch window. The nodes are commissioned as masters
+// till the policy's number of masters exist, the rest are commissioned in the
+// policy's host-group once the masters are commissioned.
+type autoCommissionEvent struct {
+	mgr *Manager
+}
+
+// newAutoCommissionEvent creates and returns autoCommissionEvent
+func newAutoCommissionEvent(mgr *Manager) *autoCommissionEvent {
+	return &autoCommissionEvent{
+		mgr: mgr,
+	}
+}
+
+func (e *autoCommissionEvent) String() string {
+	return fmt.Sprintf("autoCommissionEvent: nodes: %v", e.mgr.autoCommission.pending)
+}
+
+func (e *autoCommissionEvent) process() error {
+	ac := e.mgr.autoCommission
+	pending := ac.pending
+	ac.pending = nil
+
+	// the nodes may have disappeared or been commissioned by the user meanwhile
+	names := []string{}
+	for _, name := range pending {
+		node, err := e.mgr.findNode(name)
+		if err != nil || node.Inv == nil {
+			continue
+		}
+		if status, state := node.Inv.GetStatus(); status != inventory.Unallocated || state != inventory.Discovered {
+			logrus.Infof("skipping auto-commission of node %q as it is in %q status and %q state", name, status, state)
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	numMasters := ac.masters - e.mgr.countMasterNodes()
+	if numMasters < 0 {
+		numMasters = 0
+	}
+	if numMasters > len(names) || ac.hostGroup == ansibleMasterGroupName {
+		numMasters = len(names)
+	}
+	masters, others := names[:numMasters], names[numMasters:]
+
+	if len(masters) == 0 {
+		return newAutoCommissionGroupEvent(e.mgr, others, ac.hostGroup).process()
+	}
+
+	me := newAutoCommissionGroupEvent(e.mgr, masters, ansibleMasterGroupName)
+	if err := me.process(); err != nil {
+		return err
+	}
+	if len(others) == 0 {
+		return nil
+	}
+	// the rest of the nodes need a master to be commissioned, so they are
+	// commissioned once the masters are
+	go func() {
+		me._job.Wait()
+		if status, err := me._job.Status(); status != Complete {
+			logrus.Errorf("skipping auto-commission of nodes %v as commission of master nodes %v failed. Error: %v",
+				others, masters, err)
+			return
+		}
+		we := newWaitableEvent(newAutoCommissionGroupEvent(e.mgr, others, ac.hostGroup))
+		e.mgr.reqQ <- we
+		if err := we.waitForCompletion(); err != nil {
+			logrus.Errorf("auto-commission of nodes %v failed. Error: %v", others, err)
+		}
+	}()
+	return nil
+}
+
+// autoCommissionGroupEvent commissions a set of auto-commissioned nodes in a host-group
+type autoCommissionGroupEvent struct {
+	mgr       *Manager
+	nodeNames []string
+	hostGroup string
+
+	_job *Job
+}
+
+// newAutoCommissionGroupEvent creates and returns autoCommissionGroupEvent
+func newAutoCommissionGroupEvent(mgr *Manager, nodeNames []string, hostGroup string) *autoCommissionGroupEvent {
+	return &autoCommissionGroupEvent{
+		mgr:       mgr,
+		nodeNames: nodeNames,
+		hostGroup: hostGroup,
+	}
+}
+
+func (e *autoCommissionGroupEvent) String() string {
+	return fmt.Sprintf("autoCommissionGroupEvent: nodes: %v host-group: %s", e.nodeNames, e.hostGroup)
+}
+
+func (e *autoCommissionGroupEvent) process() error {
+	logrus.Infof("auto-commissioning nodes %v as %q", e.nodeNames, e.hostGroup)
+	ce := newCommissionEvent(e.mgr, e.nodeNames, configuration.DefaultValidJSON, e.hostGroup, 0)
+	err := ce.process()
+	e._job = ce.job()
+	if err != nil && err != errJobQueued {
+		logrus.Errorf("failed to auto-commission nodes %v. Error: %v", e.nodeNames, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// +build unittest
+
+package manager
+
+import (
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type autoCommissionSuite struct {
+}
+
+var _ = Suite(&autoCommissionSuite{})
+
+func (s *autoCommissionSuite) TestNewAutoCommissioner(c *C) {
+	config := DefaultConfig().Manager.AutoCommission
+	ac, err := newAutoCommissioner(config, defaultHostGroups())
+	c.Assert(err, IsNil)
+	c.Assert(ac, IsNil)
+
+	config.Enabled = true
+	config.LabelPattern = "^rack1-"
+	config.SerialPattern = "^SN"
+	ac, err = newAutoCommissioner(config, defaultHostGroups())
+	c.Assert(err, IsNil)
+	c.Assert(ac, NotNil)
+	c.Assert(ac.matches("rack1-node1", "SN1234"), Equals, true)
+	c.Assert(ac.matches("rack2-node1", "SN1234"), Equals, false)
+	c.Assert(ac.matches("rack1-node1", "1234"), Equals, false)
+}
+
+func (s *autoCommissionSuite) TestNewAutoCommissionerError(c *C) {
+	tests := map[string]func(*autoCommissionConfig){
+		"invalid-label-pattern":  func(config *autoCommissionConfig) { config.LabelPattern = "(" },
+		"invalid-serial-pattern": func(config *autoCommissionConfig) { config.SerialPattern = "[" },
+		"invalid-batch-window":   func(config *autoCommissionConfig) { config.BatchWindow = "foo" },
+		"invalid-masters":        func(config *autoCommissionConfig) { config.Masters = -1 },
+		"invalid-host-group":     func(config *autoCommissionConfig) { config.HostGroup = "foo" },
+	}
+	for testname, setFn := range tests {
+		config := DefaultConfig().Manager.AutoCommission
+		config.Enabled = true
+		setFn(&config)
+		_, err := newAutoCommissioner(config, defaultHostGroups())
+		c.Assert(err, NotNil, Commentf("test: %s", testname))
+	}
+}
+
+func (s *autoCommissionSuite) TestCountMasterNodes(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+		"node2": inventory.Provisioning,
+		"node3": inventory.Unallocated,
+		"node4": inventory.Allocated,
+	}, inventory.Disappeared)
+	mgr.nodes["node4"].Cfg.(*configuration.AnsibleHost).SetGroup(ansibleWorkerGroupName)
+	c.Assert(mgr.countMasterNodes(), Equals, 2)
+}
+
+func (s *autoCommissionSuite) TestAutoCommissionSkipsUnavailableNodes(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+		"node2": inventory.Unallocated,
+	}, inventory.Disappeared)
+	config := DefaultConfig().Manager.AutoCommission
+	config.Enabled = true
+	ac, err := newAutoCommissioner(config, defaultHostGroups())
+	c.Assert(err, IsNil)
+	mgr.autoCommission = ac
+	// node1 is commissioned and node2 has disappeared, so no job is started
+	ac.pending = []string{"node1", "node2", "node3"}
+	c.Assert(newAutoCommissionEvent(mgr).process(), IsNil)
+	c.Assert(ac.pending, HasLen, 0)
+	c.Assert(mgr.countMasterNodes(), Equals, 1)
+}
@@ -7,6 +7,9 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/contiv/errored"
 )
@@ -31,10 +34,17 @@ func (c *Client) formURL(rsrc string) string {
 }
 
 func (c *Client) doPost(rsrc string, req *APIRequest) error {
+	_, err := c.doPostAndRead(rsrc, req)
+	return err
+}
+
+// doPostAndRead posts the request and returns the response body. Both '200 OK'
+// and '202 Accepted' are treated as success.
+func (c *Client) doPostAndRead(rsrc string, req *APIRequest) ([]byte, error) {
 
 	var reqJSON bytes.Buffer
 	if err := json.NewEncoder(&reqJSON).Encode(req); err != nil {
-		return err
+		return nil, err
 	}
 
 	var (
@@ -42,20 +52,67 @@ func (c *Client) doPost(rsrc string, req *APIRequest) error {
 		err  error
 	)
 	resp, err = c.httpC.Post(c.formURL(rsrc), "application/json", &reqJSON)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		body = []byte{}
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return nil, httpErrorResp(rsrc, req, resp.Status, body)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			body = []byte{}
-		}
-		return httpErrorResp(rsrc, req, resp.Status, body)
+	return body, nil
+}
+
+// doPostJob posts a request that starts a job and returns the info of the job.
+// If opts.Wait is true the request blocks till the job is done.
+func (c *Client) doPostJob(rsrc string, req *APIRequest, opts JobOptions) ([]byte, error) {
+	if opts.Wait {
+		query := url.Values{}
+		query.Set(postQueryWait, "true")
+		rsrc = fmt.Sprintf("%s?%s", rsrc, query.Encode())
+	}
+	return c.doPostAndRead(rsrc, req)
+}
+
+// doDeleteJob sends a DELETE request that starts a job and returns the info of
+// the job. If opts.Wait is true the request blocks till the job is done.
+func (c *Client) doDeleteJob(rsrc string, query url.Values, opts JobOptions) ([]byte, error) {
+	if opts.Wait {
+		query.Set(postQueryWait, "true")
+	}
+	return c.doDelete(rsrc, query)
+}
+
+// doDelete sends a DELETE request and returns the response body
+func (c *Client) doDelete(rsrc string, query url.Values) ([]byte, error) {
+	if len(query) > 0 {
+		rsrc = fmt.Sprintf("%s?%s", rsrc, query.Encode())
+	}
+
+	req, err := http.NewRequest("DELETE", c.formURL(rsrc), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpC.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		body = []byte{}
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return nil, httpErrorResp(rsrc, nil, resp.Status, body)
 	}
 
-	return nil
+	return body, nil
 }
 
 func (c *Client) doGet(rsrc string) (io.ReadCloser, error) {
@@ -76,73 +133,224 @@ func (c *Client) doGet(rsrc string) (io.ReadCloser, error) {
 	return resp.Body, nil
 }
 
+// JobOptions are the options of the requests that start a job
+type JobOptions struct {
+	// Priority determines the order of the job in the job queue, when it is queued
+	Priority int
+	// Wait makes the request block till the job is done, the final info of the
+	// job is returned then
+	Wait bool
+	// Force acts on the nodes even if they are not reachable. It is used by the
+	// decommission, update and purge requests only.
+	Force bool
+}
+
 // PostNodeCommission posts the request to commission a node
 func (c *Client) PostNodeCommission(nodeName, extraVars, hostGroup string) error {
-	req := &APIRequest{
-		Nodes:     []string{nodeName},
-		HostGroup: hostGroup,
-		ExtraVars: extraVars,
-	}
-	return c.doPost(PostNodesCommission, req)
+	return c.PostNodesCommission([]string{nodeName}, extraVars, hostGroup)
 }
 
 // PostNodesCommission posts the request to commission a set of nodes
 func (c *Client) PostNodesCommission(nodeNames []string, extraVars, hostGroup string) error {
+	_, err := c.PostNodesCommissionWithOptions(nodeNames, extraVars, hostGroup, JobOptions{})
+	return err
+}
+
+// PostNodesCommissionWithOptions posts the request to commission a set of nodes.
+// It returns the info of the job that was started.
+func (c *Client) PostNodesCommissionWithOptions(nodeNames []string, extraVars, hostGroup string, opts JobOptions) ([]byte, error) {
 	req := &APIRequest{
 		Nodes:     nodeNames,
 		HostGroup: hostGroup,
 		ExtraVars: extraVars,
+		Priority:  opts.Priority,
 	}
-	return c.doPost(PostNodesCommission, req)
+	return c.doPostJob(PostNodesCommission, req, opts)
 }
 
-// PostNodeDecommission posts the request to decommission a node
-func (c *Client) PostNodeDecommission(nodeName, extraVars string) error {
+// PostNodesCommissionByHostGroup posts the request to commission a set of
+// nodes in different host-groups, as per hostGroups which is keyed by node
+// name. The nodes are commissioned in one job, the nodes of a host-group are
+// commissioned after the nodes of the host-groups that it requires.
+func (c *Client) PostNodesCommissionByHostGroup(hostGroups map[string]string, extraVars string, opts JobOptions) ([]byte, error) {
 	req := &APIRequest{
-		Nodes:     []string{nodeName},
+		NodeHostGroups: hostGroups,
+		ExtraVars:      extraVars,
+		Priority:       opts.Priority,
+	}
+	return c.doPostJob(PostNodesCommission, req, opts)
+}
+
+// PostNodesCommissionBySelector posts the request to commission the nodes
+// whose labels match the selector, like `rack=r1,owner!=team1`
+func (c *Client) PostNodesCommissionBySelector(selector, extraVars, hostGroup string, opts JobOptions) ([]byte, error) {
+	req := &APIRequest{
+		Selector:  selector,
+		HostGroup: hostGroup,
 		ExtraVars: extraVars,
+		Priority:  opts.Priority,
 	}
-	return c.doPost(PostNodesDecommission, req)
+	return c.doPostJob(PostNodesCommission, req, opts)
+}
+
+// PostNodeDecommission posts the request to decommission a node
+func (c *Client) PostNodeDecommission(nodeName, extraVars string) error {
+	return c.PostNodesDecommission([]string{nodeName}, extraVars)
 }
 
 // PostNodesDecommission posts the request to decommission a set of nodes
 func (c *Client) PostNodesDecommission(nodeNames []string, extraVars string) error {
+	_, err := c.PostNodesDecommissionWithOptions(nodeNames, extraVars, JobOptions{})
+	return err
+}
+
+// PostNodesDecommissionWithOptions posts the request to decommission a set of
+// nodes. If opts.Force is true the nodes are decommissioned even if they are
+// not reachable. It returns the info of the job that was started.
+func (c *Client) PostNodesDecommissionWithOptions(nodeNames []string, extraVars string, opts JobOptions) ([]byte, error) {
 	req := &APIRequest{
 		Nodes:     nodeNames,
 		ExtraVars: extraVars,
+		Priority:  opts.Priority,
+		Force:     opts.Force,
 	}
-	return c.doPost(PostNodesDecommission, req)
+	return c.doPostJob(PostNodesDecommission, req, opts)
 }
 
-// PostNodeUpdate posts the request to update a node and optionally change
-// it's host-group when it is specified.
-func (c *Client) PostNodeUpdate(nodeName, extraVars, hostGroup string) error {
+// PostNodesDecommissionBySelector posts the request to decommission the nodes
+// whose labels match the selector
+func (c *Client) PostNodesDecommissionBySelector(selector, extraVars string, opts JobOptions) ([]byte, error) {
+	req := &APIRequest{
+		Selector:  selector,
+		ExtraVars: extraVars,
+		Priority:  opts.Priority,
+		Force:     opts.Force,
+	}
+	return c.doPostJob(PostNodesDecommission, req, opts)
+}
+
+// PostNodeReplace posts the request to replace a commissioned node by a spare
+// node. The spare node is commissioned with the node's host-group and host
+// variables, after which the node is decommissioned, in one job.
+func (c *Client) PostNodeReplace(nodeName, spareName, extraVars string, opts JobOptions) ([]byte, error) {
 	req := &APIRequest{
 		Nodes:     []string{nodeName},
+		Spare:     spareName,
 		ExtraVars: extraVars,
-		HostGroup: hostGroup,
+		Priority:  opts.Priority,
 	}
-	return c.doPost(PostNodesUpdate, req)
+	return c.doPostJob(PostNodeReplace, req, opts)
+}
+
+// PostNodeUpdate posts the request to update a node and optionally change
+// it's host-group when it is specified.
+func (c *Client) PostNodeUpdate(nodeName, extraVars, hostGroup string) error {
+	return c.PostNodesUpdate([]string{nodeName}, extraVars, hostGroup)
 }
 
 // PostNodesUpdate posts the request to update a set of node and optionally change
 // their host-group when it is specified.
 func (c *Client) PostNodesUpdate(nodeNames []string, extraVars, hostGroup string) error {
+	_, err := c.PostNodesUpdateWithOptions(nodeNames, extraVars, hostGroup, JobOptions{})
+	return err
+}
+
+// PostNodesUpdateWithOptions posts the request to update a set of node and
+// optionally change their host-group when it is specified. If opts.Force is
+// true the reachable nodes are updated even if some of the nodes are not. It
+// returns the info of the job that was started.
+func (c *Client) PostNodesUpdateWithOptions(nodeNames []string, extraVars, hostGroup string, opts JobOptions) ([]byte, error) {
 	req := &APIRequest{
 		Nodes:     nodeNames,
 		ExtraVars: extraVars,
 		HostGroup: hostGroup,
+		Priority:  opts.Priority,
+		Force:     opts.Force,
 	}
-	return c.doPost(PostNodesUpdate, req)
+	return c.doPostJob(PostNodesUpdate, req, opts)
+}
+
+// PostNodesUpdateBySelector posts the request to update the nodes whose labels
+// match the selector and optionally change their host-group when it is specified.
+func (c *Client) PostNodesUpdateBySelector(selector, extraVars, hostGroup string, opts JobOptions) ([]byte, error) {
+	req := &APIRequest{
+		Selector:  selector,
+		ExtraVars: extraVars,
+		HostGroup: hostGroup,
+		Priority:  opts.Priority,
+		Force:     opts.Force,
+	}
+	return c.doPostJob(PostNodesUpdate, req, opts)
+}
+
+// PostNodesUpgrade posts the request to upgrade a set of commissioned nodes
+// in batches of batchSize nodes. maxUnavailable limits the number of nodes in
+// the cluster that can be unavailable during the upgrade, 0 means no limit.
+func (c *Client) PostNodesUpgrade(nodeNames []string, extraVars string, batchSize, maxUnavailable int, opts JobOptions) ([]byte, error) {
+	req := &APIRequest{
+		Nodes:          nodeNames,
+		ExtraVars:      extraVars,
+		Priority:       opts.Priority,
+		BatchSize:      batchSize,
+		MaxUnavailable: maxUnavailable,
+	}
+	return c.doPostJob(PostNodesUpgrade, req, opts)
+}
+
+// PostNodeMaintenance posts the request to put a commissioned node in maintenance
+// or take it out of maintenance, as per the action. The reason is recorded when
+// the node enters maintenance. expiry, if not empty, is a duration like 4h after
+// which the node exits maintenance.
+func (c *Client) PostNodeMaintenance(nodeName, action, reason, expiry string, opts JobOptions) ([]byte, error) {
+	return c.PostNodesMaintenance([]string{nodeName}, action, reason, expiry, opts)
+}
+
+// PostNodesMaintenance posts the request to put a set of commissioned nodes in
+// maintenance or take them out of maintenance, as per the action.
+func (c *Client) PostNodesMaintenance(nodeNames []string, action, reason, expiry string, opts JobOptions) ([]byte, error) {
+	req := &APIRequest{
+		Nodes:  nodeNames,
+		Action: action,
+		Reason: reason,
+		Expiry: expiry,
+	}
+	return c.doPostJob(PostNodesMaintenance, req, opts)
 }
 
 // PostNodesDiscover posts the request to provision a set of nodes for discovery
 func (c *Client) PostNodesDiscover(nodeAddrs []string, extraVars string) error {
+	_, err := c.PostNodesDiscoverWithOptions(nodeAddrs, extraVars, JobOptions{})
+	return err
+}
+
+// PostNodesDiscoverWithOptions posts the request to provision a set of nodes
+// for discovery. It returns the info of the job that was started.
+func (c *Client) PostNodesDiscoverWithOptions(nodeAddrs []string, extraVars string, opts JobOptions) ([]byte, error) {
 	req := &APIRequest{
 		Addrs:     nodeAddrs,
 		ExtraVars: extraVars,
+		Priority:  opts.Priority,
+	}
+	return c.doPostJob(PostNodesDiscover, req, opts)
+}
+
+// DeleteNode sends the request to purge a decommissioned node from clusterm and
+// the inventory. If opts.Force is true the node is purged irrespective of it's status.
+func (c *Client) DeleteNode(nodeName string, opts JobOptions) ([]byte, error) {
+	query := url.Values{}
+	if opts.Force {
+		query.Set(deleteQueryForce, "true")
+	}
+	return c.doDeleteJob(fmt.Sprintf("%s/%s", DeleteNodePrefix, nodeName), query, opts)
+}
+
+// PostNodeLabels posts the request to set the labels of a node. The labels are
+// merged with the existing labels of the node, a label with empty value is removed.
+func (c *Client) PostNodeLabels(nodeName string, labels map[string]string) error {
+	req := &APIRequest{
+		Labels: labels,
 	}
-	return c.doPost(PostNodesDiscover, req)
+	return c.doPost(fmt.Sprintf("%s/%s", PostNodeLabelsPrefix, nodeName), req)
 }
 
 // PostGlobals posts the request to set global extra vars
@@ -172,6 +380,42 @@ func (c *Client) PostConfig(config *Config) error {
 	return c.doPost(GetPostConfig, req)
 }
 
+// PostSpec posts the spec i.e. the desired state of the cluster. The cluster is
+// reconciled to the spec till another spec is posted or the spec is deleted. It
+// returns the actions that converge the cluster to the spec.
+func (c *Client) PostSpec(spec *ClusterSpec) ([]byte, error) {
+	req := &APIRequest{
+		Spec: spec,
+	}
+	return c.doPostAndRead(PostDeleteSpec, req)
+}
+
+// PostSpecPlan posts the request to fetch the actions that converge the
+// cluster to a spec, without acting on them
+func (c *Client) PostSpecPlan(spec *ClusterSpec) ([]byte, error) {
+	req := &APIRequest{
+		Spec: spec,
+	}
+	return c.doPostAndRead(PostSpecPlan, req)
+}
+
+// DeleteSpec deletes the applied spec, the cluster is not reconciled anymore
+func (c *Client) DeleteSpec() error {
+	_, err := c.doDelete(PostDeleteSpec, url.Values{})
+	return err
+}
+
+// PostJobCancel cancels a running provisioning job specified by jobLabel.
+// Accepted values of jobLabel are "active" or the id of an active job.
+func (c *Client) PostJobCancel(jobLabel string) error {
+	return c.doPost(fmt.Sprintf("%s/%s", PostJobCancelPrefix, jobLabel), &APIRequest{})
+}
+
+// PostJobDequeue removes a job, specified by it's id, from the job queue.
+func (c *Client) PostJobDequeue(jobID string) error {
+	return c.doPost(fmt.Sprintf("%s/%s", PostJobDequeuePrefix, jobID), &APIRequest{})
+}
+
 func (c *Client) readAll(rsrc string) ([]byte, error) {
 	resp, err := c.doGet(rsrc)
 	if err != nil {
@@ -191,11 +435,44 @@ func (c *Client) GetNode(nodeName string) ([]byte, error) {
 	return c.readAll(fmt.Sprintf("%s/%s", GetNodeInfoPrefix, nodeName))
 }
 
-// GetAllNodes requests info of all known nodes
+// GetNodeLog requests the event logs of a specified node, like it's status
+// transitions and the jobs run on it
+func (c *Client) GetNodeLog(nodeName string) ([]byte, error) {
+	return c.readAll(fmt.Sprintf("%s/%s/%s", GetNodeInfoPrefix, nodeName, GetNodeLogSuffix))
+}
+
+// GetNodeTransitions requests the transition history of a specified node within
+// the time range. A zero time leaves that end of the range open.
+func (c *Client) GetNodeTransitions(nodeName string, from, to time.Time) ([]byte, error) {
+	query := url.Values{}
+	if !from.IsZero() {
+		query.Set(transitionsQueryFrom, from.Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		query.Set(transitionsQueryTo, to.Format(time.RFC3339))
+	}
+	rsrc := fmt.Sprintf("%s/%s/%s", GetNodeInfoPrefix, nodeName, GetNodeTransitionsSuffix)
+	if len(query) > 0 {
+		rsrc = fmt.Sprintf("%s?%s", rsrc, query.Encode())
+	}
+	return c.readAll(rsrc)
+}
+
+// GetAllNodes requests info of all known nodes, keyed by their name
 func (c *Client) GetAllNodes() ([]byte, error) {
 	return c.readAll(GetNodesInfo)
 }
 
+// GetNodes requests the info of the nodes that match the query. The nodes are
+// sorted and paginated as per the query and are returned as NodesInfo.
+func (c *Client) GetNodes(q NodesQuery) ([]byte, error) {
+	rsrc := GetNodesQuery
+	if query := q.values(); len(query) > 0 {
+		rsrc = fmt.Sprintf("%s?%s", rsrc, query.Encode())
+	}
+	return c.readAll(rsrc)
+}
+
 // GetGlobals requests the value global extra vars
 func (c *Client) GetGlobals() ([]byte, error) {
 	return c.readAll(GetGlobals)
@@ -206,13 +483,49 @@ func (c *Client) GetConfig() ([]byte, error) {
 	return c.readAll(GetPostConfig)
 }
 
+// GetSpec requests the applied spec along with it's current plan
+func (c *Client) GetSpec() ([]byte, error) {
+	return c.readAll(GetSpec)
+}
+
 // GetJob requests the info of a provisioning job specified by jobLabel.
-// Accepted values of jobLabel are "active" and "last"
+// Accepted values of jobLabel are "active", "last" or the id of a job
 func (c *Client) GetJob(jobLabel string) ([]byte, error) {
 	return c.readAll(fmt.Sprintf("%s/%s", GetJobPrefix, jobLabel))
 }
 
+// GetJobs requests the info of the jobs in job history. The jobs are filtered by
+// status and node, when specified. The offset and limit are used for pagination,
+// a limit of 0 returns all the jobs after the offset.
+func (c *Client) GetJobs(status, node string, offset, limit int) ([]byte, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set(jobsQueryStatus, status)
+	}
+	if node != "" {
+		query.Set(jobsQueryNode, node)
+	}
+	if offset != 0 {
+		query.Set(jobsQueryOffset, strconv.Itoa(offset))
+	}
+	if limit != 0 {
+		query.Set(jobsQueryLimit, strconv.Itoa(limit))
+	}
+	rsrc := GetJobsInfo
+	if len(query) > 0 {
+		rsrc = fmt.Sprintf("%s?%s", GetJobsInfo, query.Encode())
+	}
+	return c.readAll(rsrc)
+}
+
+// GetJobQueue requests the info of the jobs in the job queue, in the order
+// they shall be run
+func (c *Client) GetJobQueue() ([]byte, error) {
+	return c.readAll(GetJobQueue)
+}
+
 // StreamLogs requests the log stream of a provisioning job specified by jobLabel.
+// Accepted values of jobLabel are "active" or the id of an active job.
 // It is caller's responsibility to Close the returned stream
 func (c *Client) StreamLogs(jobLabel string) (io.ReadCloser, error) {
 	return c.doGet(fmt.Sprintf("%s/%s", GetJobLogPrefix, jobLabel))
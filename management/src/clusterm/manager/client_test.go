@@ -67,6 +67,16 @@ var (
 		ExtraVars: testExtraVars,
 	}
 
+	testReqNodesPriorityBody = APIRequest{
+		Nodes:    []string{testNodeName},
+		Priority: 5,
+	}
+
+	testReqNodesForceBody = APIRequest{
+		Nodes: []string{testNodeName},
+		Force: true,
+	}
+
 	testReqDiscoverBody = APIRequest{
 		Addrs: []string{testNodeName},
 	}
@@ -108,6 +118,18 @@ var (
 			})
 	}
 
+	acceptedReturner = func(c *C, expURL *url.URL, expBody []byte) http.HandlerFunc {
+		return http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				c.Assert(r.URL.Query(), DeepEquals, expURL.Query())
+				body, err := ioutil.ReadAll(r.Body)
+				c.Assert(err, IsNil)
+				c.Assert(string(body), Equals, string(expBody))
+				w.WriteHeader(http.StatusAccepted)
+				w.Write(testGetData)
+			})
+	}
+
 	okGetReturner = func(c *C, expURL *url.URL) http.HandlerFunc {
 		return http.HandlerFunc(
 			func(w http.ResponseWriter, r *http.Request) {
@@ -149,19 +171,28 @@ func (s *managerSuite) TestPostMultiNodesSuccess(c *C) {
 	var reqNodesHostGroupExtraVarsBody bytes.Buffer
 	c.Assert(json.NewEncoder(&reqNodesHostGroupExtraVarsBody).Encode(testReqNodesHostGroupExtraVarsBody), IsNil)
 
+	var reqNodesPriorityBody bytes.Buffer
+	c.Assert(json.NewEncoder(&reqNodesPriorityBody).Encode(testReqNodesPriorityBody), IsNil)
+
 	var reqDiscoverBody bytes.Buffer
 	c.Assert(json.NewEncoder(&reqDiscoverBody).Encode(testReqDiscoverBody), IsNil)
 
 	var reqDiscoverExtraVarsBody bytes.Buffer
 	c.Assert(json.NewEncoder(&reqDiscoverExtraVarsBody).Encode(testReqDiscoverExtraVarsBody), IsNil)
 
+	var reqNodesForceBody bytes.Buffer
+	c.Assert(json.NewEncoder(&reqNodesForceBody).Encode(testReqNodesForceBody), IsNil)
+
 	testsCommission := map[string]struct {
 		expURLStr string
 		nodeNames []string
 		extraVars string
 		hostGroup string
+		priority  int
+		wait      bool
+		force     bool
 		exptdBody []byte
-		cb        func(names []string, extraVars string, hostGroup string) error
+		cb        func(names []string, extraVars string, hostGroup string, opts JobOptions) ([]byte, error)
 	}{
 		"commission": {
 			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesCommission),
@@ -169,7 +200,7 @@ func (s *managerSuite) TestPostMultiNodesSuccess(c *C) {
 			extraVars: "",
 			hostGroup: "",
 			exptdBody: reqBody.Bytes(),
-			cb:        clstrC.PostNodesCommission,
+			cb:        clstrC.PostNodesCommissionWithOptions,
 		},
 		"commission-extra-vars": {
 			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesCommission),
@@ -177,7 +208,7 @@ func (s *managerSuite) TestPostMultiNodesSuccess(c *C) {
 			extraVars: testExtraVars,
 			hostGroup: "",
 			exptdBody: reqNodesExtraVarsBody.Bytes(),
-			cb:        clstrC.PostNodesCommission,
+			cb:        clstrC.PostNodesCommissionWithOptions,
 		},
 		"commission-host-group": {
 			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesCommission),
@@ -185,7 +216,7 @@ func (s *managerSuite) TestPostMultiNodesSuccess(c *C) {
 			extraVars: "",
 			hostGroup: ansibleMasterGroupName,
 			exptdBody: reqNodesHostGroupBody.Bytes(),
-			cb:        clstrC.PostNodesCommission,
+			cb:        clstrC.PostNodesCommissionWithOptions,
 		},
 		"commission-extra-vars-host-group": {
 			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesCommission),
@@ -193,7 +224,21 @@ func (s *managerSuite) TestPostMultiNodesSuccess(c *C) {
 			extraVars: testExtraVars,
 			hostGroup: ansibleMasterGroupName,
 			exptdBody: reqNodesHostGroupExtraVarsBody.Bytes(),
-			cb:        clstrC.PostNodesCommission,
+			cb:        clstrC.PostNodesCommissionWithOptions,
+		},
+		"commission-priority": {
+			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesCommission),
+			nodeNames: []string{testNodeName},
+			priority:  5,
+			exptdBody: reqNodesPriorityBody.Bytes(),
+			cb:        clstrC.PostNodesCommissionWithOptions,
+		},
+		"commission-wait": {
+			expURLStr: fmt.Sprintf("http://%s/%s?%s=true", baseURL, PostNodesCommission, postQueryWait),
+			nodeNames: []string{testNodeName},
+			wait:      true,
+			exptdBody: reqBody.Bytes(),
+			cb:        clstrC.PostNodesCommissionWithOptions,
 		},
 		"update": {
 			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesUpdate),
@@ -201,7 +246,7 @@ func (s *managerSuite) TestPostMultiNodesSuccess(c *C) {
 			extraVars: "",
 			hostGroup: "",
 			exptdBody: reqBody.Bytes(),
-			cb:        clstrC.PostNodesUpdate,
+			cb:        clstrC.PostNodesUpdateWithOptions,
 		},
 		"update-extra-vars": {
 			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesUpdate),
@@ -209,7 +254,7 @@ func (s *managerSuite) TestPostMultiNodesSuccess(c *C) {
 			extraVars: testExtraVars,
 			hostGroup: "",
 			exptdBody: reqNodesExtraVarsBody.Bytes(),
-			cb:        clstrC.PostNodesUpdate,
+			cb:        clstrC.PostNodesUpdateWithOptions,
 		},
 		"update-host-group": {
 			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesUpdate),
@@ -217,7 +262,7 @@ func (s *managerSuite) TestPostMultiNodesSuccess(c *C) {
 			extraVars: "",
 			hostGroup: ansibleMasterGroupName,
 			exptdBody: reqNodesHostGroupBody.Bytes(),
-			cb:        clstrC.PostNodesUpdate,
+			cb:        clstrC.PostNodesUpdateWithOptions,
 		},
 		"update-extra-vars-host-group": {
 			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesUpdate),
@@ -225,7 +270,14 @@ func (s *managerSuite) TestPostMultiNodesSuccess(c *C) {
 			extraVars: testExtraVars,
 			hostGroup: ansibleMasterGroupName,
 			exptdBody: reqNodesHostGroupExtraVarsBody.Bytes(),
-			cb:        clstrC.PostNodesUpdate,
+			cb:        clstrC.PostNodesUpdateWithOptions,
+		},
+		"update-force": {
+			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesUpdate),
+			nodeNames: []string{testNodeName},
+			force:     true,
+			exptdBody: reqNodesForceBody.Bytes(),
+			cb:        clstrC.PostNodesUpdateWithOptions,
 		},
 	}
 	for testname, test := range testsCommission {
@@ -235,43 +287,69 @@ func (s *managerSuite) TestPostMultiNodesSuccess(c *C) {
 		httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, test.exptdBody))
 		defer httpS.Close()
 		clstrC.httpC = httpC
-		c.Assert(test.cb(test.nodeNames, test.extraVars, test.hostGroup), IsNil, Commentf("test: %s", testname))
+		_, err = test.cb(test.nodeNames, test.extraVars, test.hostGroup,
+			JobOptions{Priority: test.priority, Force: test.force, Wait: test.wait})
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
 	}
 
 	tests := map[string]struct {
 		expURLStr string
 		nodeNames []string
 		extraVars string
+		priority  int
+		wait      bool
+		force     bool
 		exptdBody []byte
-		cb        func(names []string, extraVars string) error
+		cb        func(names []string, extraVars string, opts JobOptions) ([]byte, error)
 	}{
 		"decommission": {
 			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesDecommission),
 			nodeNames: []string{testNodeName},
 			extraVars: "",
 			exptdBody: reqBody.Bytes(),
-			cb:        clstrC.PostNodesDecommission,
+			cb:        clstrC.PostNodesDecommissionWithOptions,
 		},
 		"decommission-extra-vars": {
 			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesDecommission),
 			nodeNames: []string{testNodeName},
 			extraVars: testExtraVars,
 			exptdBody: reqNodesExtraVarsBody.Bytes(),
-			cb:        clstrC.PostNodesDecommission,
+			cb:        clstrC.PostNodesDecommissionWithOptions,
+		},
+		"decommission-priority": {
+			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesDecommission),
+			nodeNames: []string{testNodeName},
+			priority:  5,
+			exptdBody: reqNodesPriorityBody.Bytes(),
+			cb:        clstrC.PostNodesDecommissionWithOptions,
+		},
+		"decommission-force": {
+			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesDecommission),
+			nodeNames: []string{testNodeName},
+			force:     true,
+			exptdBody: reqNodesForceBody.Bytes(),
+			cb:        clstrC.PostNodesDecommissionWithOptions,
+		},
+		"decommission-wait": {
+			expURLStr: fmt.Sprintf("http://%s/%s?%s=true", baseURL, PostNodesDecommission, postQueryWait),
+			nodeNames: []string{testNodeName},
+			wait:      true,
+			exptdBody: reqBody.Bytes(),
+			cb:        clstrC.PostNodesDecommissionWithOptions,
 		},
 		"discover": {
 			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesDiscover),
 			nodeNames: []string{testNodeName},
 			extraVars: "",
 			exptdBody: reqDiscoverBody.Bytes(),
-			cb:        clstrC.PostNodesDiscover,
+			cb:        clstrC.PostNodesDiscoverWithOptions,
 		},
 		"discover-extra-vars": {
 			expURLStr: fmt.Sprintf("http://%s/%s", baseURL, PostNodesDiscover),
 			nodeNames: []string{testNodeName},
 			extraVars: testExtraVars,
 			exptdBody: reqDiscoverExtraVarsBody.Bytes(),
-			cb:        clstrC.PostNodesDiscover,
+			cb:        clstrC.PostNodesDiscoverWithOptions,
 		},
 	}
 	for testname, test := range tests {
@@ -281,8 +359,306 @@ func (s *managerSuite) TestPostMultiNodesSuccess(c *C) {
 		httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, test.exptdBody))
 		defer httpS.Close()
 		clstrC.httpC = httpC
-		c.Assert(test.cb(test.nodeNames, test.extraVars), IsNil, Commentf("test: %s", testname))
+		_, err = test.cb(test.nodeNames, test.extraVars,
+			JobOptions{Priority: test.priority, Force: test.force, Wait: test.wait})
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+	}
+}
+
+// the methods without the job options post the request with the default options
+func (s *managerSuite) TestPostNodeWithoutOptionsSuccess(c *C) {
+	clstrC := Client{
+		url: baseURL,
+	}
+
+	var reqBody bytes.Buffer
+	c.Assert(json.NewEncoder(&reqBody).Encode(testReqNodesBody), IsNil)
+
+	var reqDiscoverBody bytes.Buffer
+	c.Assert(json.NewEncoder(&reqDiscoverBody).Encode(testReqDiscoverBody), IsNil)
+
+	tests := map[string]struct {
+		rsrc      string
+		exptdBody []byte
+		cb        func() error
+	}{
+		"commission": {
+			rsrc:      PostNodesCommission,
+			exptdBody: reqBody.Bytes(),
+			cb:        func() error { return clstrC.PostNodeCommission(testNodeName, "", "") },
+		},
+		"decommission": {
+			rsrc:      PostNodesDecommission,
+			exptdBody: reqBody.Bytes(),
+			cb:        func() error { return clstrC.PostNodeDecommission(testNodeName, "") },
+		},
+		"update": {
+			rsrc:      PostNodesUpdate,
+			exptdBody: reqBody.Bytes(),
+			cb:        func() error { return clstrC.PostNodeUpdate(testNodeName, "", "") },
+		},
+		"discover": {
+			rsrc:      PostNodesDiscover,
+			exptdBody: reqDiscoverBody.Bytes(),
+			cb:        func() error { return clstrC.PostNodesDiscover([]string{testNodeName}, "") },
+		},
+	}
+	for testname, test := range tests {
+		expURL, err := url.Parse(fmt.Sprintf("http://%s/%s", baseURL, test.rsrc))
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+
+		httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, test.exptdBody))
+		defer httpS.Close()
+		clstrC.httpC = httpC
+		c.Assert(test.cb(), IsNil, Commentf("test: %s", testname))
+	}
+}
+
+func (s *managerSuite) TestPostNodesCommissionByHostGroupSuccess(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s", baseURL, PostNodesCommission)
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	hostGroups := map[string]string{
+		testNodeName: ansibleMasterGroupName,
+		"testNode2":  ansibleWorkerGroupName,
+	}
+	var reqBody bytes.Buffer
+	c.Assert(json.NewEncoder(&reqBody).Encode(&APIRequest{
+		NodeHostGroups: hostGroups,
+		ExtraVars:      testExtraVars,
+		Priority:       5,
+	}), IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, reqBody.Bytes()))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
 	}
+
+	_, err = clstrC.PostNodesCommissionByHostGroup(hostGroups, testExtraVars, JobOptions{Priority: 5})
+	c.Assert(err, IsNil)
+}
+
+func (s *managerSuite) TestPostNodesBySelectorSuccess(c *C) {
+	selector := "rack=r1,owner!=team1"
+	tests := map[string]struct {
+		rsrc string
+		req  *APIRequest
+		cb   func(clstrC *Client) ([]byte, error)
+	}{
+		"commission": {
+			rsrc: PostNodesCommission,
+			req:  &APIRequest{Selector: selector, HostGroup: ansibleMasterGroupName, ExtraVars: testExtraVars, Priority: 5},
+			cb: func(clstrC *Client) ([]byte, error) {
+				return clstrC.PostNodesCommissionBySelector(selector, testExtraVars, ansibleMasterGroupName, JobOptions{Priority: 5})
+			},
+		},
+		"decommission": {
+			rsrc: PostNodesDecommission,
+			req:  &APIRequest{Selector: selector, ExtraVars: testExtraVars, Force: true},
+			cb: func(clstrC *Client) ([]byte, error) {
+				return clstrC.PostNodesDecommissionBySelector(selector, testExtraVars, JobOptions{Force: true})
+			},
+		},
+		"update": {
+			rsrc: PostNodesUpdate,
+			req:  &APIRequest{Selector: selector, ExtraVars: testExtraVars, HostGroup: ansibleWorkerGroupName},
+			cb: func(clstrC *Client) ([]byte, error) {
+				return clstrC.PostNodesUpdateBySelector(selector, testExtraVars, ansibleWorkerGroupName, JobOptions{})
+			},
+		},
+	}
+	for testname, test := range tests {
+		expURL, err := url.Parse(fmt.Sprintf("http://%s/%s", baseURL, test.rsrc))
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+		var reqBody bytes.Buffer
+		c.Assert(json.NewEncoder(&reqBody).Encode(test.req), IsNil, Commentf("test: %s", testname))
+		httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, reqBody.Bytes()))
+		defer httpS.Close()
+		clstrC := &Client{
+			url:   baseURL,
+			httpC: httpC,
+		}
+		_, err = test.cb(clstrC)
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+	}
+}
+
+func (s *managerSuite) TestPostNodeLabelsSuccess(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s/%s", baseURL, PostNodeLabelsPrefix, testNodeName)
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	labels := map[string]string{"rack": "r1", "owner": ""}
+	var reqBody bytes.Buffer
+	c.Assert(json.NewEncoder(&reqBody).Encode(&APIRequest{Labels: labels}), IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, reqBody.Bytes()))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	c.Assert(clstrC.PostNodeLabels(testNodeName, labels), IsNil)
+}
+
+func (s *managerSuite) TestPostNodeReplaceSuccess(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s", baseURL, PostNodeReplace)
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	var reqBody bytes.Buffer
+	c.Assert(json.NewEncoder(&reqBody).Encode(&APIRequest{
+		Nodes:     []string{testNodeName},
+		Spare:     "testNode2",
+		ExtraVars: testExtraVars,
+		Priority:  5,
+	}), IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, reqBody.Bytes()))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	_, err = clstrC.PostNodeReplace(testNodeName, "testNode2", testExtraVars, JobOptions{Priority: 5})
+	c.Assert(err, IsNil)
+}
+
+func (s *managerSuite) TestPostNodesUpgradeSuccess(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s", baseURL, PostNodesUpgrade)
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	var reqBody bytes.Buffer
+	c.Assert(json.NewEncoder(&reqBody).Encode(&APIRequest{
+		Nodes:          []string{testNodeName},
+		ExtraVars:      testExtraVars,
+		BatchSize:      2,
+		MaxUnavailable: 3,
+	}), IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, reqBody.Bytes()))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	_, err = clstrC.PostNodesUpgrade([]string{testNodeName}, testExtraVars, 2, 3, JobOptions{})
+	c.Assert(err, IsNil)
+}
+
+func (s *managerSuite) TestPostNodesMaintenanceSuccess(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s", baseURL, PostNodesMaintenance)
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	var reqBody bytes.Buffer
+	c.Assert(json.NewEncoder(&reqBody).Encode(&APIRequest{
+		Nodes:  []string{testNodeName},
+		Action: MaintenanceEnter,
+		Reason: "disk replacement",
+		Expiry: "4h",
+	}), IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, reqBody.Bytes()))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	_, err = clstrC.PostNodeMaintenance(testNodeName, MaintenanceEnter, "disk replacement", "4h", JobOptions{})
+	c.Assert(err, IsNil)
+}
+
+func (s *managerSuite) TestDeleteNodeSuccess(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s/%s?%s=true", baseURL, DeleteNodePrefix, testNodeName, deleteQueryForce)
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, []byte{}))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	_, err = clstrC.DeleteNode(testNodeName, JobOptions{Force: true})
+	c.Assert(err, IsNil)
+}
+
+func (s *managerSuite) TestDeleteNodeFailure(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s/%s", baseURL, DeleteNodePrefix, testNodeName)
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, failureReturner(c, expURL, []byte{}))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	_, err = clstrC.DeleteNode(testNodeName, JobOptions{})
+	c.Assert(err, ErrorMatches, ".*test failure\n")
+}
+
+func (s *managerSuite) TestPostSpecSuccess(c *C) {
+	spec := &ClusterSpec{
+		HostGroups: map[string]HostGroupSpec{
+			ansibleMasterGroupName: {Nodes: []string{testNodeName}},
+			ansibleWorkerGroupName: {Count: 2, Selector: NodeSelector{LabelPattern: "^rack1-"}},
+		},
+		ExtraVars: testExtraVars,
+	}
+	var reqBody bytes.Buffer
+	c.Assert(json.NewEncoder(&reqBody).Encode(&APIRequest{Spec: spec}), IsNil)
+
+	clstrC := Client{
+		url: baseURL,
+	}
+	tests := map[string]struct {
+		rsrc string
+		cb   func(spec *ClusterSpec) ([]byte, error)
+	}{
+		"apply": {rsrc: PostDeleteSpec, cb: clstrC.PostSpec},
+		"plan":  {rsrc: PostSpecPlan, cb: clstrC.PostSpecPlan},
+	}
+	for testname, test := range tests {
+		expURL, err := url.Parse(fmt.Sprintf("http://%s/%s", baseURL, test.rsrc))
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+		httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, reqBody.Bytes()))
+		defer httpS.Close()
+		clstrC.httpC = httpC
+		_, err = test.cb(spec)
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+	}
+}
+
+func (s *managerSuite) TestDeleteSpecSuccess(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s", baseURL, PostDeleteSpec)
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, []byte{}))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	c.Assert(clstrC.DeleteSpec(), IsNil)
+}
+
+func (s *managerSuite) TestPostJobAccepted(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s", baseURL, PostNodesCommission)
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	var reqBody bytes.Buffer
+	c.Assert(json.NewEncoder(&reqBody).Encode(testReqNodesBody), IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, acceptedReturner(c, expURL, reqBody.Bytes()))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	resp, err := clstrC.PostNodesCommissionWithOptions([]string{testNodeName}, "", "", JobOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(resp, DeepEquals, testGetData)
 }
 
 func (s *managerSuite) TestPostGlobalsWithVarsSuccess(c *C) {
@@ -365,6 +741,40 @@ func (s *managerSuite) TestPostConfigSuccess(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *managerSuite) TestPostJobCancelSuccess(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s/%s", baseURL, PostJobCancelPrefix, "active")
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	var reqJSON bytes.Buffer
+	c.Assert(json.NewEncoder(&reqJSON).Encode(&APIRequest{}), IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, reqJSON.Bytes()))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	err = clstrC.PostJobCancel("active")
+	c.Assert(err, IsNil)
+}
+
+func (s *managerSuite) TestPostJobDequeueSuccess(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s/%s", baseURL, PostJobDequeuePrefix, "5")
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	var reqJSON bytes.Buffer
+	c.Assert(json.NewEncoder(&reqJSON).Encode(&APIRequest{}), IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, okReturner(c, expURL, reqJSON.Bytes()))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	err = clstrC.PostJobDequeue("5")
+	c.Assert(err, IsNil)
+}
+
 func (s *managerSuite) TestPostError(c *C) {
 	expURLStr := fmt.Sprintf("http://%s/%s", baseURL, PostNodesUpdate)
 	expURL, err := url.Parse(expURLStr)
@@ -397,6 +807,54 @@ func (s *managerSuite) TestGetNodeSuccess(c *C) {
 	c.Assert(resp, DeepEquals, testGetData)
 }
 
+func (s *managerSuite) TestGetNodeLogSuccess(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s/%s/%s", baseURL, GetNodeInfoPrefix, testNodeName, GetNodeLogSuffix)
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, okGetReturner(c, expURL))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	resp, err := clstrC.GetNodeLog(testNodeName)
+	c.Assert(err, IsNil)
+	c.Assert(resp, DeepEquals, testGetData)
+}
+
+func (s *managerSuite) TestGetNodeTransitionsSuccess(c *C) {
+	tests := map[string]struct {
+		from  time.Time
+		to    time.Time
+		query string
+	}{
+		"no-range": {},
+		"range": {
+			from:  time.Date(2016, 3, 1, 10, 0, 0, 0, time.UTC),
+			to:    time.Date(2016, 3, 2, 10, 0, 0, 0, time.UTC),
+			query: "?from=2016-03-01T10%3A00%3A00Z&to=2016-03-02T10%3A00%3A00Z",
+		},
+	}
+
+	for testname, test := range tests {
+		expURLStr := fmt.Sprintf("http://%s/%s/%s/%s%s", baseURL, GetNodeInfoPrefix, testNodeName,
+			GetNodeTransitionsSuffix, test.query)
+		expURL, err := url.Parse(expURLStr)
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+		httpS, httpC := getHTTPTestClientAndServer(c, okGetReturner(c, expURL))
+		defer httpS.Close()
+		clstrC := Client{
+			url:   baseURL,
+			httpC: httpC,
+		}
+
+		resp, err := clstrC.GetNodeTransitions(testNodeName, test.from, test.to)
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+		c.Assert(resp, DeepEquals, testGetData, Commentf("test: %s", testname))
+	}
+}
+
 func (s *managerSuite) TestGetNodesSuccess(c *C) {
 	expURLStr := fmt.Sprintf("http://%s/%s", baseURL, GetNodesInfo)
 	expURL, err := url.Parse(expURLStr)
@@ -413,6 +871,47 @@ func (s *managerSuite) TestGetNodesSuccess(c *C) {
 	c.Assert(resp, DeepEquals, testGetData)
 }
 
+func (s *managerSuite) TestGetNodesQuerySuccess(c *C) {
+	tests := map[string]struct {
+		q     NodesQuery
+		query string
+	}{
+		"no-filter": {
+			query: "",
+		},
+		"status-group": {
+			q:     NodesQuery{Status: "Allocated", HostGroup: ansibleWorkerGroupName},
+			query: "?host_group=service-worker&status=Allocated",
+		},
+		"selector-sorted-paginated": {
+			q: NodesQuery{
+				Selector: "rack=r1",
+				Sort:     "-addr",
+				Fields:   []string{"inventory_state", "monitoring_state"},
+				Offset:   10,
+				Limit:    5,
+			},
+			query: "?fields=inventory_state%2Cmonitoring_state&limit=5&offset=10&selector=rack%3Dr1&sort=-addr",
+		},
+	}
+
+	for testname, test := range tests {
+		expURLStr := fmt.Sprintf("http://%s/%s%s", baseURL, GetNodesQuery, test.query)
+		expURL, err := url.Parse(expURLStr)
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+		httpS, httpC := getHTTPTestClientAndServer(c, okGetReturner(c, expURL))
+		defer httpS.Close()
+		clstrC := Client{
+			url:   baseURL,
+			httpC: httpC,
+		}
+
+		resp, err := clstrC.GetNodes(test.q)
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+		c.Assert(resp, DeepEquals, testGetData, Commentf("test: %s", testname))
+	}
+}
+
 func (s *managerSuite) TestGetGlobalsSuccess(c *C) {
 	expURLStr := fmt.Sprintf("http://%s/%s", baseURL, GetGlobals)
 	expURL, err := url.Parse(expURLStr)
@@ -445,6 +944,38 @@ func (s *managerSuite) TestGetConfigSuccess(c *C) {
 	c.Assert(resp, DeepEquals, testGetData)
 }
 
+func (s *managerSuite) TestGetSpecSuccess(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s", baseURL, GetSpec)
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, okGetReturner(c, expURL))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	resp, err := clstrC.GetSpec()
+	c.Assert(err, IsNil)
+	c.Assert(resp, DeepEquals, testGetData)
+}
+
+func (s *managerSuite) TestGetJobQueueSuccess(c *C) {
+	expURLStr := fmt.Sprintf("http://%s/%s", baseURL, GetJobQueue)
+	expURL, err := url.Parse(expURLStr)
+	c.Assert(err, IsNil)
+	httpS, httpC := getHTTPTestClientAndServer(c, okGetReturner(c, expURL))
+	defer httpS.Close()
+	clstrC := Client{
+		url:   baseURL,
+		httpC: httpC,
+	}
+
+	resp, err := clstrC.GetJobQueue()
+	c.Assert(err, IsNil)
+	c.Assert(resp, DeepEquals, testGetData)
+}
+
 func (s *managerSuite) TestGetJobSuccess(c *C) {
 	expURLStr := fmt.Sprintf("http://%s/%s/%s", baseURL, GetJobPrefix, testJobLabel)
 	expURL, err := url.Parse(expURLStr)
@@ -461,6 +992,44 @@ func (s *managerSuite) TestGetJobSuccess(c *C) {
 	c.Assert(resp, DeepEquals, testGetData)
 }
 
+func (s *managerSuite) TestGetJobsSuccess(c *C) {
+	tests := map[string]struct {
+		status string
+		node   string
+		offset int
+		limit  int
+		query  string
+	}{
+		"no-filter": {},
+		"status": {
+			status: Complete.String(),
+			query:  "?status=Complete",
+		},
+		"node-paginated": {
+			node:   testNodeName,
+			offset: 10,
+			limit:  5,
+			query:  "?limit=5&node=testNode&offset=10",
+		},
+	}
+
+	for testname, test := range tests {
+		expURLStr := fmt.Sprintf("http://%s/%s%s", baseURL, GetJobsInfo, test.query)
+		expURL, err := url.Parse(expURLStr)
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+		httpS, httpC := getHTTPTestClientAndServer(c, okGetReturner(c, expURL))
+		defer httpS.Close()
+		clstrC := Client{
+			url:   baseURL,
+			httpC: httpC,
+		}
+
+		resp, err := clstrC.GetJobs(test.status, test.node, test.offset, test.limit)
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+		c.Assert(resp, DeepEquals, testGetData, Commentf("test: %s", testname))
+	}
+}
+
 func (s *managerSuite) TestStreamLogsSuccess(c *C) {
 	expURLStr := fmt.Sprintf("http://%s/%s/%s", baseURL, GetJobLogPrefix, testJobLabel)
 	expURL, err := url.Parse(expURLStr)
@@ -9,59 +9,125 @@ import (
 	"github.com/contiv/errored"
 )
 
-func errActiveJob(desc string) error {
-	return errored.Errorf("there is already an active job, please try in sometime. Job: %s", desc)
-}
-
-// commissionEvent triggers the commission workflow
+// commissionEvent triggers the commission workflow. The nodes may be
+// commissioned in different host-groups, in which case they are commissioned
+// in phases as per the host-groups that their host-group requires.
 type commissionEvent struct {
-	mgr       *Manager
-	nodeNames []string
-	extraVars string
-	hostGroup string
+	mgr        *Manager
+	nodeNames  []string
+	extraVars  string
+	hostGroup  string
+	hostGroups map[string]string
+	priority   int
+	// selector is the label selector of the nodes, used instead of nodeNames
+	selector string
 
+	_job    *Job
 	_hosts  configuration.SubsysHosts
 	_enodes map[string]*node
 }
 
 // newCommissionEvent creates and returns commissionEvent
-func newCommissionEvent(mgr *Manager, nodeNames []string, extraVars, hostGroup string) *commissionEvent {
+func newCommissionEvent(mgr *Manager, nodeNames []string, extraVars, hostGroup string, priority int) *commissionEvent {
 	return &commissionEvent{
 		mgr:       mgr,
 		nodeNames: nodeNames,
 		extraVars: extraVars,
 		hostGroup: hostGroup,
+		priority:  priority,
+	}
+}
+
+// newCommissionEventByHostGroup creates and returns commissionEvent that
+// commissions the nodes in their host-group, as per hostGroups which is keyed
+// by node name
+func newCommissionEventByHostGroup(mgr *Manager, hostGroups map[string]string, extraVars string, priority int) *commissionEvent {
+	return &commissionEvent{
+		mgr:        mgr,
+		nodeNames:  sortedKeys(hostGroups),
+		extraVars:  extraVars,
+		hostGroups: hostGroups,
+		priority:   priority,
+	}
+}
+
+// newCommissionEventBySelector creates and returns commissionEvent that
+// commissions the nodes whose labels match the selector
+func newCommissionEventBySelector(mgr *Manager, selector, extraVars, hostGroup string, priority int) *commissionEvent {
+	return &commissionEvent{
+		mgr:       mgr,
+		selector:  selector,
+		extraVars: extraVars,
+		hostGroup: hostGroup,
+		priority:  priority,
 	}
 }
 
 func (e *commissionEvent) String() string {
+	if e.selector != "" {
+		return fmt.Sprintf("commissionEvent: nodes:%v selector:%q extra-vars:%v host-group:%v",
+			e.nodeNames, e.selector, e.extraVars, e.hostGroup)
+	}
+	if e.hostGroups != nil {
+		return fmt.Sprintf("commissionEvent: nodes:%v extra-vars:%v host-groups:%v",
+			e.nodeNames, e.extraVars, e.hostGroups)
+	}
 	return fmt.Sprintf("commissionEvent: nodes:%v extra-vars:%v host-group:%v",
 		e.nodeNames, e.extraVars, e.hostGroup)
 }
 
+// targetHostGroups returns the host-group of each node of the event
+func (e *commissionEvent) targetHostGroups() map[string]string {
+	if e.hostGroups != nil {
+		return e.hostGroups
+	}
+	targets := map[string]string{}
+	for _, name := range e.nodeNames {
+		targets[name] = e.hostGroup
+	}
+	return targets
+}
+
+func (e *commissionEvent) job() *Job {
+	return e._job
+}
+
 func (e *commissionEvent) process() error {
 	// err shouldn't be redefined below
 	var err error
 
-	err = e.mgr.checkAndSetActiveJob(
-		e.String(),
-		e.configureOrCleanupOnErrorRunner,
-		func(status JobStatus, errRet error) {
-			if status == Errored {
-				logrus.Errorf("configuration job failed. Error: %v", errRet)
-				// set assets as unallocated
-				e.mgr.setAssetsStatusBestEffort(e.nodeNames, e.mgr.inventory.SetAssetUnallocated)
-				return
+	// the job is created once, the event is processed again if the job is queued
+	if e._job == nil {
+		// the nodes are selected once, when the event is first processed
+		if e.selector != "" {
+			if e.nodeNames, err = e.mgr.nodesByLabels(e.selector); err != nil {
+				return err
 			}
-			// set assets as commissioned
-			e.mgr.setAssetsStatusBestEffort(e.nodeNames, e.mgr.inventory.SetAssetCommissioned)
-		})
-	if err != nil {
+		}
+		e._job = e.mgr.newJob(
+			e.String(),
+			e.configureOrCleanupOnErrorRunner,
+			func(status JobStatus, errRet error) {
+				if status == Errored {
+					logrus.Errorf("configuration job failed. Error: %v", errRet)
+				}
+				// set the assets that were configured and verified as commissioned
+				// and rest of the assets as unallocated
+				okNodes, failedNodes := splitNodesByResult(e.nodeNames, e._job.Results())
+				e.mgr.setAssetsStatusBestEffort(okNodes, e.mgr.inventory.SetAssetCommissioned)
+				e.mgr.clearDegraded(okNodes)
+				e.mgr.setAssetsStatusBestEffort(failedNodes, e.mgr.inventory.SetAssetUnallocated)
+			},
+			e.nodeNames)
+		e._job.setParams(jobKindCommission, e.extraVars, e.targetHostGroups())
+	}
+	job := e._job
+	if err = e.mgr.checkAndSetActiveJob(job, e, e.priority); err != nil {
 		return err
 	}
 	defer func() {
 		if err != nil {
-			e.mgr.resetActiveJob()
+			e.mgr.resetActiveJob(job)
 		}
 	}()
 
@@ -82,7 +148,7 @@ func (e *commissionEvent) process() error {
 	}
 
 	// trigger node configuration
-	go e.mgr.runActiveJob()
+	go e.mgr.runActiveJob(job)
 
 	return nil
 }
@@ -94,54 +160,26 @@ func (e *commissionEvent) eventValidate() error {
 		return err
 	}
 
-	if !IsValidHostGroup(e.hostGroup) {
-		return errored.Errorf("invalid or empty host-group specified: %q", e.hostGroup)
-	}
-
-	// when workers are being configured, make sure that there is atleast one service-master
-	if e.hostGroup == ansibleWorkerGroupName {
-		masterCommissioned := false
-		for name := range e.mgr.nodes {
-			if _, ok := e._enodes[name]; ok {
-				// skip nodes in the event
-				continue
-			}
-
-			isDiscoveredAndAllocated, err := e.mgr.isDiscoveredAndAllocatedNode(name)
-			if err != nil || !isDiscoveredAndAllocated {
-				if err != nil {
-					logrus.Debugf("a node check failed for %q. Error: %s", name, err)
-				}
-				// skip hosts that are not yet provisioned or not in discovered state
-				continue
-			}
-
-			isMasterNode, err := e.mgr.isMasterNode(name)
-			if err != nil || !isMasterNode {
-				if err != nil {
-					logrus.Debugf("a node check failed for %q. Error: %s", name, err)
-				}
-				//skip the hosts that are not in master group
-				continue
-			}
-
-			// found a master node
-			masterCommissioned = true
-			break
-		}
-		if !masterCommissioned {
-			return errored.Errorf("Cannot commission a worker node without existence of a master node in the cluster, make sure atleast one master node is commissioned.")
+	targets := e.targetHostGroups()
+	for _, name := range e.nodeNames {
+		if !e.mgr.isValidHostGroup(targets[name]) {
+			return errored.Errorf("invalid or empty host-group specified: %q", targets[name])
 		}
 	}
-	return nil
+
+	return e.mgr.checkNodeHostGroups(e._enodes, targets, "commission")
 }
 
-// prepareInventory adds the specified nodes to the specified host-group
+// prepareInventory adds the specified nodes to their host-group
 func (e *commissionEvent) prepareInventory() error {
+	targets := e.targetHostGroups()
 	hosts := []*configuration.AnsibleHost{}
-	for _, node := range e._enodes {
+	for name, node := range e._enodes {
 		hostInfo := node.Cfg.(*configuration.AnsibleHost)
-		hostInfo.SetGroup(e.hostGroup)
+		hostInfo.SetGroup(targets[name])
+		if err := e.mgr.saveNodeConfig(name); err != nil {
+			return err
+		}
 		hosts = append(hosts, hostInfo)
 	}
 	e._hosts = hosts
@@ -150,19 +188,71 @@ func (e *commissionEvent) prepareInventory() error {
 }
 
 // configureOrCleanupOnErrorRunner is the job runner that runs configuration playbooks on one or more nodes.
-// It runs cleanup playbook on failure
+// The nodes where the configuration succeeded are set as provisioned and are verified, before they are
+// commissioned. When the nodes are commissioned in phases, a phase is run only if all the nodes of the
+// previous phases were commissioned, the nodes of the phases that are not run are reported as failed.
+// It runs cleanup playbook on the nodes where the configuration or verification failed, or on all the
+// attempted nodes on cancellation, in which case none of the nodes are commissioned
 func (e *commissionEvent) configureOrCleanupOnErrorRunner(cancelCh CancelChannel, jobLogs io.Writer) error {
-	outReader, cancelFunc, errCh := e.mgr.configuration.Configure(e._hosts, e.extraVars)
-	cfgErr := logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs)
+	results := uniformResults(e.nodeNames, configuration.HostResultFailed)
+	phases := commissionPhases(e.mgr.hostGroups(), e.targetHostGroups())
+	attempted := []string{}
+	var cfgErr error
+	for i, phase := range phases {
+		if len(phases) > 1 {
+			fmt.Fprintf(jobLogs, "commissioning nodes %v, phase %d of %d\n", phase, i+1, len(phases))
+		}
+		attempted = append(attempted, phase...)
+		if cfgErr = e.configurePhase(phase, results, cancelCh, jobLogs); cfgErr != nil {
+			break
+		}
+	}
+	if cfgErr == errJobCancelled {
+		for _, name := range attempted {
+			results[name] = configuration.HostResultFailed
+		}
+	}
+	e._job.setResults(results)
+	okNodes, failedNodes := splitNodesByResult(attempted, results)
+	e.mgr.gatherFacts(filterHosts(e._hosts, okNodes), jobLogs)
 	if cfgErr == nil {
 		return nil
 	}
 	logrus.Errorf("configuration failed, starting cleanup. Error: %s", cfgErr)
-	outReader, cancelFunc, errCh = e.mgr.configuration.Cleanup(e._hosts, e.extraVars)
-	if err := logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs); err != nil {
+	outReader, cancelFunc, errCh := e.mgr.configuration.Cleanup(filterHosts(e._hosts, failedNodes), e.extraVars)
+	if err := logOutputAndReturnStatus(outReader, errCh, cleanupCancelChannel(cancelCh, cfgErr),
+		cancelFunc, jobLogs); err != nil {
 		logrus.Errorf("cleanup failed. Error: %s", err)
 	}
 
 	//return the error status from provisioning
 	return cfgErr
 }
+
+// configurePhase configures and verifies the nodes of a commission phase and
+// records their outcome in the results. It returns the error of the phase.
+func (e *commissionEvent) configurePhase(nodeNames []string, results map[string]configuration.HostResult,
+	cancelCh CancelChannel, jobLogs io.Writer) error {
+	outReader, cancelFunc, errCh := e.mgr.configuration.Configure(filterHosts(e._hosts, nodeNames), e.extraVars)
+	cfgErr := logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs)
+	for name, result := range stepResults(nodeNames, cfgErr) {
+		results[name] = result
+	}
+	okNodes, _ := splitNodesByResult(nodeNames, results)
+	if len(okNodes) == 0 {
+		return cfgErr
+	}
+	e.mgr.setAssetsStatusBestEffort(okNodes, e.mgr.inventory.SetAssetProvisioned)
+	verifyErr := e.mgr.verifyProvisioned(filterHosts(e._hosts, okNodes), e.extraVars, cancelCh, jobLogs)
+	if verifyErr == nil {
+		return cfgErr
+	}
+	logrus.Errorf("verification of provisioned nodes failed. Error: %s", verifyErr)
+	for name, result := range stepResults(okNodes, verifyErr) {
+		results[name] = result
+	}
+	if cfgErr == nil {
+		cfgErr = verifyErr
+	}
+	return cfgErr
+}
@@ -13,8 +13,51 @@ import (
 	"github.com/mapuri/serf/client"
 )
 
+const (
+	// ReconcileRollback is the reconcile policy to roll back the assets left in a
+	// transitional status by an interrupted job to the nearest stable status
+	ReconcileRollback = "rollback"
+	// ReconcileRetry is the reconcile policy to re-run the interrupted job's
+	// playbook on the assets once they are discovered again
+	ReconcileRetry = "retry"
+	// ReconcileFlag is the reconcile policy to leave the assets as is and flag
+	// them for the operator to act upon
+	ReconcileFlag = "flag"
+)
+
 type clustermConfig struct {
 	Addr string `json:"addr"`
+	// ReconcilePolicy is the action taken on startup for the assets that were
+	// left in a transitional status by the jobs interrupted by clusterm's stop
+	ReconcilePolicy string `json:"reconcile_policy"`
+	// AutoCommission is the policy to commission the newly discovered nodes automatically
+	AutoCommission autoCommissionConfig `json:"auto_commission"`
+	// Remediation are the policies, keyed by host-group, to remediate the
+	// commissioned nodes that stay disappeared
+	Remediation map[string]remediationPolicy `json:"remediation,omitempty"`
+	// HostGroups are the host-groups that the nodes can be commissioned in,
+	// keyed by their name in ansible
+	HostGroups map[string]hostGroupConfig `json:"host_groups"`
+	// SpecReconcileInterval is the interval, like 1m, at which the cluster is
+	// reconciled to the applied spec. 0s disables the periodic reconcile.
+	SpecReconcileInterval string `json:"spec_reconcile_interval"`
+	// JobHistory is the store and the retention of the job history
+	JobHistory jobHistoryConfig `json:"job_history"`
+}
+
+// jobHistoryConfig is the configuration of the job history. The history is kept
+// in boltdb, along with the maintenance info of the nodes and the applied spec.
+type jobHistoryConfig struct {
+	// BoltDB is the boltdb that the job history is kept in. When it is not
+	// specified, the boltdb of the inventory is used, or else the job history
+	// is not kept when the inventory is collins.
+	BoltDB *boltdb.Config `json:"boltdb,omitempty"`
+	// MaxJobs is the number of most recent jobs that are kept in the job history,
+	// the older jobs are deleted along with their logs. 0 means no limit.
+	MaxJobs int `json:"max_jobs"`
+	// MaxAge is the duration, like 720h, that a job is kept in the job history
+	// once it is done. 0s or empty means no limit.
+	MaxAge string `json:"max_age,omitempty"`
 }
 
 type inventorySubsysConfig struct {
@@ -50,11 +93,48 @@ func DefaultConfig() *Config {
 			PrivKeyFile:       "/vagrant/management/src/demo/files/insecure_private_key",
 		},
 		Manager: clustermConfig{
-			Addr: "0.0.0.0:9007",
+			Addr:            "0.0.0.0:9007",
+			ReconcilePolicy: ReconcileRollback,
+			AutoCommission: autoCommissionConfig{
+				Masters:     1,
+				HostGroup:   ansibleWorkerGroupName,
+				BatchWindow: "30s",
+			},
+			HostGroups:            defaultHostGroups(),
+			SpecReconcileInterval: "1m",
+			JobHistory: jobHistoryConfig{
+				MaxJobs: 1000,
+			},
 		},
 	}
 }
 
+// inventoryDBConfig returns the configuration of the boltdb inventory, nil if
+// the inventory is collins. We give priority to boltdb inventory if both are set
+// in config and if no inventory config was provided then we default to boltdb.
+func (c *Config) inventoryDBConfig() *boltdb.Config {
+	if c.Inventory.BoltDB != nil {
+		return c.Inventory.BoltDB
+	}
+	if c.Inventory.Collins != nil {
+		return nil
+	}
+	config := boltdb.DefaultConfig()
+	return &config
+}
+
+// jobHistoryDBConfig returns the configuration of the boltdb that the job
+// history is kept in. It is the job history's own boltdb, when one is
+// configured, or else the boltdb of the inventory. It is nil when the inventory
+// is collins and no boltdb is configured for the job history, in which case the
+// job history is not kept.
+func (c *Config) jobHistoryDBConfig() *boltdb.Config {
+	if c.Manager.JobHistory.BoltDB != nil {
+		return c.Manager.JobHistory.BoltDB
+	}
+	return c.inventoryDBConfig()
+}
+
 // read parses the configuration from the specified reader
 // On success, it also return the updated receiver configuration
 func (c *Config) read(r io.Reader) (*Config, error) {
@@ -71,11 +151,16 @@ func (c *Config) read(r io.Reader) (*Config, error) {
 }
 
 // MergeFromConfig merges the specified configuration into the receiver configuration
-// On success, it also return the updated receiver configuration
+// On success, it also return the updated receiver configuration. The host-groups,
+// when specified, replace the receiver's host-groups instead of being merged
+// with them, so that the default host-groups can be left out.
 func (c *Config) MergeFromConfig(src *Config) (*Config, error) {
 	if err := mergo.MergeWithOverwrite(c, src); err != nil {
 		return nil, errored.Errorf("failed to merge configuration. Error: %s", err)
 	}
+	if len(src.Manager.HostGroups) > 0 {
+		c.Manager.HostGroups = src.Manager.HostGroups
+	}
 	return c, nil
 }
 
@@ -93,3 +93,81 @@ func (s *configSuite) TestMergeConfigSuccessBoltdbInventory(c *C) {
 	c.Assert(dst.Inventory.BoltDB, DeepEquals, exptdDst.Inventory.BoltDB)
 	c.Assert(dst.Inventory.Collins, Equals, (*collins.Config)(nil))
 }
+
+func (s *configSuite) TestMergeConfigHostGroups(c *C) {
+	// the configured host-groups replace the default host-groups
+	dst := DefaultConfig()
+	confStr := `{
+		"manager" : {
+			"host_groups" : {
+				"etcd" : { "min" : 3 },
+				"kube-worker" : { "requires" : [ "etcd" ] }
+			}
+		}
+	}`
+	_, err := dst.MergeFromReader(strings.NewReader(confStr))
+	c.Assert(err, IsNil)
+	c.Assert(dst.Manager.HostGroups, DeepEquals, map[string]hostGroupConfig{
+		"etcd":        {Min: 3},
+		"kube-worker": {Requires: []string{"etcd"}},
+	})
+	c.Assert(validateHostGroups(dst.Manager.HostGroups), IsNil)
+
+	// the default host-groups are kept when none are configured
+	dst = DefaultConfig()
+	_, err = dst.MergeFromReader(strings.NewReader(`{ "manager" : { "addr" : "0.0.0.0:9007" } }`))
+	c.Assert(err, IsNil)
+	c.Assert(dst.Manager.HostGroups, DeepEquals, defaultHostGroups())
+}
+
+func (s *configSuite) TestJobHistoryDBConfig(c *C) {
+	histDB := &boltdb.Config{DBFile: "history.boltdb"}
+	invDB := &boltdb.Config{DBFile: "inventory.boltdb"}
+	collinsCfg := &collins.Config{URL: "http://collins:9000"}
+	defaultDB := boltdb.DefaultConfig()
+	tests := map[string]struct {
+		inventory inventorySubsysConfig
+		history   *boltdb.Config
+		exptdInv  *boltdb.Config
+		exptdHist *boltdb.Config
+	}{
+		"default": {
+			exptdInv:  &defaultDB,
+			exptdHist: &defaultDB,
+		},
+		"boltdb-inventory": {
+			inventory: inventorySubsysConfig{BoltDB: invDB},
+			exptdInv:  invDB,
+			exptdHist: invDB,
+		},
+		"collins-inventory": {
+			inventory: inventorySubsysConfig{Collins: collinsCfg},
+		},
+		"collins-inventory-history-boltdb": {
+			inventory: inventorySubsysConfig{Collins: collinsCfg},
+			history:   histDB,
+			exptdHist: histDB,
+		},
+		"boltdb-inventory-history-boltdb": {
+			inventory: inventorySubsysConfig{BoltDB: invDB},
+			history:   histDB,
+			exptdInv:  invDB,
+			exptdHist: histDB,
+		},
+	}
+	for testname, test := range tests {
+		config := DefaultConfig()
+		config.Inventory = test.inventory
+		config.Manager.JobHistory.BoltDB = test.history
+		c.Assert(config.inventoryDBConfig(), DeepEquals, test.exptdInv, Commentf("test: %s", testname))
+		c.Assert(config.jobHistoryDBConfig(), DeepEquals, test.exptdHist, Commentf("test: %s", testname))
+	}
+}
+
+func (s *configSuite) TestValidateJobHistoryConfig(c *C) {
+	c.Assert(validateJobHistoryConfig(DefaultConfig().Manager.JobHistory), IsNil)
+	c.Assert(validateJobHistoryConfig(jobHistoryConfig{MaxAge: "720h"}), IsNil)
+	c.Assert(validateJobHistoryConfig(jobHistoryConfig{MaxJobs: -1}), NotNil)
+	c.Assert(validateJobHistoryConfig(jobHistoryConfig{MaxAge: "foo"}), NotNil)
+	c.Assert(validateJobHistoryConfig(jobHistoryConfig{MaxAge: "-1h"}), NotNil)
+}
@@ -11,14 +11,31 @@ const (
 	// to decommission one or more assets
 	PostNodesDecommission = "decommission/nodes"
 
+	// PostNodeReplace is the prefix for the POST REST endpoint
+	// to replace a commissioned asset by a spare asset
+	PostNodeReplace = "replace/node"
+
 	// PostNodesUpdate is the prefix for the POST REST endpoint
 	// to update configuration of one or more assets
 	PostNodesUpdate = "update/nodes"
 
+	// PostNodesUpgrade is the prefix for the POST REST endpoint
+	// to upgrade one or more commissioned assets in batches
+	PostNodesUpgrade = "upgrade/nodes"
+
+	// PostNodesMaintenance is the prefix for the POST REST endpoint
+	// to put one or more commissioned assets in maintenance or take them out of it
+	PostNodesMaintenance = "maintenance/nodes"
+
 	// PostNodesDiscover is the prefix for the POST REST endpoint
 	// to provision one or more specified nodes for discovery
 	PostNodesDiscover = "discover/nodes"
 
+	// PostNodeLabelsPrefix is the prefix for the POST REST endpoint
+	// to set the labels of an asset
+	PostNodeLabelsPrefix = "labels/node"
+	postNodeLabels       = PostNodeLabelsPrefix + "/{tag}"
+
 	// PostGlobals is the prefix for the POST REST endpoint
 	// to set global configuration values
 	PostGlobals = "globals"
@@ -27,28 +44,81 @@ const (
 	// to post a monitor event for one or more nodes.
 	PostMonitorEvent = "monitor/event"
 
+	// PostJobCancelPrefix is the prefix for the POST REST endpoint
+	// to cancel a running provisioning job. {job} value can be
+	// 'active' or the id of an active job
+	PostJobCancelPrefix = "cancel/job"
+	postJobCancel       = PostJobCancelPrefix + "/{job}"
+
+	// PostJobDequeuePrefix is the prefix for the POST REST endpoint
+	// to remove a job from the job queue. {job} value is the id of a queued job
+	PostJobDequeuePrefix = "dequeue/job"
+	postJobDequeue       = PostJobDequeuePrefix + "/{job}"
+
+	// PostDeleteSpec is the prefix for the REST endpoint to POST the spec i.e.
+	// the desired state of the cluster, or DELETE the applied spec
+	PostDeleteSpec = "spec"
+
+	// PostSpecPlan is the prefix for the POST REST endpoint
+	// to fetch the actions that converge the cluster to a spec, without acting on it
+	PostSpecPlan = "spec/plan"
+
+	// DeleteNodePrefix is the prefix for the DELETE REST endpoint
+	// to purge a decommissioned asset from clusterm and the inventory
+	DeleteNodePrefix = "node"
+	deleteNode       = DeleteNodePrefix + "/{tag}"
+
 	// GetNodeInfoPrefix is the prefix for the GET REST endpoint
 	// to fetch info for an asset
 	GetNodeInfoPrefix = "info/node"
 	getNodeInfo       = GetNodeInfoPrefix + "/{tag}"
 
+	// GetNodeLogSuffix is the suffix, after GetNodeInfoPrefix and the node's name,
+	// for the GET REST endpoint to fetch the event logs of an asset
+	GetNodeLogSuffix = "log"
+	getNodeLog       = getNodeInfo + "/" + GetNodeLogSuffix
+
+	// GetNodeTransitionsSuffix is the suffix, after GetNodeInfoPrefix and the
+	// node's name, for the GET REST endpoint to fetch the transition history of
+	// an asset
+	GetNodeTransitionsSuffix = "transitions"
+	getNodeTransitions       = getNodeInfo + "/" + GetNodeTransitionsSuffix
+
 	// GetNodesInfo is the prefix for the GET REST endpoint
-	// to fetch info for all know assets
+	// to fetch info for all know assets, keyed by their name
 	GetNodesInfo = "info/nodes"
 
+	// GetNodesQuery is the prefix for the GET REST endpoint to fetch a page
+	// of the assets. The nodes can be filtered, sorted and paginated using the
+	// query parameters
+	GetNodesQuery = "info/nodes/query"
+
 	// GetGlobals is the prefix for the GET REST endpoint
 	// to fetch the global configuration values
 	GetGlobals = "info/globals"
 
+	// GetSpec is the prefix for the GET REST endpoint
+	// to fetch the applied spec along with it's current plan
+	GetSpec = "info/spec"
+
 	// GetJobPrefix is the prefix for the GET REST endpoint
 	// to fetch the status and logs of a provisioning job. {job} value can be
-	// 'active' or 'last'
+	// 'active', 'last' or the id of a job
 	GetJobPrefix = "info/job"
 	getJob       = GetJobPrefix + "/{job}"
 
+	// GetJobsInfo is the prefix for the GET REST endpoint
+	// to fetch the status of the jobs in job history. The jobs can be filtered
+	// and paginated using the query parameters
+	GetJobsInfo = "info/jobs"
+
+	// GetJobQueue is the prefix for the GET REST endpoint
+	// to fetch the jobs waiting in the job queue
+	GetJobQueue = "info/queue"
+
 	// GetJobLogPrefix is the prefix for the GET REST endpoint
 	// to stream the logs of a provisioning job. {job} value can be
-	// 'active'
+	// 'active' or the id of an active job
 	GetJobLogPrefix = "info/logs"
 	getJobLog       = GetJobLogPrefix + "/{job}"
 
@@ -74,6 +144,36 @@ const (
 
 	jobLabelActive = "active"
 	jobLabelLast   = "last"
+
+	// query parameters for filtering and paginating the job history
+	jobsQueryStatus = "status"
+	jobsQueryNode   = "node"
+	jobsQueryOffset = "offset"
+	jobsQueryLimit  = "limit"
+
+	// query parameters for filtering, sorting and paginating the nodes
+	nodesQueryStatus       = "status"
+	nodesQueryState        = "state"
+	nodesQueryHostGroup    = "host_group"
+	nodesQueryMonitorLabel = "monitor_label"
+	nodesQueryAddr         = "addr"
+	nodesQuerySelector     = "selector"
+	nodesQuerySort         = "sort"
+	nodesQueryFields       = "fields"
+	nodesQueryOffset       = "offset"
+	nodesQueryLimit        = "limit"
+
+	// query parameter for the POST requests that start a job, to wait for
+	// the job to be done
+	postQueryWait = "wait"
+
+	// query parameters for the time range of the transition history, in RFC3339 format
+	transitionsQueryFrom = "from"
+	transitionsQueryTo   = "to"
+
+	// query parameter for the DELETE request to purge a node that is not
+	// decommissioned
+	deleteQueryForce = "force"
 )
 
 // JobStatus corresponds to possible status values of a job
@@ -86,6 +186,8 @@ const (
 	Running
 	// Complete is the status of the job that ends with success
 	Complete
-	// Errored is the status of the job that ends with error including user triggered cancellation
+	// Errored is the status of the job that ends with error
 	Errored
+	// Cancelled is the status of the job that ends as it was cancelled by the user
+	Cancelled
 )
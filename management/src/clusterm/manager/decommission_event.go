@@ -3,10 +3,10 @@ package manager
 import (
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/contiv/cluster/management/src/configuration"
-	"github.com/contiv/errored"
 )
 
 // decommissionEvent triggers the decommission workflow
@@ -14,50 +14,99 @@ type decommissionEvent struct {
 	mgr       *Manager
 	nodeNames []string
 	extraVars string
-
-	_hosts  configuration.SubsysHosts
-	_enodes map[string]*node
+	priority  int
+	// force decommissions the nodes even if they are not reachable. The cleanup
+	// is skipped on the nodes that are not reachable, it's result is ignored
+	// and the master node count is not enforced.
+	force bool
+	// selector is the label selector of the nodes, used instead of nodeNames
+	selector string
+
+	_job     *Job
+	_hosts   configuration.SubsysHosts
+	_enodes  map[string]*node
+	_skipped []string
 }
 
 // newDecommissionEvent creates and returns decommissionEvent
-func newDecommissionEvent(mgr *Manager, nodeNames []string, extraVars string) *decommissionEvent {
+func newDecommissionEvent(mgr *Manager, nodeNames []string, extraVars string, priority int, force bool) *decommissionEvent {
 	return &decommissionEvent{
 		mgr:       mgr,
 		nodeNames: nodeNames,
 		extraVars: extraVars,
+		priority:  priority,
+		force:     force,
+	}
+}
+
+// newDecommissionEventBySelector creates and returns decommissionEvent that
+// decommissions the nodes whose labels match the selector
+func newDecommissionEventBySelector(mgr *Manager, selector, extraVars string, priority int, force bool) *decommissionEvent {
+	return &decommissionEvent{
+		mgr:       mgr,
+		selector:  selector,
+		extraVars: extraVars,
+		priority:  priority,
+		force:     force,
 	}
 }
 
 func (e *decommissionEvent) String() string {
-	return fmt.Sprintf("decommissionEvent: nodes:%v extra-vars: %v", e.nodeNames, e.extraVars)
+	if e.selector != "" {
+		return fmt.Sprintf("decommissionEvent: nodes:%v selector:%q extra-vars: %v force: %v",
+			e.nodeNames, e.selector, e.extraVars, e.force)
+	}
+	return fmt.Sprintf("decommissionEvent: nodes:%v extra-vars: %v force: %v", e.nodeNames, e.extraVars, e.force)
+}
+
+func (e *decommissionEvent) job() *Job {
+	return e._job
 }
 
 func (e *decommissionEvent) process() error {
 	// err shouldn't be redefined below
 	var err error
 
-	err = e.mgr.checkAndSetActiveJob(
-		e.String(),
-		e.cleanupRunner,
-		func(status JobStatus, errRet error) {
-			if status == Errored {
-				logrus.Errorf("cleanup job failed. Error: %v", errRet)
+	// the job is created once, the event is processed again if the job is queued
+	if e._job == nil {
+		// the nodes are selected once, when the event is first processed
+		if e.selector != "" {
+			if e.nodeNames, err = e.mgr.nodesByLabels(e.selector); err != nil {
+				return err
 			}
-
-			// set assets as decommissioned
-			e.mgr.setAssetsStatusBestEffort(e.nodeNames, e.mgr.inventory.SetAssetDecommissioned)
-		})
-	if err != nil {
+		}
+		e._job = e.mgr.newJob(
+			e.String(),
+			e.cleanupRunner,
+			func(status JobStatus, errRet error) {
+				if status == Errored {
+					logrus.Errorf("cleanup job failed. Error: %v", errRet)
+				}
+
+				// set assets as decommissioned. This is done even if the cleanup was
+				// cancelled, as decommissioned is the only status that a cancelled asset
+				// can move to and the node can be commissioned again from there.
+				if e.force {
+					e.setForcedDecommissioned()
+					return
+				}
+				e.mgr.setAssetsStatusBestEffort(e.nodeNames, e.mgr.inventory.SetAssetDecommissioned)
+			},
+			e.nodeNames)
+		e._job.setParams(jobKindDecommission, e.extraVars, nil)
+	}
+	job := e._job
+	if err = e.mgr.checkAndSetActiveJob(job, e, e.priority); err != nil {
 		return err
 	}
 	defer func() {
 		if err != nil {
-			e.mgr.resetActiveJob()
+			e.mgr.resetActiveJob(job)
 		}
 	}()
 
 	// validate event data
-	if e._enodes, err = e.mgr.commonEventValidate(e.nodeNames); err != nil {
+	if err = e.eventValidate(); err != nil {
 		return err
 	}
 
@@ -73,63 +122,98 @@ func (e *decommissionEvent) process() error {
 	}
 
 	// trigger node cleanup
-	go e.mgr.runActiveJob()
+	go e.mgr.runActiveJob(job)
 
 	return nil
 }
 
-// prepareInventory validates that after the cleanup on the nodes in the event,
-// one of following is still true:
+// eventValidate validates the nodes of the event. The nodes need not be
+// reachable when the decommission is forced.
+func (e *decommissionEvent) eventValidate() error {
+	var err error
+	if e.force {
+		e._enodes, err = e.mgr.forcedEventValidate(e.nodeNames)
+	} else {
+		e._enodes, err = e.mgr.commonEventValidate(e.nodeNames)
+	}
+	return err
+}
+
+// prepareInventory validates that the cluster's host-groups stay as per their
+// definition after the cleanup on the nodes in the event, unless the decommission
+// is forced. For instance with the default host-groups, one of following shall
+// still be true:
 // - all nodes have been cleaned up; OR
 // - there is atleast one master node left
 func (e *decommissionEvent) prepareInventory() error {
-	mastersLeft := 0
-	workersLeft := 0
-	for name := range e.mgr.nodes {
-		if _, ok := e._enodes[name]; ok {
-			// skip the node in the event
-			continue
-		}
-		isDiscoveredAndAllocated, err := e.mgr.isDiscoveredAndAllocatedNode(name)
-		if err != nil || !isDiscoveredAndAllocated {
-			if err != nil {
-				logrus.Debugf("a node check failed for %q. Error: %s", name, err)
-			}
-			// skip hosts that are not yet provisioned or not in discovered state
-			continue
+	if !e.force {
+		if err := e.mgr.checkHostGroups(e._enodes, "", "decommission"); err != nil {
+			return err
 		}
-		isWorkerNode, err := e.mgr.isWorkerNode(name)
-		if err != nil {
-			// skip this node
-			logrus.Debugf("a node check failed for %q. Error: %s", name, err)
-			continue
-		}
-		if isWorkerNode {
-			workersLeft++
-		} else {
-			mastersLeft++
-		}
-	}
-
-	if workersLeft > 0 && mastersLeft <= 0 {
-		return errored.Errorf("decommissioning the specified node(s) will leave only worker nodes in the cluster, make sure all worker nodes are decommissioned before last master node.")
 	}
 
-	// prepare the inventory
+	// prepare the inventory. The cleanup is skipped on the nodes that are not
+	// reachable, when the decommission is forced.
 	hosts := []*configuration.AnsibleHost{}
-	for _, node := range e._enodes {
+	e._skipped = nil
+	for name, node := range e._enodes {
+		if e.force {
+			if isDiscovered, _ := e.mgr.isDiscoveredNode(name); !isDiscovered {
+				e._skipped = append(e._skipped, name)
+				continue
+			}
+		}
 		hosts = append(hosts, node.Cfg.(*configuration.AnsibleHost))
 	}
 	e._hosts = hosts
+	sort.Strings(e._skipped)
 
 	return nil
 }
 
 // cleanupRunner is the job runner that runs cleanup playbooks on one or more nodes
 func (e *decommissionEvent) cleanupRunner(cancelCh CancelChannel, jobLogs io.Writer) error {
-	outReader, cancelFunc, errCh := e.mgr.configuration.Cleanup(e._hosts, e.extraVars)
-	if err := logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs); err != nil {
-		return err
+	var err error
+	if len(e._skipped) > 0 {
+		fmt.Fprintf(jobLogs, "skipping the cleanup of nodes %v as they are not reachable and the decommission is forced\n", e._skipped)
+	}
+	if len(e._skipped) < len(e.nodeNames) {
+		outReader, cancelFunc, errCh := e.mgr.configuration.Cleanup(e._hosts, e.extraVars)
+		err = logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs)
+	}
+	results := stepResults(e.nodeNames, err)
+	for _, name := range e._skipped {
+		results[name] = configuration.HostResultUnreachable
+	}
+	e._job.setResults(results)
+	// only the playbook failures are ignored, the cancellation of the job is
+	// still reported
+	if err != nil && err != errJobCancelled && e.force {
+		fmt.Fprintf(jobLogs, "ignoring the cleanup failure as the decommission is forced. Error: %v\n", err)
+		return nil
+	}
+	return err
+}
+
+// setForcedDecommissioned sets the assets of a forced decommission as
+// decommissioned, recording in the inventory the reason for the nodes where
+// the cleanup was skipped or failed
+func (e *decommissionEvent) setForcedDecommissioned() {
+	results := e._job.Results()
+	for _, name := range e.nodeNames {
+		var err error
+		switch results[name] {
+		case configuration.HostResultOK:
+			err = e.mgr.inventory.SetAssetDecommissioned(name)
+		case configuration.HostResultUnreachable:
+			err = e.mgr.inventory.SetAssetDecommissionedWithReason(name,
+				"Node was decommissioned by force without cleanup, as it was not reachable")
+		default:
+			err = e.mgr.inventory.SetAssetDecommissionedWithReason(name,
+				"Node was decommissioned by force, though it's cleanup failed")
+		}
+		if err != nil {
+			logrus.Errorf("failed to update %s's state in inventory, Error: %v", name, err)
+		}
 	}
-	return nil
 }
@@ -0,0 +1,113 @@
+// +build unittest
+
+package manager
+
+import (
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type decommissionSuite struct {
+}
+
+var _ = Suite(&decommissionSuite{})
+
+// newForceTestManager returns a manager with two commissioned nodes, of which
+// node1 has disappeared, with the configuration actions returning the specified error
+func newForceTestManager(c *C, ctrl *gomock.Controller, err error) *Manager {
+	mgr := newVerifyTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+		"node2": inventory.Allocated,
+	}, err, nil)
+	c.Assert(mgr.inventory.SetAssetDisappeared("node1"), IsNil)
+	return mgr
+}
+
+func (s *decommissionSuite) TestDecommissionUnreachable(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newForceTestManager(c, ctrl, nil)
+	e := newDecommissionEvent(mgr, []string{"node1"}, configuration.DefaultValidJSON, 0, false)
+	c.Assert(e.process(), ErrorMatches, ".*node1.*")
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated)
+}
+
+func (s *decommissionSuite) TestForcedDecommission(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newForceTestManager(c, ctrl, &configuration.HostsError{
+		Err: errored.Errorf("test failure"),
+		Results: map[string]configuration.HostResult{
+			"node2": configuration.HostResultFailed,
+		},
+	})
+	e := newDecommissionEvent(mgr, []string{"node1", "node2"}, configuration.DefaultValidJSON, 0, true)
+	c.Assert(e.process(), IsNil)
+	c.Assert(e._skipped, DeepEquals, []string{"node1"})
+	e._job.Wait()
+	// the cleanup failure is ignored as the decommission is forced
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(e._job.Results(), DeepEquals, map[string]configuration.HostResult{
+		"node1": configuration.HostResultUnreachable,
+		"node2": configuration.HostResultFailed,
+	})
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Decommissioned)
+	c.Assert(assetStatus(mgr, "node2"), Equals, inventory.Decommissioned)
+}
+
+func (s *decommissionSuite) TestForcedDecommissionCancelled(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	// the cleanup is cancelled
+	mgr := newForceTestManager(c, ctrl, errJobCancelled)
+	e := newDecommissionEvent(mgr, []string{"node1", "node2"}, configuration.DefaultValidJSON, 0, true)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	// the cancellation is not ignored, even though the decommission is forced
+	c.Assert(e._job.Info().Status, Equals, Cancelled.String())
+	c.Assert(e._job.Info().ErrVal, Equals, errJobCancelled.Error())
+	c.Assert(e._job.Results(), DeepEquals, map[string]configuration.HostResult{
+		"node1": configuration.HostResultUnreachable,
+		"node2": configuration.HostResultFailed,
+	})
+	// the cancelled nodes are still moved to decommissioned status
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Decommissioned)
+	c.Assert(assetStatus(mgr, "node2"), Equals, inventory.Decommissioned)
+}
+
+func (s *decommissionSuite) TestForcedUpdate(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newForceTestManager(c, ctrl, nil)
+	e := newUpdateEvent(mgr, []string{"node1"}, configuration.DefaultValidJSON, "", 0, false)
+	c.Assert(e.process(), ErrorMatches, ".*node1.*")
+
+	// a forced update needs atleast one reachable node
+	e = newUpdateEvent(mgr, []string{"node1"}, configuration.DefaultValidJSON, "", 0, true)
+	c.Assert(e.process(), ErrorMatches, ".*none of the nodes \\[node1\\] are reachable.*")
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated)
+
+	// the node that is not reachable is left out of the update
+	f := &phaseConfigSubsys{}
+	mgr.configuration = f
+	e = newUpdateEvent(mgr, []string{"node1", "node2"}, configuration.DefaultValidJSON, "", 0, true)
+	c.Assert(e.process(), IsNil)
+	c.Assert(e._skipped, DeepEquals, []string{"node1"})
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(e._job.Results(), DeepEquals, map[string]configuration.HostResult{
+		"node1": configuration.HostResultUnreachable,
+		"node2": configuration.HostResultOK,
+	})
+	c.Assert(f.cleanedUp, DeepEquals, [][]string{{"node2"}})
+	c.Assert(f.configured, DeepEquals, [][]string{{"node2"}})
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Unallocated)
+	c.Assert(assetStatus(mgr, "node2"), Equals, inventory.Allocated)
+}
@@ -40,5 +40,8 @@ func (e *disappearedEvent) process() error {
 		// XXX. Log this to collins
 		return err
 	}
+
+	// remediate the node if it doesn't reappear in time, as per the remediation policy
+	e.mgr.scheduleRemediation(name)
 	return nil
 }
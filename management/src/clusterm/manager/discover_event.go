@@ -14,16 +14,19 @@ type discoverEvent struct {
 	mgr       *Manager
 	nodeAddrs []string
 	extraVars string
+	priority  int
 
+	_job   *Job
 	_hosts configuration.SubsysHosts
 }
 
 // newDiscoverEvent creates and returns discoverEvent
-func newDiscoverEvent(mgr *Manager, nodeAddrs []string, extraVars string) *discoverEvent {
+func newDiscoverEvent(mgr *Manager, nodeAddrs []string, extraVars string, priority int) *discoverEvent {
 	return &discoverEvent{
 		mgr:       mgr,
 		nodeAddrs: nodeAddrs,
 		extraVars: extraVars,
+		priority:  priority,
 	}
 }
 
@@ -31,28 +34,54 @@ func (e *discoverEvent) String() string {
 	return fmt.Sprintf("discoverEvent: addr: %v extra-vars: %v", e.nodeAddrs, e.extraVars)
 }
 
+func (e *discoverEvent) job() *Job {
+	return e._job
+}
+
 func (e *discoverEvent) process() error {
 	// err shouldn't be redefined below
 	var err error
 
-	err = e.mgr.checkAndSetActiveJob(
-		e.String(),
-		e.discoverRunner,
-		func(status JobStatus, errRet error) {
-			if status == Errored {
-				logrus.Errorf("provisioning discovery job failed. Error: %v", errRet)
-			}
-		})
-	if err != nil {
+	// the job is created once, the event is processed again if the job is queued
+	if e._job == nil {
+		e._job = e.mgr.newJob(
+			e.String(),
+			e.discoverRunner,
+			func(status JobStatus, errRet error) {
+				if status == Errored {
+					logrus.Errorf("provisioning discovery job failed. Error: %v", errRet)
+				}
+			},
+			e.mgr.discoverLockNames(e.nodeAddrs))
+	}
+	job := e._job
+	if err = e.mgr.checkAndSetActiveJob(job, e, e.priority); err != nil {
 		return err
 	}
 	defer func() {
 		if err != nil {
-			e.mgr.resetActiveJob()
+			e.mgr.resetActiveJob(job)
 		}
 	}()
 
 	// validate
+	if err = e.eventValidate(); err != nil {
+		return err
+	}
+
+	// prepare inventory
+	if err = e.pepareInventory(); err != nil {
+		return err
+	}
+
+	// trigger node discovery provisioning
+	go e.mgr.runActiveJob(job)
+
+	return nil
+}
+
+// eventValidate makes sure that no node exists with the addresses being discovered
+func (e *discoverEvent) eventValidate() error {
 	existingNodes := []string{}
 	for _, addr := range e.nodeAddrs {
 		node, err := e.mgr.findNodeByMgmtAddr(addr)
@@ -61,26 +90,58 @@ func (e *discoverEvent) process() error {
 		}
 	}
 	if len(existingNodes) > 0 {
-		err = errored.Errorf("one or more nodes already exist with the specified management addresses. Existing nodes: %v", existingNodes)
-		return err
+		return errored.Errorf("one or more nodes already exist with the specified management addresses. Existing nodes: %v", existingNodes)
 	}
+	return nil
+}
 
-	// prepare inventory
-	if err = e.pepareInventory(); err != nil {
-		return err
+// discoverLockNames returns the names that the discovery of the addresses locks.
+// An address is locked by the name of the node that is known with it, if any,
+// so that the discovery conflicts with the jobs on that node. Else the address
+// itself is locked.
+func (m *Manager) discoverLockNames(addrs []string) []string {
+	nodeNames := m.sortedNodeNames()
+	names := []string{}
+	for _, addr := range addrs {
+		name := addr
+		for _, nodeName := range nodeNames {
+			if nodeAddrs(m.nodes[nodeName])[addr] {
+				name = nodeName
+				break
+			}
+		}
+		names = append(names, name)
 	}
+	return names
+}
 
-	// trigger node discovery provisioning
-	go e.mgr.runActiveJob()
+// nodeAddrs returns the addresses that a node is known with, as per it's
+// monitoring, configuration and inventory state
+func nodeAddrs(n *node) map[string]bool {
+	addrs := map[string]bool{}
+	if n.Mon != nil {
+		addrs[n.Mon.GetMgmtAddress()] = true
+	}
+	if host, ok := n.Cfg.(*configuration.AnsibleHost); ok {
+		addrs[host.GetAddr()] = true
+	}
+	if n.Inv != nil {
+		addrs[n.Inv.GetConfig().Addr] = true
+	}
+	delete(addrs, "")
+	return addrs
+}
 
-	return nil
+// discoverInventoryName returns the inventory name of the i'th node being discovered
+func discoverInventoryName(i int) string {
+	return fmt.Sprintf("node%d", i+1)
 }
 
 // pepareInventory prepares the inventory
 func (e *discoverEvent) pepareInventory() error {
 	hosts := []*configuration.AnsibleHost{}
 	for i, addr := range e.nodeAddrs {
-		invName := fmt.Sprintf("node%d", i+1)
+		invName := discoverInventoryName(i)
 		hosts = append(hosts, configuration.NewAnsibleHost(
 			invName, addr, ansibleDiscoverGroupName,
 			map[string]string{
@@ -97,7 +158,19 @@ func (e *discoverEvent) pepareInventory() error {
 // It adds the node(s) to contiv-node hostgroup
 func (e *discoverEvent) discoverRunner(cancelCh CancelChannel, jobLogs io.Writer) error {
 	outReader, cancelFunc, errCh := e.mgr.configuration.Configure(e._hosts, e.extraVars)
-	if err := logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs); err != nil {
+	err := logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs)
+	// the results are reported by inventory name, record them by address
+	invNames := []string{}
+	for i := range e.nodeAddrs {
+		invNames = append(invNames, discoverInventoryName(i))
+	}
+	invResults := stepResults(invNames, err)
+	results := map[string]configuration.HostResult{}
+	for i, addr := range e.nodeAddrs {
+		results[addr] = invResults[invNames[i]]
+	}
+	e._job.setResults(results)
+	if err != nil {
 		logrus.Errorf("discover failed. Error: %s", err)
 		return err
 	}
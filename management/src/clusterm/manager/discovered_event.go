@@ -4,7 +4,7 @@ import (
 	"fmt"
 
 	"github.com/Sirupsen/logrus"
-	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
 	"github.com/contiv/cluster/management/src/monitor"
 )
 
@@ -33,13 +33,7 @@ func (e *discoveredEvent) process() error {
 	enode, err := e.mgr.findNode(name)
 	if err != nil && err.Error() == nodeNotExistsError(name).Error() {
 		e.mgr.nodes[name] = &node{
-			// XXX: node's role/group shall come from manager's role assignment logic or
-			// from user configuration
-			Cfg: configuration.NewAnsibleHost(name, e.nodes[0].GetMgmtAddress(),
-				ansibleMasterGroupName, map[string]string{
-					ansibleNodeNameHostVar: name,
-					ansibleNodeAddrHostVar: e.nodes[0].GetMgmtAddress(),
-				}),
+			Cfg: e.mgr.newNodeConfig(name, e.nodes[0].GetMgmtAddress()),
 		}
 		enode = e.mgr.nodes[name]
 	} else if err != nil {
@@ -48,18 +42,50 @@ func (e *discoveredEvent) process() error {
 
 	// update node's monitoring info to the one received in the event
 	enode.Mon = e.nodes[0]
+	enode.Maint = e.mgr.maintenance[name]
 	enode.Inv = e.mgr.inventory.GetAsset(name)
-	if enode.Inv == nil {
+	isNew := enode.Inv == nil
+	// a commissioned node that reappears may have rebooted, so it's configuration is verified
+	reappeared := false
+	if isNew {
 		if err := e.mgr.inventory.AddAsset(name); err != nil {
 			// XXX. Log this to collins
 			logrus.Errorf("adding asset %q to discovered in inventory failed. Error: %s", name, err)
 			return err
 		}
 		enode.Inv = e.mgr.inventory.GetAsset(name)
-	} else if err := e.mgr.inventory.SetAssetDiscovered(name); err != nil {
-		// XXX. Log this to collins
-		logrus.Errorf("setting asset %q to discovered in inventory failed. Error: %s", name, err)
+	} else {
+		status, state := enode.Inv.GetStatus()
+		reappeared = status == inventory.Allocated && state != inventory.Discovered
+		if err := e.mgr.inventory.SetAssetDiscovered(name); err != nil {
+			// XXX. Log this to collins
+			logrus.Errorf("setting asset %q to discovered in inventory failed. Error: %s", name, err)
+			return err
+		}
+	}
+
+	// record node's configuration state, as it may be new or it's address may have changed
+	if err := e.mgr.saveNodeConfig(name); err != nil {
+		logrus.Errorf("setting asset %q's configuration in inventory failed. Error: %s", name, err)
 		return err
 	}
+
+	// gather node's hardware facts, as it may be new or it's hardware may have changed
+	e.mgr.gatherNodeFacts(name)
+
+	e.mgr.cancelRemediation(name)
+
+	// re-run the job that was interrupted on the node, when reconcile policy is to retry
+	e.mgr.retryInterruptedJob(name)
+
+	if reappeared {
+		e.mgr.verifyNode(name)
+	}
+
+	// commission the node, if it is newly discovered and matches the auto-commission policy
+	if isNew && e.mgr.autoCommission != nil &&
+		e.mgr.autoCommission.matches(e.nodes[0].GetLabel(), e.nodes[0].GetSerial()) {
+		e.mgr.queueAutoCommission(name)
+	}
 	return nil
 }
@@ -8,12 +8,29 @@ type event interface {
 	process() error
 }
 
+// jobEvent is an event that starts a job
+type jobEvent interface {
+	event
+	// job returns the job started by the event. It is nil till the event is processed.
+	job() *Job
+}
+
+// validatedEvent is an event that validates it's data before it's job is run.
+// The validation is also done before the job is queued, so that an invalid
+// request is rejected right away instead of failing once it is dequeued.
+type validatedEvent interface {
+	event
+	eventValidate() error
+}
+
 func (m *Manager) eventLoop() {
 	for {
 		me := <-m.reqQ
 		logrus.Debugf("dequeued manager event: %s", me)
+		m.setCurrentEvent(me)
 		err := me.process()
 		// log and continue
 		logrus.Debugf("done handling event %s. Error(if any): %v", me, err)
+		m.setCurrentEvent(nil)
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/configuration"
 	"github.com/contiv/errored"
 )
 
@@ -51,18 +52,134 @@ func logOutputAndReturnStatus(r io.Reader, errCh chan error, cancelCh CancelChan
 	}
 }
 
+// cleanupCancelChannel returns the channel to signal cancellation of the cleanup
+// that is run after a failed job step. The cleanup after a cancelled step can't
+// be cancelled, as it is what leaves the nodes in a consistent state.
+func cleanupCancelChannel(cancelCh CancelChannel, stepErr error) CancelChannel {
+	if stepErr == errJobCancelled {
+		return nil
+	}
+	return cancelCh
+}
+
+// stepResults returns the outcome of a job step on each of the nodes. When the
+// configuration subsystem doesn't report the outcome per node, like when the
+// step is cancelled, the step's status applies to all the nodes.
+func stepResults(nodeNames []string, stepErr error) map[string]configuration.HostResult {
+	if stepErr == nil {
+		return uniformResults(nodeNames, configuration.HostResultOK)
+	}
+	hostsErr, ok := stepErr.(*configuration.HostsError)
+	if !ok {
+		return uniformResults(nodeNames, configuration.HostResultFailed)
+	}
+	results := map[string]configuration.HostResult{}
+	for _, name := range nodeNames {
+		results[name] = configuration.HostResultFailed
+		if result, ok := hostsErr.Results[name]; ok {
+			results[name] = result
+		}
+	}
+	return results
+}
+
+// uniformResults returns the results with same outcome on all the nodes
+func uniformResults(nodeNames []string, result configuration.HostResult) map[string]configuration.HostResult {
+	results := map[string]configuration.HostResult{}
+	for _, name := range nodeNames {
+		results[name] = result
+	}
+	return results
+}
+
+// uniformHostGroups returns the same host-group for all the nodes, keyed by
+// node name. It returns nil if no host-group is specified.
+func uniformHostGroups(nodeNames []string, hostGroup string) map[string]string {
+	if hostGroup == "" {
+		return nil
+	}
+	hostGroups := map[string]string{}
+	for _, name := range nodeNames {
+		hostGroups[name] = hostGroup
+	}
+	return hostGroups
+}
+
+// splitNodesByResult returns the nodes where the job succeeded and the rest of
+// the nodes, as per the results. A node that is missing in the results is
+// treated as failed.
+func splitNodesByResult(nodeNames []string, results map[string]configuration.HostResult) ([]string, []string) {
+	okNodes := []string{}
+	failedNodes := []string{}
+	for _, name := range nodeNames {
+		if results[name] == configuration.HostResultOK {
+			okNodes = append(okNodes, name)
+			continue
+		}
+		failedNodes = append(failedNodes, name)
+	}
+	return okNodes, failedNodes
+}
+
+// filterHosts returns the hosts with specified names
+func filterHosts(hosts configuration.SubsysHosts, names []string) configuration.SubsysHosts {
+	filtered := []*configuration.AnsibleHost{}
+	for _, host := range hosts.([]*configuration.AnsibleHost) {
+		if containsString(names, host.GetTag()) {
+			filtered = append(filtered, host)
+		}
+	}
+	return filtered
+}
+
 // commonEventValidate does common validation for events. It returns a map of nodes
-// associted with their name on success
+// associted with their name on success. The nodes in maintenance are rejected.
 func (m *Manager) commonEventValidate(nodeNames []string) (map[string]*node, error) {
 	if len(nodeNames) == 0 {
 		return nil, errored.Errorf("atleast one node should be specified")
 	}
 
+	if err := m.checkNotInMaintenance(nodeNames); err != nil {
+		return nil, err
+	}
+
 	err := m.areDiscoveredNodes(nodeNames)
 	if err != nil {
 		return nil, err
 	}
 
+	return m.eventNodes(nodeNames)
+}
+
+// forcedEventValidate is like commonEventValidate, except that the nodes need
+// not be in discovered state. It is used by the forced operations, which are
+// meant for the nodes that are not reachable.
+func (m *Manager) forcedEventValidate(nodeNames []string) (map[string]*node, error) {
+	if len(nodeNames) == 0 {
+		return nil, errored.Errorf("atleast one node should be specified")
+	}
+
+	if err := m.checkNotInMaintenance(nodeNames); err != nil {
+		return nil, err
+	}
+
+	return m.eventNodes(nodeNames)
+}
+
+// checkNotInMaintenance makes sure that none of the nodes is in maintenance, as
+// the nodes in maintenance are not acted upon till they exit maintenance
+func (m *Manager) checkNotInMaintenance(nodeNames []string) error {
+	for _, name := range nodeNames {
+		if _, ok := m.maintenance[name]; ok {
+			return errNodeInMaintenance(name)
+		}
+	}
+	return nil
+}
+
+// eventNodes returns the nodes with the specified names, making sure that
+// their configuration exists
+func (m *Manager) eventNodes(nodeNames []string) (map[string]*node, error) {
 	enodes := map[string]*node{}
 	for _, name := range nodeNames {
 		node, err := m.findNode(name)
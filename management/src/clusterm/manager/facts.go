@@ -0,0 +1,146 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+)
+
+// gatherFacts gathers the hardware facts of the hosts and records them in the
+// inventory. The facts are best effort, a failure to gather or record them is
+// logged, to the job logs if specified, and ignored.
+func (m *Manager) gatherFacts(hosts configuration.SubsysHosts, jobLogs io.Writer) {
+	ansibleHosts := hosts.([]*configuration.AnsibleHost)
+	if m.configuration == nil || len(ansibleHosts) == 0 {
+		return
+	}
+
+	facts, err := m.configuration.GatherFacts(hosts)
+	if err != nil {
+		logrus.Errorf("failed to gather hardware facts of one or more nodes. Error: %v", err)
+		if jobLogs != nil {
+			fmt.Fprintf(jobLogs, "failed to gather hardware facts of one or more nodes. Error: %v\n", err)
+		}
+	}
+	for _, host := range ansibleHosts {
+		name := host.GetTag()
+		f, ok := facts[name]
+		if !ok {
+			m.addAssetLog(name, inventory.LogTypeWarning, "failed to gather hardware facts")
+			continue
+		}
+		if err := m.inventory.SetAssetFacts(name, f); err != nil {
+			logrus.Errorf("failed to update %s's hardware facts in inventory. Error: %v", name, err)
+		}
+	}
+}
+
+// gatherFactsEvent triggers the gathering of the hardware facts of the nodes,
+// which is done when a node is discovered as it may be new or it's hardware may
+// have changed. The facts are gathered as a job, so that the event loop isn't
+// held up while they are gathered and the nodes are not acted upon meanwhile.
+type gatherFactsEvent struct {
+	mgr       *Manager
+	nodeNames []string
+
+	_job   *Job
+	_hosts configuration.SubsysHosts
+}
+
+// newGatherFactsEvent creates and returns gatherFactsEvent
+func newGatherFactsEvent(mgr *Manager, nodeNames []string) *gatherFactsEvent {
+	return &gatherFactsEvent{
+		mgr:       mgr,
+		nodeNames: nodeNames,
+	}
+}
+
+func (e *gatherFactsEvent) String() string {
+	return fmt.Sprintf("gatherFactsEvent: nodes: %v", e.nodeNames)
+}
+
+func (e *gatherFactsEvent) job() *Job {
+	return e._job
+}
+
+func (e *gatherFactsEvent) process() error {
+	// err shouldn't be redefined below
+	var err error
+
+	// the job is created once, the event is processed again if the job is queued
+	if e._job == nil {
+		e._job = e.mgr.newJob(
+			e.String(),
+			e.gatherFactsRunner,
+			func(status JobStatus, errRet error) {
+				if status == Errored {
+					logrus.Errorf("hardware facts gathering job failed. Error: %v", errRet)
+				}
+			},
+			e.nodeNames)
+	}
+	job := e._job
+	if err = e.mgr.checkAndSetActiveJob(job, e, 0); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			e.mgr.resetActiveJob(job)
+		}
+	}()
+
+	// validate event data
+	if err = e.eventValidate(); err != nil {
+		return err
+	}
+
+	// trigger the gathering of facts
+	go e.mgr.runActiveJob(job)
+
+	return nil
+}
+
+// eventValidate makes sure that the nodes are still reachable, as the node may
+// have changed while the job was queued. The hosts are copied as they may be
+// updated by the events processed while the facts are gathered.
+func (e *gatherFactsEvent) eventValidate() error {
+	if err := e.mgr.areDiscoveredNodes(e.nodeNames); err != nil {
+		return err
+	}
+	enodes, err := e.mgr.eventNodes(e.nodeNames)
+	if err != nil {
+		return err
+	}
+
+	hosts := []*configuration.AnsibleHost{}
+	for _, name := range e.nodeNames {
+		host := enodes[name].Cfg.(*configuration.AnsibleHost)
+		vars := map[string]string{}
+		for k, v := range host.GetVars() {
+			vars[k] = v
+		}
+		hosts = append(hosts, configuration.NewAnsibleHost(name, host.GetAddr(), host.GetGroup(), vars))
+	}
+	e._hosts = hosts
+	return nil
+}
+
+// gatherFactsRunner is the job runner that gathers the hardware facts of the nodes
+func (e *gatherFactsEvent) gatherFactsRunner(cancelCh CancelChannel, jobLogs io.Writer) error {
+	e.mgr.gatherFacts(e._hosts, jobLogs)
+	return nil
+}
+
+// gatherNodeFacts triggers the gathering of the hardware facts of a discovered node
+func (m *Manager) gatherNodeFacts(name string) {
+	if m.configuration == nil {
+		return
+	}
+
+	if err := newGatherFactsEvent(m, []string{name}).process(); err != nil && err != errJobQueued {
+		logrus.Errorf("failed to gather the hardware facts of node %q. Error: %v", name, err)
+	}
+}
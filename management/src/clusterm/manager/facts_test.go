@@ -0,0 +1,83 @@
+// +build unittest
+
+package manager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type factsSuite struct {
+}
+
+var _ = Suite(&factsSuite{})
+
+func (s *factsSuite) TestCommissionGathersFacts(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr, client := newAssetLogsTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Unallocated,
+		"node2": inventory.Unallocated,
+	}, nil)
+	facts := inventory.AssetFacts{
+		CPUs:      4,
+		MemoryMB:  8192,
+		OS:        "CentOS",
+		OSVersion: "7.2.1511",
+		NICs:      []inventory.NICFacts{{Name: "eth1", MAC: "08:00:27:a8:bd:1e"}},
+	}
+	// the facts are gathered only on node1
+	mgr.configuration.(*fakeConfigSubsys).facts = map[string]inventory.AssetFacts{"node1": facts}
+
+	e := newCommissionEvent(mgr, []string{"node1", "node2"}, configuration.DefaultValidJSON, ansibleMasterGroupName, 0)
+	c.Assert(e.process(), IsNil)
+	waitForJobReset(c, mgr, e._job)
+
+	c.Assert(mgr.inventory.GetAsset("node1").GetFacts(), DeepEquals, &facts)
+	c.Assert(mgr.inventory.GetAsset("node2").GetFacts(), IsNil)
+	c.Assert(containsString(logMessages(client.logs["node2"]), "WARNING: failed to gather hardware facts"), Equals, true)
+
+	// the facts are served as part of the node's info
+	out, err := mgr.oneNode(&APIRequest{Nodes: []string{"node1"}})
+	c.Assert(err, IsNil)
+	body, err := ioutil.ReadAll(out)
+	c.Assert(err, IsNil)
+	info := struct {
+		Inv struct {
+			Facts *inventory.AssetFacts `json:"facts"`
+		} `json:"inventory_state"`
+	}{}
+	c.Assert(json.Unmarshal(body, &info), IsNil)
+	c.Assert(info.Inv.Facts, DeepEquals, &facts)
+}
+
+func (s *factsSuite) TestGatherFactsJob(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr, _ := newAssetLogsTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+	}, nil)
+	facts := inventory.AssetFacts{CPUs: 2, MemoryMB: 4096}
+	mgr.configuration.(*fakeConfigSubsys).facts = map[string]inventory.AssetFacts{"node1": facts}
+
+	// the facts are gathered as a job, which is queued while the node is in use
+	j1, err := setActiveJob(mgr, "job1", []string{"node1"})
+	c.Assert(err, IsNil)
+	e := newGatherFactsEvent(mgr, []string{"node1"})
+	c.Assert(e.process(), Equals, errJobQueued)
+	c.Assert(mgr.getQueuedJobs(), DeepEquals, []*Job{e._job})
+	c.Assert(mgr.inventory.GetAsset("node1").GetFacts(), IsNil)
+
+	mgr.resetActiveJob(j1)
+	c.Assert(newProcessJobQueueEvent(mgr).process(), IsNil)
+	waitForJobReset(c, mgr, e._job)
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(mgr.inventory.GetAsset("node1").GetFacts(), DeepEquals, &facts)
+}
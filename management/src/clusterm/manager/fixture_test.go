@@ -0,0 +1,56 @@
+// +build unittest
+
+package manager
+
+import (
+	"time"
+
+	"github.com/contiv/cluster/management/src/boltdb"
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/cluster/management/src/mock"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+// newTestInventory returns an inventory, backed by a mock client, containing
+// assets in the specified status and state
+func newTestInventory(c *C, ctrl *gomock.Controller, assets map[string]inventory.AssetStatus,
+	state inventory.AssetState) *inventory.GeneralSubsys {
+	mClient := mock.NewMockSubsysClient(ctrl)
+	mClient.EXPECT().SetAssetStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	mClient.EXPECT().SetAssetConfig(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	mClient.EXPECT().DeleteAsset(gomock.Any()).AnyTimes()
+	mClient.EXPECT().AddAssetLog(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	mClient.EXPECT().SetAssetAttributes(gomock.Any(), gomock.Any()).AnyTimes()
+	inv := inventory.NewGeneralSubsys(mClient)
+	for name, status := range assets {
+		c.Assert(inv.RestoreAsset(name, inventory.NewAssetWithState(mClient, name, status, state)), IsNil)
+	}
+	return inv
+}
+
+// newTestManager returns a manager with a node for each of the assets, in the
+// specified status and state. The nodes are in master host-group and the
+// configuration actions succeed.
+func newTestManager(c *C, ctrl *gomock.Controller, assets map[string]inventory.AssetStatus,
+	state inventory.AssetState) *Manager {
+	mgr := &Manager{
+		reqQ:            make(chan event, 10),
+		inventory:       newTestInventory(c, ctrl, assets, state),
+		configuration:   &fakeConfigSubsys{},
+		config:          DefaultConfig(),
+		nodes:           map[string]*node{},
+		interrupted:     map[string]inventory.AssetStatus{},
+		interruptedJobs: map[string]boltdb.Job{},
+		maintenance:     map[string]*MaintenanceInfo{},
+		disappeared:     map[string]time.Time{},
+	}
+	for name := range assets {
+		mgr.nodes[name] = &node{
+			Inv: mgr.inventory.GetAsset(name),
+			Cfg: configuration.NewAnsibleHost(name, "", ansibleMasterGroupName, map[string]string{}),
+		}
+	}
+	return mgr
+}
@@ -0,0 +1,286 @@
+package manager
+
+import (
+	"sort"
+
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+)
+
+// hostGroupConfig is the definition of a host-group i.e. an ansible group that
+// the nodes are commissioned in, like service-master or a group of storage nodes
+type hostGroupConfig struct {
+	// Min is the number of commissioned nodes that the host-group shall be left
+	// with when it's nodes are decommissioned or moved to other host-groups,
+	// unless all the nodes of the cluster are decommissioned. 0 means no minimum.
+	Min int `json:"min,omitempty"`
+	// Max is the maximum number of commissioned nodes in the host-group. 0 means
+	// no maximum.
+	Max int `json:"max,omitempty"`
+	// Requires are the host-groups that shall have atleast one commissioned
+	// node for the nodes to be commissioned in this host-group, like workers
+	// require a master. The last node of a required host-group can't be
+	// decommissioned while this host-group has commissioned nodes.
+	Requires []string `json:"requires,omitempty"`
+}
+
+// defaultHostGroups returns the host-groups that are defined by default
+func defaultHostGroups() map[string]hostGroupConfig {
+	return map[string]hostGroupConfig{
+		ansibleMasterGroupName: {},
+		ansibleWorkerGroupName: {
+			Requires: []string{ansibleMasterGroupName},
+		},
+	}
+}
+
+func errInvalidHostGroupConfig(hostGroup, field string, val interface{}) error {
+	return errored.Errorf("invalid %s specified for host-group %q: %v", field, hostGroup, val)
+}
+
+// validateHostGroups validates the host-group definitions. The host-groups
+// required by a host-group shall be defined and shall not require it in turn,
+// as neither host-group's nodes could be commissioned first otherwise.
+func validateHostGroups(groups map[string]hostGroupConfig) error {
+	if len(groups) == 0 {
+		return errored.Errorf("atleast one host-group should be defined")
+	}
+	for name, group := range groups {
+		if name == "" || name == ansibleDiscoverGroupName {
+			return errored.Errorf("invalid host-group name specified: %q", name)
+		}
+		if group.Min < 0 {
+			return errInvalidHostGroupConfig(name, "min", group.Min)
+		}
+		if group.Max < 0 || (group.Max > 0 && group.Max < group.Min) {
+			return errInvalidHostGroupConfig(name, "max", group.Max)
+		}
+		for _, required := range group.Requires {
+			if _, ok := groups[required]; !ok {
+				return errInvalidHostGroupConfig(name, "required host-group", required)
+			}
+			if requiresHostGroup(groups, required, name, map[string]bool{}) {
+				return errored.Errorf("host-groups %q and %q require each other", name, required)
+			}
+		}
+	}
+	return nil
+}
+
+// requiresHostGroup returns true if the host-group requires the other
+// host-group directly or through the host-groups that it requires
+func requiresHostGroup(groups map[string]hostGroupConfig, name, other string, visited map[string]bool) bool {
+	if visited[name] {
+		return false
+	}
+	visited[name] = true
+	for _, required := range groups[name].Requires {
+		if required == other || requiresHostGroup(groups, required, other, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostGroups returns the host-groups defined in the configuration
+func (m *Manager) hostGroups() map[string]hostGroupConfig {
+	if m.config == nil || len(m.config.Manager.HostGroups) == 0 {
+		return defaultHostGroups()
+	}
+	return m.config.Manager.HostGroups
+}
+
+// isValidHostGroup checks if the passed hostGroup is defined
+func (m *Manager) isValidHostGroup(hostGroup string) bool {
+	_, ok := m.hostGroups()[hostGroup]
+	return ok
+}
+
+// hostGroupNames returns the sorted names of the defined host-groups
+func hostGroupNames(groups map[string]hostGroupConfig) []string {
+	names := []string{}
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// commissionedNodesByGroup returns the number of commissioned and reachable
+// nodes in each host-group, excluding the specified nodes. As the jobs run
+// concurrently, the nodes that an active job is commissioning or updating are
+// counted as well, in the host-group that the job moves them to, so that the
+// host-groups stay as per their definition whichever way the job ends.
+func (m *Manager) commissionedNodesByGroup(exclude map[string]*node) map[string]int {
+	counts := map[string]int{}
+	for name, node := range m.nodes {
+		if _, ok := exclude[name]; ok {
+			continue
+		}
+		if node.Cfg == nil {
+			continue
+		}
+		if !m.isCommissionedOrInFlight(name, node) {
+			// skip hosts that are not yet provisioned or not in discovered state
+			continue
+		}
+		counts[node.Cfg.GetGroup()]++
+	}
+	return counts
+}
+
+// isCommissionedOrInFlight returns true if the node is commissioned and
+// reachable, or if it is being provisioned or updated by an active job. The
+// host-group of such a node has already been set to the one the job moves it to.
+func (m *Manager) isCommissionedOrInFlight(name string, node *node) bool {
+	if isDiscoveredAndAllocated, err := m.isDiscoveredAndAllocatedNode(name); err == nil && isDiscoveredAndAllocated {
+		return true
+	}
+	if node.Inv == nil || m.locks.owner(name) == nil {
+		return false
+	}
+	switch status, _ := node.Inv.GetStatus(); status {
+	case inventory.Provisioning, inventory.Provisioned, inventory.Maintenance:
+		return true
+	}
+	return false
+}
+
+// checkHostGroups validates, as per the host-group definitions, the change in
+// host-groups of the commissioned nodes when the nodes of an event are moved to
+// the specified host-group by a commission or update. An empty host-group means
+// that the nodes are decommissioned. The action is used in the error messages.
+func (m *Manager) checkHostGroups(enodes map[string]*node, hostGroup, action string) error {
+	targets := map[string]string{}
+	for name := range enodes {
+		targets[name] = hostGroup
+	}
+	return m.checkNodeHostGroups(enodes, targets, action)
+}
+
+// checkNodeHostGroups is like checkHostGroups, except that the nodes of the
+// event are moved to their own host-group as per targets, which is keyed by
+// node name. The host-groups required by a target host-group may be satisfied
+// by the nodes of the event itself, as such nodes are commissioned first.
+func (m *Manager) checkNodeHostGroups(enodes map[string]*node, targets map[string]string, action string) error {
+	groups := m.hostGroups()
+	after := m.commissionedNodesByGroup(enodes)
+	total := 0
+	for _, count := range after {
+		total += count
+	}
+	for _, name := range sortedKeys(targets) {
+		if hostGroup := targets[name]; hostGroup != "" {
+			after[hostGroup]++
+			total++
+		}
+	}
+
+	for _, hostGroup := range targetHostGroups(targets) {
+		group := groups[hostGroup]
+		for _, required := range group.Requires {
+			if after[required] == 0 {
+				return errored.Errorf("Cannot %s the node(s) in host-group %q without existence of a node in host-group %q in the cluster, make sure atleast one node is commissioned in host-group %q.",
+					action, hostGroup, required, required)
+			}
+		}
+		if group.Max > 0 && after[hostGroup] > group.Max {
+			return errored.Errorf("Cannot %s the node(s) in host-group %q as it will have %d nodes, more than it's maximum of %d nodes.",
+				action, hostGroup, after[hostGroup], group.Max)
+		}
+	}
+
+	// the host-groups that the commissioned nodes of the event are moved out of
+	leaving := map[string]bool{}
+	for name, node := range enodes {
+		if isDiscoveredAndAllocated, err := m.isDiscoveredAndAllocatedNode(name); err != nil || !isDiscoveredAndAllocated {
+			continue
+		}
+		if group := node.Cfg.GetGroup(); group != targets[name] {
+			leaving[group] = true
+		}
+	}
+	for _, left := range hostGroupNames(groups) {
+		if !leaving[left] {
+			continue
+		}
+		if min := groups[left].Min; min > 0 && total > 0 && after[left] < min {
+			return errored.Errorf("Cannot %s the node(s) as it will leave host-group %q with %d nodes, less than it's minimum of %d nodes.",
+				action, left, after[left], min)
+		}
+		if after[left] > 0 {
+			continue
+		}
+		for _, name := range hostGroupNames(groups) {
+			if after[name] > 0 && containsString(groups[name].Requires, left) {
+				return errored.Errorf("Cannot %s the node(s) as it will leave nodes in host-group %q without a node in host-group %q, make sure all nodes in host-group %q are decommissioned before the last node in host-group %q.",
+					action, name, left, name, left)
+			}
+		}
+	}
+	return nil
+}
+
+// targetHostGroups returns the sorted, distinct and non-empty host-groups of targets
+func targetHostGroups(targets map[string]string) []string {
+	seen := map[string]bool{}
+	hostGroups := []string{}
+	for _, hostGroup := range targets {
+		if hostGroup == "" || seen[hostGroup] {
+			continue
+		}
+		seen[hostGroup] = true
+		hostGroups = append(hostGroups, hostGroup)
+	}
+	sort.Strings(hostGroups)
+	return hostGroups
+}
+
+// sortedKeys returns the sorted keys of a map of strings
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// hostGroupDepth returns the length of the longest chain of host-groups that a
+// host-group requires. The host-groups that don't require any have depth 0.
+func hostGroupDepth(groups map[string]hostGroupConfig, name string, depths map[string]int) int {
+	if depth, ok := depths[name]; ok {
+		return depth
+	}
+	depth := 0
+	for _, required := range groups[name].Requires {
+		if d := hostGroupDepth(groups, required, depths) + 1; d > depth {
+			depth = d
+		}
+	}
+	depths[name] = depth
+	return depth
+}
+
+// commissionPhases splits the nodes, as per their host-group in targets, in
+// the phases that they shall be commissioned in. The nodes of a host-group are
+// commissioned in a phase after the nodes of the host-groups that it requires.
+func commissionPhases(groups map[string]hostGroupConfig, targets map[string]string) [][]string {
+	depths := map[string]int{}
+	byDepth := map[int][]string{}
+	maxDepth := 0
+	for _, name := range sortedKeys(targets) {
+		depth := hostGroupDepth(groups, targets[name], depths)
+		byDepth[depth] = append(byDepth[depth], name)
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+	}
+	phases := [][]string{}
+	for depth := 0; depth <= maxDepth; depth++ {
+		if len(byDepth[depth]) > 0 {
+			phases = append(phases, byDepth[depth])
+		}
+	}
+	return phases
+}
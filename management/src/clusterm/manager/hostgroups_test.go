@@ -0,0 +1,358 @@
+// +build unittest
+
+package manager
+
+import (
+	"io"
+	"sort"
+
+	"golang.org/x/net/context"
+
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type hostGroupsSuite struct {
+}
+
+var _ = Suite(&hostGroupsSuite{})
+
+const storageGroupName = "storage"
+
+func testHostGroups() map[string]hostGroupConfig {
+	groups := defaultHostGroups()
+	groups[storageGroupName] = hostGroupConfig{
+		Min:      2,
+		Max:      3,
+		Requires: []string{ansibleMasterGroupName},
+	}
+	return groups
+}
+
+func (s *hostGroupsSuite) TestValidateHostGroups(c *C) {
+	c.Assert(validateHostGroups(defaultHostGroups()), IsNil)
+	c.Assert(validateHostGroups(testHostGroups()), IsNil)
+
+	tests := map[string]map[string]hostGroupConfig{
+		"no-groups":     {},
+		"empty-name":    {"": {}},
+		"reserved-name": {ansibleDiscoverGroupName: {}},
+		"negative-min":  {"foo": {Min: -1}},
+		"max-below-min": {"foo": {Min: 2, Max: 1}},
+		"undefined-requires": {
+			"foo": {Requires: []string{"bar"}},
+		},
+		"self-requires": {
+			"foo": {Requires: []string{"foo"}},
+		},
+		"cyclic-requires": {
+			"foo": {Requires: []string{"bar"}},
+			"bar": {Requires: []string{"baz"}},
+			"baz": {Requires: []string{"foo"}},
+		},
+	}
+	for testname, groups := range tests {
+		c.Assert(validateHostGroups(groups), NotNil, Commentf("test: %s", testname))
+	}
+}
+
+// newHostGroupsTestManager returns a manager with a commissioned master, worker
+// and two storage nodes, along with an uncommissioned node
+func (s *hostGroupsSuite) TestIsValidHostGroup(c *C) {
+	c.Assert(IsValidHostGroup(ansibleMasterGroupName), Equals, true)
+	c.Assert(IsValidHostGroup(ansibleWorkerGroupName), Equals, true)
+	c.Assert(IsValidHostGroup("foo"), Equals, false)
+}
+
+func newHostGroupsTestManager(c *C, ctrl *gomock.Controller) *Manager {
+	groups := map[string]string{
+		"master1":  ansibleMasterGroupName,
+		"worker1":  ansibleWorkerGroupName,
+		"storage1": storageGroupName,
+		"storage2": storageGroupName,
+	}
+	assets := map[string]inventory.AssetStatus{"spare1": inventory.Unallocated}
+	for name := range groups {
+		assets[name] = inventory.Allocated
+	}
+	mgr := newVerifyTestManager(c, ctrl, assets, nil, nil)
+	mgr.config.Manager.HostGroups = testHostGroups()
+	for name, group := range groups {
+		mgr.nodes[name].Cfg.(*configuration.AnsibleHost).SetGroup(group)
+	}
+	return mgr
+}
+
+func (s *hostGroupsSuite) TestCheckHostGroups(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newHostGroupsTestManager(c, ctrl)
+	tests := map[string]struct {
+		nodes     []string
+		hostGroup string
+		exptdErr  string
+	}{
+		"commission-storage": {
+			nodes:     []string{"spare1"},
+			hostGroup: storageGroupName,
+		},
+		"decommission-worker": {
+			nodes: []string{"worker1"},
+		},
+		"decommission-all": {
+			nodes: []string{"master1", "worker1", "storage1", "storage2"},
+		},
+		"update-worker-to-storage": {
+			nodes:     []string{"worker1"},
+			hostGroup: storageGroupName,
+		},
+		"exceed-max": {
+			nodes:     []string{"spare1", "worker1"},
+			hostGroup: storageGroupName,
+			exptdErr:  ".*host-group \"storage\" as it will have 4 nodes, more than it's maximum of 3 nodes.*",
+		},
+		"below-min": {
+			nodes:    []string{"storage1"},
+			exptdErr: ".*leave host-group \"storage\" with 1 nodes, less than it's minimum of 2 nodes.*",
+		},
+		"last-required-node": {
+			nodes:    []string{"master1"},
+			exptdErr: ".*leave nodes in host-group \"service-worker\" without a node in host-group \"service-master\".*",
+		},
+		"update-last-required-node": {
+			nodes:     []string{"master1"},
+			hostGroup: ansibleWorkerGroupName,
+			exptdErr:  ".*in host-group \"service-worker\" without existence of a node in host-group \"service-master\".*",
+		},
+	}
+	for testname, test := range tests {
+		enodes, err := mgr.eventNodes(test.nodes)
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+		err = mgr.checkHostGroups(enodes, test.hostGroup, "test")
+		if test.exptdErr == "" {
+			c.Assert(err, IsNil, Commentf("test: %s", testname))
+			continue
+		}
+		c.Assert(err, ErrorMatches, test.exptdErr, Commentf("test: %s", testname))
+	}
+}
+
+func (s *hostGroupsSuite) TestCheckHostGroupsInFlightJob(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	// spare1 is being commissioned in storage host-group by an active job
+	mgr := newHostGroupsTestManager(c, ctrl)
+	c.Assert(mgr.inventory.SetAssetProvisioning("spare1"), IsNil)
+	mgr.nodes["spare1"].Cfg.(*configuration.AnsibleHost).SetGroup(storageGroupName)
+	enodes, err := mgr.eventNodes([]string{"worker1"})
+	c.Assert(err, IsNil)
+	// a node left in provisioning status without a job is not counted
+	c.Assert(mgr.checkHostGroups(enodes, storageGroupName, "test"), IsNil)
+
+	_, err = setActiveJob(mgr, "commission", []string{"spare1"})
+	c.Assert(err, IsNil)
+	c.Assert(mgr.checkHostGroups(enodes, storageGroupName, "test"), ErrorMatches,
+		".*host-group \"storage\" as it will have 4 nodes, more than it's maximum of 3 nodes.*")
+
+	// the last master can't be decommissioned while a worker is being commissioned
+	mgr.nodes["spare1"].Cfg.(*configuration.AnsibleHost).SetGroup(ansibleWorkerGroupName)
+	enodes, err = mgr.eventNodes([]string{"master1", "worker1", "storage1", "storage2"})
+	c.Assert(err, IsNil)
+	c.Assert(mgr.checkHostGroups(enodes, "", "test"), ErrorMatches,
+		".*leave nodes in host-group \"service-worker\" without a node in host-group \"service-master\".*")
+}
+
+func (s *hostGroupsSuite) TestCommissionUndefinedHostGroup(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newHostGroupsTestManager(c, ctrl)
+	e := newCommissionEvent(mgr, []string{"spare1"}, configuration.DefaultValidJSON, "edge", 0)
+	c.Assert(e.process(), ErrorMatches, ".*invalid or empty host-group specified: \"edge\".*")
+
+	e = newCommissionEvent(mgr, []string{"spare1"}, configuration.DefaultValidJSON, storageGroupName, 0)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(assetStatus(mgr, "spare1"), Equals, inventory.Allocated)
+}
+
+func (s *hostGroupsSuite) TestCommissionPhases(c *C) {
+	groups := testHostGroups()
+	groups["backup"] = hostGroupConfig{Requires: []string{storageGroupName}}
+	phases := commissionPhases(groups, map[string]string{
+		"node1": ansibleWorkerGroupName,
+		"node2": ansibleMasterGroupName,
+		"node3": "backup",
+		"node4": storageGroupName,
+		"node5": ansibleMasterGroupName,
+	})
+	c.Assert(phases, DeepEquals, [][]string{{"node2", "node5"}, {"node1", "node4"}, {"node3"}})
+
+	phases = commissionPhases(groups, map[string]string{"node1": ansibleWorkerGroupName})
+	c.Assert(phases, DeepEquals, [][]string{{"node1"}})
+}
+
+// phaseConfigSubsys is a configuration subsystem that records the nodes of each
+// configuration and fails the configuration of the specified nodes. The job is
+// cancelled during the cancelPhase'th configuration, if set.
+type phaseConfigSubsys struct {
+	fakeConfigSubsys
+	failNodes   []string
+	cancelPhase int
+	configured  [][]string
+	cleanedUp   [][]string
+}
+
+func hostNames(nodes configuration.SubsysHosts) []string {
+	names := []string{}
+	for _, host := range nodes.([]*configuration.AnsibleHost) {
+		names = append(names, host.GetTag())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (f *phaseConfigSubsys) Configure(nodes configuration.SubsysHosts, extraVars string) (io.Reader, context.CancelFunc, chan error) {
+	names := hostNames(nodes)
+	f.configured = append(f.configured, names)
+	if len(f.configured) == f.cancelPhase {
+		return fakeRun(errJobCancelled)
+	}
+	hostsErr := &configuration.HostsError{
+		Err:     errored.Errorf("test failure"),
+		Results: uniformResults(names, configuration.HostResultOK),
+	}
+	for _, name := range names {
+		if containsString(f.failNodes, name) {
+			hostsErr.Results[name] = configuration.HostResultFailed
+		}
+	}
+	if okNodes, _ := splitNodesByResult(names, hostsErr.Results); len(okNodes) == len(names) {
+		return fakeRun(nil)
+	}
+	return fakeRun(hostsErr)
+}
+
+func (f *phaseConfigSubsys) Cleanup(nodes configuration.SubsysHosts, extraVars string) (io.Reader, context.CancelFunc, chan error) {
+	f.cleanedUp = append(f.cleanedUp, hostNames(nodes))
+	return fakeRun(nil)
+}
+
+func newCommissionByHostGroupTestManager(c *C, ctrl *gomock.Controller, failNodes []string) (*Manager, *phaseConfigSubsys) {
+	mgr := newVerifyTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Unallocated,
+		"node2": inventory.Unallocated,
+		"node3": inventory.Unallocated,
+	}, nil, nil)
+	f := &phaseConfigSubsys{failNodes: failNodes}
+	mgr.configuration = f
+	return mgr, f
+}
+
+func (s *hostGroupsSuite) TestCommissionByHostGroup(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr, f := newCommissionByHostGroupTestManager(c, ctrl, nil)
+	e := newCommissionEventByHostGroup(mgr, map[string]string{
+		"node1": ansibleWorkerGroupName,
+		"node2": ansibleMasterGroupName,
+		"node3": ansibleWorkerGroupName,
+	}, configuration.DefaultValidJSON, 0)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(f.configured, DeepEquals, [][]string{{"node2"}, {"node1", "node3"}})
+	c.Assert(f.cleanedUp, IsNil)
+	for _, name := range []string{"node1", "node2", "node3"} {
+		c.Assert(assetStatus(mgr, name), Equals, inventory.Allocated)
+	}
+	c.Assert(mgr.nodes["node1"].Cfg.GetGroup(), Equals, ansibleWorkerGroupName)
+	c.Assert(mgr.nodes["node2"].Cfg.GetGroup(), Equals, ansibleMasterGroupName)
+}
+
+func (s *hostGroupsSuite) TestCommissionByHostGroupPhaseFailure(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	// the workers are not configured when the master fails
+	mgr, f := newCommissionByHostGroupTestManager(c, ctrl, []string{"node2"})
+	e := newCommissionEventByHostGroup(mgr, map[string]string{
+		"node1": ansibleWorkerGroupName,
+		"node2": ansibleMasterGroupName,
+		"node3": ansibleWorkerGroupName,
+	}, configuration.DefaultValidJSON, 0)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Errored.String())
+	c.Assert(f.configured, DeepEquals, [][]string{{"node2"}})
+	c.Assert(f.cleanedUp, DeepEquals, [][]string{{"node2"}})
+	for _, name := range []string{"node1", "node2", "node3"} {
+		c.Assert(assetStatus(mgr, name), Equals, inventory.Unallocated)
+		c.Assert(e._job.Results()[name], Equals, configuration.HostResultFailed)
+	}
+
+	// the masters that were commissioned stay commissioned when a worker fails
+	mgr, f = newCommissionByHostGroupTestManager(c, ctrl, []string{"node3"})
+	e = newCommissionEventByHostGroup(mgr, map[string]string{
+		"node1": ansibleWorkerGroupName,
+		"node2": ansibleMasterGroupName,
+		"node3": ansibleWorkerGroupName,
+	}, configuration.DefaultValidJSON, 0)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Errored.String())
+	c.Assert(f.cleanedUp, DeepEquals, [][]string{{"node3"}})
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated)
+	c.Assert(assetStatus(mgr, "node2"), Equals, inventory.Allocated)
+	c.Assert(assetStatus(mgr, "node3"), Equals, inventory.Unallocated)
+}
+
+func (s *hostGroupsSuite) TestCommissionByHostGroupCancelled(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	// all the attempted nodes are cleaned up, including the masters that were
+	// configured in the first phase, when the job is cancelled
+	mgr, f := newCommissionByHostGroupTestManager(c, ctrl, nil)
+	f.cancelPhase = 2
+	e := newCommissionEventByHostGroup(mgr, map[string]string{
+		"node1": ansibleWorkerGroupName,
+		"node2": ansibleMasterGroupName,
+		"node3": ansibleWorkerGroupName,
+	}, configuration.DefaultValidJSON, 0)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Cancelled.String())
+	c.Assert(e._job.Info().ErrVal, Equals, errJobCancelled.Error())
+	c.Assert(f.configured, DeepEquals, [][]string{{"node2"}, {"node1", "node3"}})
+	c.Assert(f.cleanedUp, DeepEquals, [][]string{{"node1", "node2", "node3"}})
+	for _, name := range []string{"node1", "node2", "node3"} {
+		c.Assert(assetStatus(mgr, name), Equals, inventory.Unallocated)
+		c.Assert(e._job.Results()[name], Equals, configuration.HostResultFailed)
+	}
+}
+
+func (s *hostGroupsSuite) TestCommissionByHostGroupValidation(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr, _ := newCommissionByHostGroupTestManager(c, ctrl, nil)
+	e := newCommissionEventByHostGroup(mgr, map[string]string{
+		"node1": ansibleWorkerGroupName,
+		"node2": ansibleWorkerGroupName,
+	}, configuration.DefaultValidJSON, 0)
+	c.Assert(e.process(), ErrorMatches, ".*without existence of a node in host-group \"service-master\".*")
+
+	e = newCommissionEventByHostGroup(mgr, map[string]string{
+		"node1": ansibleWorkerGroupName,
+		"node2": "edge",
+	}, configuration.DefaultValidJSON, 0)
+	c.Assert(e.process(), ErrorMatches, ".*invalid or empty host-group specified: \"edge\".*")
+}
@@ -0,0 +1,359 @@
+package manager
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/boltdb"
+	"github.com/contiv/errored"
+)
+
+// errJobInterrupted is the error recorded in job history for the jobs that
+// were not done when clusterm stopped
+var errJobInterrupted = errored.Errorf("job was interrupted as clusterm stopped before it was done")
+
+func errJobIDNotExist(id uint64) error {
+	return errored.Errorf("info for job with id %d doesn't exist", id)
+}
+
+func errInvalidJobStatus(status string) error {
+	return errored.Errorf("Invalid job status specified: %q", status)
+}
+
+// jobsFilter specifies the criteria to select jobs from job history
+type jobsFilter struct {
+	status string
+	node   string
+	offset int
+	limit  int
+}
+
+// JobsInfo is a page of jobs from the job history as returned by the REST interface
+type JobsInfo struct {
+	Total int        `json:"total"`
+	Jobs  []*JobInfo `json:"jobs"`
+}
+
+// newJob() is a helper to create a new job on the specified nodes with an unique id
+func (m *Manager) newJob(desc string, runner JobRunner, doneCb DoneCallback, nodeNames []string) *Job {
+	m.jobsMutex.Lock()
+	m.lastJobID++
+	id := m.lastJobID
+	m.jobsMutex.Unlock()
+
+	j := NewJob(desc, runner, doneCb)
+	j.id = id
+	j.nodes = nodeNames
+	j.actor = m.currentActor()
+	return j
+}
+
+func jobToHistory(j *Job) boltdb.Job {
+	info := j.Info()
+	hj := boltdb.Job{
+		ID:      info.ID,
+		Desc:    info.Desc,
+		Task:    info.Task,
+		Nodes:   info.Nodes,
+		Status:  info.Status,
+		Error:   info.ErrVal,
+		Results: info.Results,
+	}
+	if info.StartTime != nil {
+		hj.StartTime = *info.StartTime
+	}
+	if info.EndTime != nil {
+		hj.EndTime = *info.EndTime
+	}
+	j.Lock()
+	hj.Kind, hj.ExtraVars, hj.HostGroups = j.kind, j.extraVars, j.hostGroups
+	j.Unlock()
+	return hj
+}
+
+func historyToJobInfo(hj boltdb.Job) *JobInfo {
+	info := &JobInfo{
+		ID:      hj.ID,
+		Desc:    hj.Desc,
+		Task:    hj.Task,
+		Nodes:   hj.Nodes,
+		Status:  hj.Status,
+		ErrVal:  hj.Error,
+		Results: hj.Results,
+	}
+	if !hj.StartTime.IsZero() {
+		startTime := hj.StartTime
+		info.StartTime = &startTime
+	}
+	if !hj.EndTime.IsZero() {
+		endTime := hj.EndTime
+		info.EndTime = &endTime
+	}
+	return info
+}
+
+// saveJob() records the job's current info and logs in the job history.
+// Failures are logged and ignored as job history is not critical to job's function
+func (m *Manager) saveJob(j *Job) {
+	if m.db == nil {
+		return
+	}
+
+	if err := m.db.SetJob(jobToHistory(j)); err != nil {
+		logrus.Errorf("failed to save job %d in job history. Error: %v", j.id, err)
+		return
+	}
+	if err := m.db.SetJobLogs(j.id, j.logs.Bytes()); err != nil {
+		logrus.Errorf("failed to save logs of job %d in job history. Error: %v", j.id, err)
+	}
+	if status, _ := j.Status(); isJobDone(status) {
+		m.pruneJobHistory()
+	}
+}
+
+// isJobDone returns true if the job with the specified status won't run anymore
+func isJobDone(status JobStatus) bool {
+	return status == Complete || status == Errored || status == Cancelled
+}
+
+// isHistoryJobDone returns true if the job in the job history won't run anymore
+func isHistoryJobDone(hj boltdb.Job) bool {
+	return hj.Status == Complete.String() || hj.Status == Errored.String() || hj.Status == Cancelled.String()
+}
+
+// validateJobHistoryConfig validates the retention of the job history
+func validateJobHistoryConfig(config jobHistoryConfig) error {
+	if config.MaxJobs < 0 {
+		return errored.Errorf("invalid max jobs specified for the job history: %d", config.MaxJobs)
+	}
+	if config.MaxAge == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(config.MaxAge); err != nil || d < 0 {
+		return errored.Errorf("invalid max age specified for the job history: %q", config.MaxAge)
+	}
+	return nil
+}
+
+// pruneJobHistory() deletes the jobs, along with their logs, that are past the
+// retention of the job history i.e. the jobs that are older than the max age,
+// and the oldest jobs when there are more than the max jobs. The jobs that are
+// not done are always kept. Failures are logged and ignored.
+func (m *Manager) pruneJobHistory() {
+	if m.db == nil || m.config == nil {
+		return
+	}
+	config := m.config.Manager.JobHistory
+	var maxAge time.Duration
+	if config.MaxAge != "" {
+		// the max age is validated on startup
+		maxAge, _ = time.ParseDuration(config.MaxAge)
+	}
+	if config.MaxJobs == 0 && maxAge == 0 {
+		return
+	}
+
+	jobs, err := m.db.GetAllJobs()
+	if err != nil {
+		logrus.Errorf("failed to read job history. Error: %v", err)
+		return
+	}
+	done := []boltdb.Job{}
+	for _, hj := range jobs {
+		if isHistoryJobDone(hj) {
+			done = append(done, hj)
+		}
+	}
+	excess := len(jobs) - config.MaxJobs
+	if config.MaxJobs == 0 {
+		excess = 0
+	}
+	// the jobs are ordered by their ids, so the oldest jobs are deleted first
+	for i, hj := range done {
+		if i >= excess && (maxAge == 0 || time.Since(hj.EndTime) <= maxAge) {
+			continue
+		}
+		if err := m.db.DeleteJob(hj.ID); err != nil {
+			logrus.Errorf("failed to delete job %d from job history. Error: %v", hj.ID, err)
+		}
+	}
+}
+
+// restoreJobHistory() restores the job id counter from the job history. The
+// jobs that were not done when clusterm stopped are marked as errored and are
+// kept by their nodes, to re-run them as per the reconcile policy.
+func (m *Manager) restoreJobHistory() error {
+	if m.db == nil {
+		return nil
+	}
+
+	jobs, err := m.db.GetAllJobs()
+	if err != nil {
+		return err
+	}
+
+	for _, hj := range jobs {
+		if hj.ID > m.lastJobID {
+			m.lastJobID = hj.ID
+		}
+		if isHistoryJobDone(hj) {
+			continue
+		}
+		// the jobs are iterated in the order of their IDs, so a node ends up
+		// with the last job that was interrupted on it
+		for _, name := range hj.Nodes {
+			m.interruptedJobs[name] = hj
+		}
+		hj.Status = Errored.String()
+		hj.Error = errJobInterrupted.Error()
+		// the time the job was interrupted is not known, so it's end time is
+		// when it is found to be interrupted
+		hj.EndTime = time.Now()
+		if err := m.db.SetJob(hj); err != nil {
+			logrus.Errorf("failed to update interrupted job %d in job history. Error: %v", hj.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// getJobInfo() returns the info of the job with specified id. The info is looked
+// up in the active, queued and last jobs first and then in the job history.
+func (m *Manager) getJobInfo(id uint64) (*JobInfo, error) {
+	jobs := append(m.getActiveJobs(), m.getQueuedJobs()...)
+	if j := m.getLastJob(); j != nil {
+		jobs = append(jobs, j)
+	}
+	for _, j := range jobs {
+		if j.id == id {
+			return j.Info(), nil
+		}
+	}
+
+	if m.db == nil {
+		return nil, errJobIDNotExist(id)
+	}
+
+	hj, err := m.db.GetJob(id)
+	if err != nil {
+		return nil, errJobIDNotExist(id)
+	}
+	logs, err := m.db.GetJobLogs(id)
+	if err != nil {
+		return nil, err
+	}
+	info := historyToJobInfo(hj)
+	info.Logs = strings.Split(string(logs), "\n")
+	return info, nil
+}
+
+// getLastJobFromHistory() returns the info of the most recent job in the job
+// history, that is not active or queued. It returns nil if there is no such job.
+func (m *Manager) getLastJobFromHistory() *JobInfo {
+	if m.db == nil {
+		return nil
+	}
+
+	jobs, err := m.db.GetAllJobs()
+	if err != nil {
+		logrus.Errorf("failed to read job history. Error: %v", err)
+		return nil
+	}
+
+	active := map[uint64]struct{}{}
+	for _, j := range append(m.getActiveJobs(), m.getQueuedJobs()...) {
+		active[j.id] = struct{}{}
+	}
+	for i := len(jobs) - 1; i >= 0; i-- {
+		if _, ok := active[jobs[i].ID]; ok {
+			continue
+		}
+		info, err := m.getJobInfo(jobs[i].ID)
+		if err != nil {
+			logrus.Errorf("failed to read job %d from job history. Error: %v", jobs[i].ID, err)
+			return nil
+		}
+		return info
+	}
+	return nil
+}
+
+func isValidJobStatus(status string) bool {
+	for s := Queued; s <= Cancelled; s++ {
+		if s.String() == status {
+			return true
+		}
+	}
+	return false
+}
+
+// getJobsFromHistory() returns a page of jobs from job history that match the
+// filter. The jobs are ordered with most recent job first and the info of the
+// active and queued jobs reflects their current status. The job logs are not included.
+func (m *Manager) getJobsFromHistory(filter jobsFilter) (*JobsInfo, error) {
+	if filter.status != "" && !isValidJobStatus(filter.status) {
+		return nil, errInvalidJobStatus(filter.status)
+	}
+
+	infos := map[uint64]*JobInfo{}
+	if m.db != nil {
+		jobs, err := m.db.GetAllJobs()
+		if err != nil {
+			return nil, err
+		}
+		for _, hj := range jobs {
+			infos[hj.ID] = historyToJobInfo(hj)
+		}
+	}
+	for _, j := range append(m.getActiveJobs(), m.getQueuedJobs()...) {
+		info := j.Info()
+		info.Logs = nil
+		infos[j.id] = info
+	}
+
+	ids := []uint64{}
+	for id, info := range infos {
+		if filter.status != "" && info.Status != filter.status {
+			continue
+		}
+		if filter.node != "" && !containsString(info.Nodes, filter.node) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Sort(sort.Reverse(uint64s(ids)))
+
+	page := &JobsInfo{
+		Total: len(ids),
+		Jobs:  []*JobInfo{},
+	}
+	if filter.offset < len(ids) {
+		ids = ids[filter.offset:]
+		if filter.limit > 0 && filter.limit < len(ids) {
+			ids = ids[:filter.limit]
+		}
+		for _, id := range ids {
+			page.Jobs = append(page.Jobs, infos[id])
+		}
+	}
+
+	return page, nil
+}
+
+// uint64s attaches the methods of sort.Interface to []uint64
+type uint64s []uint64
+
+func (u uint64s) Len() int           { return len(u) }
+func (u uint64s) Less(i, j int) bool { return u[i] < u[j] }
+func (u uint64s) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+// +build unittest
+
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/contiv/cluster/management/src/boltdb"
+	"github.com/contiv/cluster/management/src/configuration"
+
+	. "gopkg.in/check.v1"
+)
+
+type jobHistorySuite struct {
+}
+
+var _ = Suite(&jobHistorySuite{})
+
+func (s *jobHistorySuite) TestNewJobIDs(c *C) {
+	mgr := &Manager{lastJobID: 10}
+	j1 := mgr.newJob("job1", nil, nil, []string{"foo"})
+	j2 := mgr.newJob("job2", nil, nil, []string{"bar"})
+	c.Assert(j1.ID(), Equals, uint64(11))
+	c.Assert(j2.ID(), Equals, uint64(12))
+	c.Assert(j1.Info().Nodes, DeepEquals, []string{"foo"})
+}
+
+func (s *jobHistorySuite) TestGetJobsFromHistory(c *C) {
+	mgr := &Manager{reqQ: make(chan event, 10)}
+	j1, err := setActiveJob(mgr, "job1", []string{"foo"})
+	c.Assert(err, IsNil)
+	j2, err := setActiveJob(mgr, "job2", []string{"bar"})
+	c.Assert(err, IsNil)
+	j3, err := setActiveJob(mgr, "job3", []string{"foo1", "bar1"})
+	c.Assert(err, IsNil)
+
+	tests := map[string]struct {
+		filter   jobsFilter
+		exptdIDs []uint64
+		total    int
+	}{
+		"no-filter": {
+			exptdIDs: []uint64{j3.ID(), j2.ID(), j1.ID()},
+			total:    3,
+		},
+		"node": {
+			filter:   jobsFilter{node: "bar1"},
+			exptdIDs: []uint64{j3.ID()},
+			total:    1,
+		},
+		"status": {
+			filter:   jobsFilter{status: Queued.String()},
+			exptdIDs: []uint64{j3.ID(), j2.ID(), j1.ID()},
+			total:    3,
+		},
+		"status-no-match": {
+			filter:   jobsFilter{status: Complete.String()},
+			exptdIDs: []uint64{},
+			total:    0,
+		},
+		"offset-limit": {
+			filter:   jobsFilter{offset: 1, limit: 1},
+			exptdIDs: []uint64{j2.ID()},
+			total:    3,
+		},
+		"offset-out-of-range": {
+			filter:   jobsFilter{offset: 5},
+			exptdIDs: []uint64{},
+			total:    3,
+		},
+	}
+
+	for key, test := range tests {
+		page, err := mgr.getJobsFromHistory(test.filter)
+		c.Assert(err, IsNil, Commentf("key: %s", key))
+		c.Assert(page.Total, Equals, test.total, Commentf("key: %s", key))
+		ids := []uint64{}
+		for _, info := range page.Jobs {
+			ids = append(ids, info.ID)
+		}
+		c.Assert(ids, DeepEquals, test.exptdIDs, Commentf("key: %s", key))
+	}
+}
+
+func (s *jobHistorySuite) TestGetJobInfo(c *C) {
+	mgr := &Manager{reqQ: make(chan event, 10)}
+	j1, err := setActiveJob(mgr, "job1", []string{"foo"})
+	c.Assert(err, IsNil)
+	mgr.resetActiveJob(j1)
+
+	info, err := mgr.getJobInfo(j1.ID())
+	c.Assert(err, IsNil)
+	c.Assert(info.Desc, Equals, "job1")
+
+	_, err = mgr.getJobInfo(j1.ID() + 1)
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, errJobIDNotExist(j1.ID()+1).Error())
+}
+
+func (s *jobHistorySuite) TestJobResultsToHistory(c *C) {
+	mgr := &Manager{}
+	j := mgr.newJob("job1", nil, nil, []string{"foo", "bar"})
+	j.setResults(map[string]configuration.HostResult{
+		"foo": configuration.HostResultOK,
+		"bar": configuration.HostResultFailed,
+	})
+
+	exptdResults := map[string]string{"foo": "ok", "bar": "failed"}
+	hj := jobToHistory(j)
+	c.Assert(hj.Results, DeepEquals, exptdResults)
+	c.Assert(historyToJobInfo(hj).Results, DeepEquals, exptdResults)
+}
+
+func (s *jobHistorySuite) TestPruneJobHistory(c *C) {
+	dir, err := ioutil.TempDir("", "jobhistory")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	db, err := boltdb.NewClientFromConfig(boltdb.Config{DBFile: filepath.Join(dir, "test.boltdb")})
+	c.Assert(err, IsNil)
+
+	old := time.Now().Add(-2 * time.Hour)
+	jobs := []boltdb.Job{
+		{ID: 1, Status: Complete.String(), EndTime: old},
+		{ID: 2, Status: Running.String()},
+		{ID: 3, Status: Errored.String(), EndTime: time.Now()},
+		{ID: 4, Status: Complete.String(), EndTime: time.Now()},
+		{ID: 5, Status: Complete.String(), EndTime: time.Now()},
+	}
+	for _, hj := range jobs {
+		c.Assert(db.SetJob(hj), IsNil)
+		c.Assert(db.SetJobLogs(hj.ID, []byte("logs")), IsNil)
+	}
+	jobIDs := func() []uint64 {
+		jobs, err := db.GetAllJobs()
+		c.Assert(err, IsNil)
+		ids := []uint64{}
+		for _, hj := range jobs {
+			ids = append(ids, hj.ID)
+		}
+		return ids
+	}
+
+	mgr := &Manager{db: db, config: DefaultConfig()}
+	mgr.config.Manager.JobHistory = jobHistoryConfig{}
+	mgr.pruneJobHistory()
+	c.Assert(jobIDs(), DeepEquals, []uint64{1, 2, 3, 4, 5})
+
+	// the jobs older than the max age are deleted along with their logs
+	mgr.config.Manager.JobHistory = jobHistoryConfig{MaxAge: "1h"}
+	mgr.pruneJobHistory()
+	c.Assert(jobIDs(), DeepEquals, []uint64{2, 3, 4, 5})
+	logs, err := db.GetJobLogs(1)
+	c.Assert(err, IsNil)
+	c.Assert(logs, HasLen, 0)
+
+	// the oldest jobs that are done are deleted to keep the max jobs, while the
+	// jobs that are not done are kept
+	mgr.config.Manager.JobHistory = jobHistoryConfig{MaxJobs: 2}
+	mgr.pruneJobHistory()
+	c.Assert(jobIDs(), DeepEquals, []uint64{2, 5})
+}
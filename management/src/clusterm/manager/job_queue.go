@@ -0,0 +1,201 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/errored"
+)
+
+// errJobQueued is returned by the processing of an event whose job conflicts
+// with the active or queued jobs. The job is queued and is run later, so it is
+// not treated as a failure of the request.
+var errJobQueued = errored.Errorf("job was queued as one or more nodes are in use by other jobs")
+
+// errJobDequeued is the error recorded in job history for the jobs that were
+// removed from the queue before they could run
+var errJobDequeued = errored.Errorf("job was removed from the queue")
+
+func errJobNotQueued(job string) error {
+	return errored.Errorf("job %q is not in the queue", job)
+}
+
+// QueuedJobInfo is the information of a job in the job queue as returned by the REST interface
+type QueuedJobInfo struct {
+	Priority int      `json:"priority"`
+	Job      *JobInfo `json:"job"`
+}
+
+// queuedJob is an entry in the job queue. It holds the event that created the
+// job, the event is processed again when the job reaches the head of the queue.
+type queuedJob struct {
+	job      *Job
+	ev       event
+	priority int
+}
+
+// jobQueue keeps the jobs that conflict with the active jobs. The jobs are
+// ordered by their priority, higher priority first, and the jobs with same
+// priority are kept in the order they were created.
+type jobQueue struct {
+	sync.Mutex
+	entries []*queuedJob
+}
+
+func (q *jobQueue) Len() int { return len(q.entries) }
+func (q *jobQueue) Less(i, j int) bool {
+	if q.entries[i].priority != q.entries[j].priority {
+		return q.entries[i].priority > q.entries[j].priority
+	}
+	return q.entries[i].job.id < q.entries[j].job.id
+}
+func (q *jobQueue) Swap(i, j int) { q.entries[i], q.entries[j] = q.entries[j], q.entries[i] }
+
+// add adds a job to the queue as per it's priority
+func (q *jobQueue) add(qj *queuedJob) {
+	q.Lock()
+	defer q.Unlock()
+	q.entries = append(q.entries, qj)
+	sort.Stable(q)
+}
+
+// remove removes a job from the queue. It returns false if the job is not in the queue.
+func (q *jobQueue) remove(j *Job) bool {
+	q.Lock()
+	defer q.Unlock()
+	for i, qj := range q.entries {
+		if qj.job == j {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// list returns the entries in the queue at the time of call, in queue order
+func (q *jobQueue) list() []*queuedJob {
+	q.Lock()
+	defer q.Unlock()
+	return append([]*queuedJob{}, q.entries...)
+}
+
+// conflicts checks if a job with specified priority shall wait behind a queued
+// job, i.e. a job that is ahead of it in queue order and shares one or more nodes.
+func (q *jobQueue) conflicts(j *Job, priority int) bool {
+	q.Lock()
+	defer q.Unlock()
+	for _, qj := range q.entries {
+		if qj.job == j || qj.priority < priority ||
+			(qj.priority == priority && qj.job.id > j.id) {
+			continue
+		}
+		for _, name := range j.nodes {
+			if containsString(qj.job.nodes, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// enqueueJob() adds the job to the job queue along with the event that created it
+func (m *Manager) enqueueJob(j *Job, e event, priority int) {
+	m.queue.add(&queuedJob{
+		job:      j,
+		ev:       e,
+		priority: priority,
+	})
+	m.saveJob(j)
+}
+
+// dequeueJob() removes a job from the job queue. The job is recorded as errored
+// in the job history.
+func (m *Manager) dequeueJob(id uint64) error {
+	for _, qj := range m.queue.list() {
+		if qj.job.id != id {
+			continue
+		}
+		if !m.queue.remove(qj.job) {
+			break
+		}
+		m.failQueuedJob(qj.job, errJobDequeued)
+		return nil
+	}
+	return errJobNotQueued(fmt.Sprintf("%d", id))
+}
+
+// failQueuedJob() records the failure of a queued job that couldn't be run
+func (m *Manager) failQueuedJob(j *Job, err error) {
+	j.setStatus(Errored, err)
+	m.saveJob(j)
+	// signal the waiters, if any, as the job won't run anymore
+	close(j.doneCh)
+}
+
+// getQueuedJobs() returns the jobs in the job queue at the time of call, in queue order
+func (m *Manager) getQueuedJobs() []*Job {
+	jobs := []*Job{}
+	for _, qj := range m.queue.list() {
+		jobs = append(jobs, qj.job)
+	}
+	return jobs
+}
+
+// getJobQueueInfo() returns the info of the jobs in the job queue, in queue order
+func (m *Manager) getJobQueueInfo() []*QueuedJobInfo {
+	infos := []*QueuedJobInfo{}
+	for _, qj := range m.queue.list() {
+		info := qj.job.Info()
+		info.Logs = nil
+		infos = append(infos, &QueuedJobInfo{
+			Priority: qj.priority,
+			Job:      info,
+		})
+	}
+	return infos
+}
+
+// signalJobQueue() triggers the processing of the job queue, if there are queued jobs
+func (m *Manager) signalJobQueue() {
+	if len(m.queue.list()) == 0 {
+		return
+	}
+	// the event is posted in a separate go-routine as this may be called
+	// from within the event loop
+	go func() { m.reqQ <- newProcessJobQueueEvent(m) }()
+}
+
+// processJobQueueEvent processes the events of the queued jobs in queue order.
+// The jobs that still conflict with other jobs are queued again.
+type processJobQueueEvent struct {
+	mgr *Manager
+}
+
+// newProcessJobQueueEvent creates and returns processJobQueueEvent
+func newProcessJobQueueEvent(mgr *Manager) *processJobQueueEvent {
+	return &processJobQueueEvent{
+		mgr: mgr,
+	}
+}
+
+func (e *processJobQueueEvent) String() string {
+	return "processJobQueueEvent"
+}
+
+func (e *processJobQueueEvent) process() error {
+	for _, qj := range e.mgr.queue.list() {
+		if !e.mgr.queue.remove(qj.job) {
+			// job was removed from the queue in the meanwhile
+			continue
+		}
+		err := qj.ev.process()
+		if err == nil || err == errJobQueued {
+			continue
+		}
+		logrus.Errorf("queued job %d failed to start. Error: %v", qj.job.id, err)
+		e.mgr.failQueuedJob(qj.job, err)
+	}
+	return nil
+}
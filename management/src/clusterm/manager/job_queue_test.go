@@ -0,0 +1,164 @@
+// +build unittest
+
+package manager
+
+import (
+	"fmt"
+
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type jobQueueSuite struct {
+}
+
+var _ = Suite(&jobQueueSuite{})
+
+// testJobEvent is an event that sets it's job as active, just like the events
+// that run a job
+type testJobEvent struct {
+	mgr      *Manager
+	job      *Job
+	priority int
+}
+
+func (e *testJobEvent) String() string {
+	return "testJobEvent"
+}
+
+func (e *testJobEvent) process() error {
+	return e.mgr.checkAndSetActiveJob(e.job, e, e.priority)
+}
+
+func newTestJobEvent(mgr *Manager, desc string, nodeNames []string, priority int) *testJobEvent {
+	return &testJobEvent{
+		mgr:      mgr,
+		job:      mgr.newJob(desc, nil, nil, nodeNames),
+		priority: priority,
+	}
+}
+
+func (s *jobQueueSuite) TestQueueOrder(c *C) {
+	mgr := &Manager{reqQ: make(chan event, 10)}
+	j1, err := setActiveJob(mgr, "job1", []string{"foo"})
+	c.Assert(err, IsNil)
+
+	priorities := []int{0, 1, 0, 2, 1}
+	exptdOrder := []int{3, 1, 4, 0, 2}
+	events := []*testJobEvent{}
+	for i, p := range priorities {
+		e := newTestJobEvent(mgr, fmt.Sprintf("queued%d", i), []string{"foo"}, p)
+		c.Assert(e.process(), Equals, errJobQueued)
+		events = append(events, e)
+	}
+
+	exptdJobs := []*Job{}
+	for _, i := range exptdOrder {
+		exptdJobs = append(exptdJobs, events[i].job)
+	}
+	c.Assert(mgr.getQueuedJobs(), DeepEquals, exptdJobs)
+	c.Assert(mgr.getActiveJobs(), DeepEquals, []*Job{j1})
+
+	infos := mgr.getJobQueueInfo()
+	c.Assert(len(infos), Equals, len(exptdOrder))
+	for i, info := range infos {
+		c.Assert(info.Priority, Equals, priorities[exptdOrder[i]])
+		c.Assert(info.Job.Status, Equals, Queued.String())
+	}
+}
+
+func (s *jobQueueSuite) TestQueueConflicts(c *C) {
+	mgr := &Manager{reqQ: make(chan event, 10)}
+	_, err := setActiveJob(mgr, "job1", []string{"foo"})
+	c.Assert(err, IsNil)
+
+	tests := []struct {
+		name     string
+		nodes    []string
+		priority int
+		exptdErr error
+	}{
+		{"conflict-active", []string{"foo"}, 0, errJobQueued},
+		{"disjoint", []string{"bar"}, 0, nil},
+		{"conflict-active-and-free", []string{"foo", "baz"}, 0, errJobQueued},
+		{"conflict-queued", []string{"baz"}, 0, errJobQueued},
+		{"higher-priority-than-queued", []string{"baz"}, 1, nil},
+	}
+
+	for _, test := range tests {
+		e := newTestJobEvent(mgr, test.name, test.nodes, test.priority)
+		c.Assert(e.process(), Equals, test.exptdErr, Commentf("test: %s", test.name))
+	}
+	c.Assert(len(mgr.getActiveJobs()), Equals, 3)
+	c.Assert(len(mgr.getQueuedJobs()), Equals, 3)
+}
+
+func (s *jobQueueSuite) TestProcessJobQueue(c *C) {
+	mgr := &Manager{reqQ: make(chan event, 10)}
+	j1, err := setActiveJob(mgr, "job1", []string{"foo"})
+	c.Assert(err, IsNil)
+	e2 := newTestJobEvent(mgr, "job2", []string{"foo"}, 0)
+	c.Assert(e2.process(), Equals, errJobQueued)
+	e3 := newTestJobEvent(mgr, "job3", []string{"foo"}, 1)
+	c.Assert(e3.process(), Equals, errJobQueued)
+
+	// the higher priority job is run once the active job is done, while the
+	// other job stays queued
+	mgr.resetActiveJob(j1)
+	c.Assert(newProcessJobQueueEvent(mgr).process(), IsNil)
+	c.Assert(mgr.getActiveJobs(), DeepEquals, []*Job{e3.job})
+	c.Assert(mgr.getQueuedJobs(), DeepEquals, []*Job{e2.job})
+
+	mgr.resetActiveJob(e3.job)
+	c.Assert(newProcessJobQueueEvent(mgr).process(), IsNil)
+	c.Assert(mgr.getActiveJobs(), DeepEquals, []*Job{e2.job})
+	c.Assert(len(mgr.getQueuedJobs()), Equals, 0)
+}
+
+func (s *jobQueueSuite) TestDequeueJob(c *C) {
+	mgr := &Manager{reqQ: make(chan event, 10)}
+	_, err := setActiveJob(mgr, "job1", []string{"foo"})
+	c.Assert(err, IsNil)
+	e2 := newTestJobEvent(mgr, "job2", []string{"foo"}, 0)
+	c.Assert(e2.process(), Equals, errJobQueued)
+
+	c.Assert(mgr.dequeueJob(e2.job.ID()), IsNil)
+	c.Assert(len(mgr.getQueuedJobs()), Equals, 0)
+	status, errVal := e2.job.Status()
+	c.Assert(status, Equals, Errored)
+	c.Assert(errVal, Equals, errJobDequeued)
+
+	err = mgr.dequeueJob(e2.job.ID())
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, errJobNotQueued(fmt.Sprintf("%d", e2.job.ID())).Error())
+}
+
+func (s *jobQueueSuite) TestQueueValidatesEvent(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newVerifyTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+	}, nil, nil)
+	j1, err := setActiveJob(mgr, "job1", []string{"node1"})
+	c.Assert(err, IsNil)
+
+	// an invalid event is rejected instead of being queued
+	e := newUpdateEvent(mgr, []string{"node1"}, "", "foo", 0, false)
+	c.Assert(e.process(), ErrorMatches, ".*invalid host-group specified.*")
+	c.Assert(mgr.getQueuedJobs(), HasLen, 0)
+
+	// a queued event is validated again once it is dequeued
+	e = newUpdateEvent(mgr, []string{"node1"}, "", "", 0, false)
+	c.Assert(e.process(), Equals, errJobQueued)
+	c.Assert(mgr.getQueuedJobs(), DeepEquals, []*Job{e._job})
+	c.Assert(mgr.inventory.SetAssetDisappeared("node1"), IsNil)
+	mgr.resetActiveJob(j1)
+	c.Assert(newProcessJobQueueEvent(mgr).process(), IsNil)
+	c.Assert(mgr.getQueuedJobs(), HasLen, 0)
+	c.Assert(mgr.getActiveJobs(), HasLen, 0)
+	status, errVal := e._job.Status()
+	c.Assert(status, Equals, Errored)
+	c.Assert(errVal, ErrorMatches, ".*not in discovered state.*")
+}
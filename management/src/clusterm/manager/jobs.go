@@ -9,11 +9,25 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/contiv/cluster/management/src/configuration"
 	"github.com/contiv/errored"
 )
 
-var notRunningErr = errored.Errorf("job is not Running")
+var (
+	notRunningErr       = errored.Errorf("job is not Running")
+	cancelInProgressErr = errored.Errorf("job is already being cancelled")
+)
+
+// The kinds of the jobs that leave the nodes in a transitional status while
+// they run, and are re-run when they are interrupted by a clusterm restart
+const (
+	jobKindCommission   = "commission"
+	jobKindDecommission = "decommission"
+	jobKindUpdate       = "update"
+	jobKindUpgrade      = "upgrade"
+)
 
 // CancelChannel is type of the channle used to signal cancellation of job
 type CancelChannel chan struct{}
@@ -28,14 +42,43 @@ type DoneCallback func(status JobStatus, errVal error)
 // Job corresponds to a long running task, triggered by an event
 type Job struct {
 	sync.Mutex
+	id        uint64
+	nodes     []string
+	actor     string // who started the job, recorded as the cause of the status transitions
 	runner    JobRunner
 	done      DoneCallback
 	cancelCh  CancelChannel
+	cancelled bool
+	doneCh    chan struct{}
 	status    JobStatus
 	errVal    error
+	results   map[string]configuration.HostResult
 	logs      bytes.Buffer
 	logWriter *MultiWriter
 	desc      string
+	startTime time.Time
+	endTime   time.Time
+	// kind, extraVars and hostGroups are the parameters of the job's event,
+	// that are kept in the job history to re-run an interrupted job
+	kind       string
+	extraVars  string
+	hostGroups map[string]string
+}
+
+// JobInfo is the information of a job as returned by the REST interface. The
+// same information is kept in the job history for the jobs that are done.
+type JobInfo struct {
+	ID        uint64     `json:"id"`
+	Desc      string     `json:"desc"`
+	Task      string     `json:"task"`
+	Nodes     []string   `json:"nodes"`
+	Status    string     `json:"status"`
+	ErrVal    string     `json:"error"`
+	StartTime *time.Time `json:"start_time,omitempty"`
+	EndTime   *time.Time `json:"end_time,omitempty"`
+	// Results is the outcome of the job on each node, when available
+	Results map[string]string `json:"results,omitempty"`
+	Logs    []string          `json:"logs,omitempty"`
 }
 
 // NewJob initializes and returns an instance of a job described by the runner and done callback
@@ -45,6 +88,7 @@ func NewJob(desc string, jr JobRunner, done DoneCallback) *Job {
 		done:      done,
 		desc:      desc,
 		cancelCh:  make(chan struct{}),
+		doneCh:    make(chan struct{}),
 		status:    Queued,
 		errVal:    nil,
 		logWriter: &MultiWriter{},
@@ -59,46 +103,106 @@ func (j *Job) runnerName() string {
 
 // String returns a brief description of the job
 func (j *Job) String() string {
-	return fmt.Sprintf("[task: %s status: %v errVal: %v]", j.runnerName(), j.status, j.errVal)
+	status, errVal := j.Status()
+	return fmt.Sprintf("[id: %d task: %s status: %v errVal: %v]", j.id, j.runnerName(), status, errVal)
+}
+
+// ID returns the id of the job
+func (j *Job) ID() uint64 {
+	return j.id
+}
+
+// setParams sets the parameters of the job's event, that are needed to re-run
+// the job when it is interrupted. hostGroups are the target host-groups of the
+// nodes keyed by node name, an empty host-group means the node's current one.
+func (j *Job) setParams(kind, extraVars string, hostGroups map[string]string) {
+	j.Lock()
+	j.kind = kind
+	j.extraVars = extraVars
+	j.hostGroups = hostGroups
+	j.Unlock()
 }
 
+// setStatus sets the status of the job. The start time of the job is recorded
+// when it starts running and the end time when it is done.
 func (j *Job) setStatus(status JobStatus, err error) {
 	j.Lock()
 	j.status = status
 	j.errVal = err
+	switch status {
+	case Running:
+		j.startTime = time.Now()
+	case Complete, Errored, Cancelled:
+		j.endTime = time.Now()
+	}
+	j.Unlock()
+}
+
+// setResults records the outcome of the job on each node
+func (j *Job) setResults(results map[string]configuration.HostResult) {
+	j.Lock()
+	j.results = results
 	j.Unlock()
 }
 
+// Results returns the outcome of the job on each node, as recorded at the time of call
+func (j *Job) Results() map[string]configuration.HostResult {
+	j.Lock()
+	defer j.Unlock()
+	results := map[string]configuration.HostResult{}
+	for name, result := range j.results {
+		results[name] = result
+	}
+	return results
+}
+
 // Run begins the job and wait for completion. This function blocks
 func (j *Job) Run() {
 	j.setStatus(Running, nil)
 	defer func() {
-		j.done(j.status, j.errVal)
+		j.done(j.Status())
 		j.logWriter.Close()
+		close(j.doneCh)
 	}()
 
 	if err := j.runner(j.cancelCh, j.logWriter); err != nil {
+		if err == errJobCancelled {
+			j.setStatus(Cancelled, err)
+			return
+		}
 		j.setStatus(Errored, err)
 		return
 	}
 	j.setStatus(Complete, nil)
 }
 
-//Cancel signals canceling a running job
+// Cancel signals canceling a running job. The signal is sent by closing the
+// cancel-channel, so it is seen by every step of the runner that follows and
+// a call to Cancel never blocks.
 func (j *Job) Cancel() error {
-	// if job is running then run it's cancel function
 	// the job status shall be updated as part of runner
 	j.Lock()
 	defer j.Unlock()
-	if j.status == Running {
-		j.cancelCh <- struct{}{}
-		return nil
+	if j.status != Running {
+		return notRunningErr
+	}
+	if j.cancelled {
+		return cancelInProgressErr
 	}
-	return notRunningErr
+	j.cancelled = true
+	close(j.cancelCh)
+	return nil
+}
+
+// Wait blocks till the job is done, i.e. it has run and it's done callback has returned
+func (j *Job) Wait() {
+	<-j.doneCh
 }
 
 // Status returns the status of a job at the time of call
 func (j *Job) Status() (JobStatus, error) {
+	j.Lock()
+	defer j.Unlock()
 	return j.status, j.errVal
 }
 
@@ -120,23 +224,40 @@ func (j *Job) PipeLogs(w io.Writer) error {
 	return nil
 }
 
-// MarshalJSON marshals and returns the JSON for job info
-func (j *Job) MarshalJSON() ([]byte, error) {
-	toJSON := struct {
-		Desc   string   `json:"desc"`
-		Task   string   `json:"task"`
-		Status string   `json:"status"`
-		ErrVal string   `json:"error"`
-		Logs   []string `json:"logs"`
-	}{
-		Desc:   j.desc,
-		Task:   j.runnerName(),
-		Status: j.status.String(),
-		Logs:   strings.Split(j.logs.String(), "\n"),
+// Info returns the information of the job at the time of call
+func (j *Job) Info() *JobInfo {
+	info := &JobInfo{
+		ID:    j.id,
+		Desc:  j.desc,
+		Task:  j.runnerName(),
+		Nodes: j.nodes,
+		Logs:  strings.Split(j.logs.String(), "\n"),
 	}
+	j.Lock()
+	info.Status = j.status.String()
 	if j.errVal != nil {
-		toJSON.ErrVal = fmt.Sprintf("%v", j.errVal)
+		info.ErrVal = fmt.Sprintf("%v", j.errVal)
+	}
+	if !j.startTime.IsZero() {
+		startTime := j.startTime
+		info.StartTime = &startTime
+	}
+	if !j.endTime.IsZero() {
+		endTime := j.endTime
+		info.EndTime = &endTime
+	}
+	j.Unlock()
+	if results := j.Results(); len(results) > 0 {
+		info.Results = map[string]string{}
+		for name, result := range results {
+			info.Results[name] = string(result)
+		}
 	}
 
-	return json.Marshal(toJSON)
+	return info
+}
+
+// MarshalJSON marshals and returns the JSON for job info
+func (j *Job) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Info())
 }
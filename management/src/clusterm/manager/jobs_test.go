@@ -108,6 +108,25 @@ func (s *jobsSuite) TestJobRunSuccess(c *C) {
 	checkDoneCb(c, cbCh)
 }
 
+func (s *jobsSuite) TestJobRunTimes(c *C) {
+	wg := &sync.WaitGroup{}
+	cbCh := make(chan struct{}, 1)
+	j := NewJob("", runner(wg, 100*time.Millisecond, nil), expectDoneCb(c, cbCh, Complete, nil))
+	info := j.Info()
+	c.Assert(info.StartTime, IsNil)
+	c.Assert(info.EndTime, IsNil)
+	wg.Add(1)
+	go j.Run()
+
+	waitAndCheckJobStatus(c, wg, j, Complete, nil)
+
+	checkDoneCb(c, cbCh)
+	info = j.Info()
+	c.Assert(info.StartTime, NotNil)
+	c.Assert(info.EndTime, NotNil)
+	c.Assert(info.EndTime.Before(*info.StartTime), Equals, false)
+}
+
 func (s *jobsSuite) TestJobStatusRunning(c *C) {
 	wg := &sync.WaitGroup{}
 	cbCh := make(chan struct{}, 1)
@@ -154,6 +173,28 @@ func (s *jobsSuite) TestJobRunCancel(c *C) {
 	checkDoneCb(c, cbCh)
 }
 
+func (s *jobsSuite) TestJobCancelTwice(c *C) {
+	wg := &sync.WaitGroup{}
+	cbCh := make(chan struct{}, 1)
+	j := NewJob("", cancellableRunner(c, wg, 3*time.Second, errJobCancelled),
+		expectDoneCb(c, cbCh, Cancelled, errJobCancelled))
+	wg.Add(1)
+	go j.Run()
+	// give some time for job to start
+	time.Sleep(1 * time.Second)
+	c.Assert(j.Cancel(), IsNil)
+	c.Assert(j.Cancel(), Equals, cancelInProgressErr)
+
+	waitAndCheckJobStatus(c, wg, j, Cancelled, errJobCancelled)
+
+	checkDoneCb(c, cbCh)
+}
+
+func (s *jobsSuite) TestJobCancelNotRunning(c *C) {
+	j := NewJob("", nil, nil)
+	c.Assert(j.Cancel(), Equals, notRunningErr)
+}
+
 func (s *jobsSuite) TestJobLogs(c *C) {
 	wg := &sync.WaitGroup{}
 	cbCh := make(chan struct{}, 1)
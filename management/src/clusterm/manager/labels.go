@@ -0,0 +1,145 @@
+package manager
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/contiv/errored"
+)
+
+// labelRe is the format of the key and value of a label, like `rack` or `r1`
+var labelRe = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_./-]*[A-Za-z0-9])?$`)
+
+// labelRequirement is a requirement of a label selector, that the node's label
+// with the key is equal, or not equal, to the value
+type labelRequirement struct {
+	key   string
+	value string
+	equal bool
+}
+
+func (r labelRequirement) matches(labels map[string]string) bool {
+	return (labels[r.key] == r.value) == r.equal
+}
+
+// matchesSelector returns true if the labels meet all the requirements of a selector
+func matchesSelector(reqs []labelRequirement, labels map[string]string) bool {
+	for _, r := range reqs {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// errInvalidLabel is the error returned when a label with invalid key or value
+// is specified
+func errInvalidLabel(key, value string) error {
+	return errored.Errorf("invalid label %q=%q, the key and value should be alphanumeric and may contain '_', '.', '/' or '-' in between", key, value)
+}
+
+// errInvalidSelector is the error returned when an invalid label selector is specified
+func errInvalidSelector(selector string) error {
+	return errored.Errorf("invalid label selector %q, it should be a comma separated list of 'key=value' or 'key!=value'", selector)
+}
+
+// errNoNodesSelected is the error returned when no node matches a label selector
+func errNoNodesSelected(selector string) error {
+	return errored.Errorf("no nodes match the label selector %q", selector)
+}
+
+// validateLabels validates the labels to be set on a node. A label with empty
+// value is removed from the node.
+func validateLabels(labels map[string]string) error {
+	if len(labels) == 0 {
+		return errored.Errorf("no labels specified")
+	}
+	for k, v := range labels {
+		if !labelRe.MatchString(k) || (v != "" && !labelRe.MatchString(v)) {
+			return errInvalidLabel(k, v)
+		}
+	}
+	return nil
+}
+
+// parseSelector parses a label selector, that is a comma separated list of
+// requirements like `rack=r1,owner!=team1`. A node matches the selector if it
+// meets all the requirements.
+func parseSelector(selector string) ([]labelRequirement, error) {
+	reqs := []labelRequirement{}
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		req := labelRequirement{equal: true}
+		kv := strings.SplitN(term, "!=", 2)
+		if len(kv) == 2 {
+			req.equal = false
+		} else {
+			kv = strings.SplitN(term, "=", 2)
+		}
+		if len(kv) != 2 {
+			return nil, errInvalidSelector(selector)
+		}
+		req.key, req.value = strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if !labelRe.MatchString(req.key) || !labelRe.MatchString(req.value) {
+			return nil, errInvalidSelector(selector)
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// nodesByLabels returns the sorted names of the nodes whose labels match the
+// selector. It is an error if no node matches.
+func (m *Manager) nodesByLabels(selector string) ([]string, error) {
+	reqs, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, name := range m.sortedNodeNames() {
+		n := m.nodes[name]
+		if n.Inv == nil {
+			continue
+		}
+		if matchesSelector(reqs, n.Inv.GetAttributes()) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, errNoNodesSelected(selector)
+	}
+	return names, nil
+}
+
+// setLabelsEvent sets the labels of a node. The labels are merged with the
+// existing labels of the node, a label with empty value is removed.
+type setLabelsEvent struct {
+	mgr      *Manager
+	nodeName string
+	labels   map[string]string
+}
+
+// newSetLabelsEvent creates and returns setLabelsEvent
+func newSetLabelsEvent(mgr *Manager, nodeName string, labels map[string]string) *setLabelsEvent {
+	return &setLabelsEvent{
+		mgr:      mgr,
+		nodeName: nodeName,
+		labels:   labels,
+	}
+}
+
+func (e *setLabelsEvent) String() string {
+	return fmt.Sprintf("setLabelsEvent: node: %s labels: %v", e.nodeName, e.labels)
+}
+
+func (e *setLabelsEvent) process() error {
+	if _, err := e.mgr.findNode(e.nodeName); err != nil {
+		return err
+	}
+	if err := validateLabels(e.labels); err != nil {
+		return err
+	}
+	return e.mgr.inventory.SetAssetAttributes(e.nodeName, e.labels)
+}
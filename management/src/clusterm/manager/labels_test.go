@@ -0,0 +1,107 @@
+// +build unittest
+
+package manager
+
+import (
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type labelsSuite struct {
+}
+
+var _ = Suite(&labelsSuite{})
+
+func (s *labelsSuite) TestParseSelector(c *C) {
+	reqs, err := parseSelector("rack=r1, owner!=team1")
+	c.Assert(err, IsNil)
+	c.Assert(reqs, DeepEquals, []labelRequirement{
+		{key: "rack", value: "r1", equal: true},
+		{key: "owner", value: "team1", equal: false},
+	})
+
+	for _, selector := range []string{"", "rack", "rack=", "=r1", "rack=r1,", "rack==r1", "rack=r 1"} {
+		_, err := parseSelector(selector)
+		c.Assert(err, ErrorMatches, "invalid label selector.*", Commentf("selector: %q", selector))
+	}
+}
+
+func (s *labelsSuite) TestValidateLabels(c *C) {
+	c.Assert(validateLabels(map[string]string{"rack": "r1", "hw/class": "gpu-2", "owner": ""}), IsNil)
+	c.Assert(validateLabels(map[string]string{}), ErrorMatches, "no labels specified")
+	c.Assert(validateLabels(map[string]string{"rack": "r,1"}), ErrorMatches, "invalid label.*")
+	c.Assert(validateLabels(map[string]string{"-rack": "r1"}), ErrorMatches, "invalid label.*")
+}
+
+var labelsTestAssets = map[string]inventory.AssetStatus{
+	"node1": inventory.Allocated,
+	"node2": inventory.Allocated,
+	"node3": inventory.Allocated,
+}
+
+// setTestLabels labels node1 and node2 to be in rack r1 and node3 to be in rack r2
+func setTestLabels(c *C, mgr *Manager) {
+	for name, rack := range map[string]string{"node1": "r1", "node2": "r1", "node3": "r2"} {
+		c.Assert(newSetLabelsEvent(mgr, name, map[string]string{"rack": rack, "owner": "team1"}).process(), IsNil)
+	}
+}
+
+func (s *labelsSuite) TestSetLabels(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newTestManager(c, ctrl, labelsTestAssets, inventory.Discovered)
+	setTestLabels(c, mgr)
+	c.Assert(newSetLabelsEvent(mgr, "node1", map[string]string{"owner": "", "zone": "z1"}).process(), IsNil)
+	c.Assert(mgr.nodes["node1"].Inv.GetAttributes(), DeepEquals, map[string]string{"rack": "r1", "zone": "z1"})
+
+	c.Assert(newSetLabelsEvent(mgr, "node4", map[string]string{"rack": "r1"}).process(), ErrorMatches, ".*node4.*")
+	c.Assert(newSetLabelsEvent(mgr, "node1", map[string]string{"rack": "r 1"}).process(), ErrorMatches, "invalid label.*")
+}
+
+func (s *labelsSuite) TestNodesByLabels(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newTestManager(c, ctrl, labelsTestAssets, inventory.Discovered)
+	setTestLabels(c, mgr)
+	c.Assert(newSetLabelsEvent(mgr, "node2", map[string]string{"owner": "team2"}).process(), IsNil)
+
+	tests := map[string][]string{
+		"rack=r1":               {"node1", "node2"},
+		"owner!=team2":          {"node1", "node3"},
+		"rack=r1,owner!=team2":  {"node1"},
+		"zone!=z1":              {"node1", "node2", "node3"},
+		"rack!=r1,owner=team1 ": {"node3"},
+	}
+	for selector, exptd := range tests {
+		names, err := mgr.nodesByLabels(selector)
+		c.Assert(err, IsNil, Commentf("selector: %q", selector))
+		c.Assert(names, DeepEquals, exptd, Commentf("selector: %q", selector))
+	}
+
+	_, err := mgr.nodesByLabels("rack=r3")
+	c.Assert(err, ErrorMatches, "no nodes match.*")
+}
+
+func (s *labelsSuite) TestDecommissionBySelector(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newTestManager(c, ctrl, labelsTestAssets, inventory.Discovered)
+	setTestLabels(c, mgr)
+	e := newDecommissionEventBySelector(mgr, "rack=r1", configuration.DefaultValidJSON, 0, false)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(e._job.Info().Nodes, DeepEquals, []string{"node1", "node2"})
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Decommissioned)
+	c.Assert(assetStatus(mgr, "node2"), Equals, inventory.Decommissioned)
+	c.Assert(assetStatus(mgr, "node3"), Equals, inventory.Allocated)
+
+	e = newDecommissionEventBySelector(mgr, "rack=r3", configuration.DefaultValidJSON, 0, false)
+	c.Assert(e.process(), ErrorMatches, "no nodes match.*")
+	c.Assert(e._job, IsNil)
+}
@@ -0,0 +1,103 @@
+package manager
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/contiv/errored"
+)
+
+func errActiveJob(desc string) error {
+	return errored.Errorf("there is already an active job, please try in sometime. Job: %s", desc)
+}
+
+func errActiveJobOnNodes(nodes []string, desc string) error {
+	return errored.Errorf("there is already an active job on one or more nodes, please try in sometime. Nodes: %v Job: %s", nodes, desc)
+}
+
+// nodeLocks provides the per-node locking facility for jobs. It allows the jobs
+// on disjoint set of nodes to run concurrently, while the jobs on conflicting
+// nodes are rejected. A job can also lock the whole cluster (for instance to
+// update the clusterm configuration), in which case no other job is allowed.
+type nodeLocks struct {
+	sync.Mutex
+	owners map[string]*Job
+	all    *Job
+}
+
+// lock acquires the lock on all the specified nodes for the job. Either all
+// the nodes are locked or none are.
+func (l *nodeLocks) lock(j *Job, names []string) error {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.all != nil {
+		return errActiveJob(l.all.String())
+	}
+
+	var (
+		owner  *Job
+		locked []string
+	)
+	for _, name := range names {
+		if o, ok := l.owners[name]; ok && o != j {
+			owner = o
+			locked = append(locked, name)
+		}
+	}
+	if len(locked) > 0 {
+		sort.Strings(locked)
+		return errActiveJobOnNodes(locked, owner.String())
+	}
+
+	if l.owners == nil {
+		l.owners = make(map[string]*Job)
+	}
+	for _, name := range names {
+		l.owners[name] = j
+	}
+	return nil
+}
+
+// lockAll acquires a cluster wide lock for the job. It fails if any other job
+// holds a lock.
+func (l *nodeLocks) lockAll(j *Job) error {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.all != nil {
+		return errActiveJob(l.all.String())
+	}
+	for _, o := range l.owners {
+		return errActiveJob(o.String())
+	}
+
+	l.all = j
+	return nil
+}
+
+// unlock releases all the locks held by the job
+func (l *nodeLocks) unlock(j *Job) {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.all == j {
+		l.all = nil
+	}
+	for name, o := range l.owners {
+		if o == j {
+			delete(l.owners, name)
+		}
+	}
+}
+
+// owner returns the job that holds the lock on the node, if any
+func (l *nodeLocks) owner(name string) *Job {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.all != nil {
+		return l.all
+	}
+	return l.owners[name]
+}
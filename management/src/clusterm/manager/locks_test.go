@@ -0,0 +1,93 @@
+// +build unittest
+
+package manager
+
+import (
+	"io"
+
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type locksSuite struct {
+}
+
+var _ = Suite(&locksSuite{})
+
+func noopJob(desc string) *Job {
+	return NewJob(desc,
+		func(cancelCh CancelChannel, logs io.Writer) error { return nil },
+		func(status JobStatus, errRet error) {})
+}
+
+func (s *locksSuite) TestLockDisjointNodes(c *C) {
+	l := &nodeLocks{}
+	j1 := noopJob("job1")
+	j2 := noopJob("job2")
+	c.Assert(l.lock(j1, []string{"foo", "bar"}), IsNil)
+	c.Assert(l.lock(j2, []string{"dead", "beef"}), IsNil)
+}
+
+func (s *locksSuite) TestLockConflictingNodes(c *C) {
+	l := &nodeLocks{}
+	j1 := noopJob("job1")
+	j2 := noopJob("job2")
+	c.Assert(l.lock(j1, []string{"foo", "bar"}), IsNil)
+	err := l.lock(j2, []string{"dead", "bar", "foo"})
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, errActiveJobOnNodes([]string{"bar", "foo"}, j1.String()).Error())
+
+	// make sure failed lock attempt didn't lock any of the nodes
+	j3 := noopJob("job3")
+	c.Assert(l.lock(j3, []string{"dead"}), IsNil)
+}
+
+func (s *locksSuite) TestUnlock(c *C) {
+	l := &nodeLocks{}
+	j1 := noopJob("job1")
+	j2 := noopJob("job2")
+	c.Assert(l.lock(j1, []string{"foo", "bar"}), IsNil)
+	l.unlock(j1)
+	c.Assert(l.lock(j2, []string{"foo", "bar"}), IsNil)
+}
+
+func (s *locksSuite) TestLockAll(c *C) {
+	l := &nodeLocks{}
+	j1 := noopJob("job1")
+	j2 := noopJob("job2")
+	c.Assert(l.lockAll(j1), IsNil)
+	err := l.lock(j2, []string{"foo"})
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, errActiveJob(j1.String()).Error())
+	err = l.lockAll(j2)
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, errActiveJob(j1.String()).Error())
+
+	l.unlock(j1)
+	c.Assert(l.lock(j2, []string{"foo"}), IsNil)
+	err = l.lockAll(j1)
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, errActiveJob(j2.String()).Error())
+}
+
+func (s *locksSuite) TestDiscoverLocksKnownNodes(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newVerifyTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+	}, nil, nil)
+	mgr.nodes["node1"].Cfg = configuration.NewAnsibleHost("node1", "1.1.1.1", ansibleMasterGroupName, map[string]string{})
+	c.Assert(mgr.discoverLockNames([]string{"1.1.1.1", "2.2.2.2"}), DeepEquals, []string{"node1", "2.2.2.2"})
+
+	// the discovery of a known node's address conflicts with the job on the node
+	j1, err := setActiveJob(mgr, "job1", []string{"node1"})
+	c.Assert(err, IsNil)
+	e := newDiscoverEvent(mgr, []string{"1.1.1.1", "2.2.2.2"}, configuration.DefaultValidJSON, 0)
+	c.Assert(e.process(), Equals, errJobQueued)
+	c.Assert(e._job.nodes, DeepEquals, []string{"node1", "2.2.2.2"})
+	c.Assert(mgr.dequeueJob(e._job.ID()), IsNil)
+	mgr.resetActiveJob(j1)
+}
@@ -0,0 +1,322 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/boltdb"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+)
+
+const (
+	// MaintenanceEnter is the maintenance action to put the nodes in maintenance
+	MaintenanceEnter = "enter"
+	// MaintenanceExit is the maintenance action to take the nodes out of maintenance
+	MaintenanceExit = "exit"
+)
+
+func errInvalidMaintenanceAction(action string) error {
+	return errored.Errorf("invalid or empty maintenance action specified: %q, it should be %q or %q",
+		action, MaintenanceEnter, MaintenanceExit)
+}
+
+func errInvalidMaintenanceExpiry(expiry string) error {
+	return errored.Errorf("invalid maintenance expiry specified: %q, it should be a positive duration like 30m or 4h", expiry)
+}
+
+func errNodeNotInMaintenance(name string) error {
+	return errored.Errorf("node %q is not in maintenance", name)
+}
+
+func errNodeInMaintenance(name string) error {
+	return errored.Errorf("node %q is in maintenance, it needs to exit maintenance before being acted upon", name)
+}
+
+var errEmptyMaintenanceReason = errored.Errorf("a reason should be specified to put the nodes in maintenance")
+
+// MaintenanceInfo is the info of a node that is put in maintenance by the operator
+type MaintenanceInfo struct {
+	Reason    string     `json:"reason"`
+	StartTime time.Time  `json:"start_time"`
+	Expiry    *time.Time `json:"expiry,omitempty"`
+}
+
+// maintenanceEvent puts the nodes in maintenance or takes them out of it. No
+// playbooks are run, only the status of the nodes is changed. The nodes in
+// maintenance are out of service i.e. they are not counted as commissioned
+// nodes and lifecycle operations are not allowed on them, till they exit maintenance.
+type maintenanceEvent struct {
+	mgr       *Manager
+	nodeNames []string
+	action    string
+	reason    string
+	expiry    time.Duration
+
+	_job *Job
+}
+
+// newMaintenanceEvent creates and returns maintenanceEvent
+func newMaintenanceEvent(mgr *Manager, nodeNames []string, action, reason string, expiry time.Duration) *maintenanceEvent {
+	return &maintenanceEvent{
+		mgr:       mgr,
+		nodeNames: nodeNames,
+		action:    action,
+		reason:    reason,
+		expiry:    expiry,
+	}
+}
+
+func (e *maintenanceEvent) String() string {
+	return fmt.Sprintf("maintenanceEvent: nodes: %v action: %s reason: %q expiry: %s",
+		e.nodeNames, e.action, e.reason, e.expiry)
+}
+
+func (e *maintenanceEvent) job() *Job {
+	return e._job
+}
+
+func (e *maintenanceEvent) process() error {
+	// err shouldn't be redefined below
+	var err error
+
+	// the job is created once, the event is processed again if the job is queued
+	if e._job == nil {
+		e._job = e.mgr.newJob(
+			e.String(),
+			e.maintenanceRunner,
+			func(status JobStatus, errRet error) {
+				if status == Errored {
+					logrus.Errorf("maintenance job failed. Error: %v", errRet)
+				}
+			},
+			e.nodeNames)
+	}
+	job := e._job
+	if err = e.mgr.checkAndSetActiveJob(job, e, 0); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			e.mgr.resetActiveJob(job)
+		}
+	}()
+
+	// validate event data
+	if err = e.eventValidate(); err != nil {
+		return err
+	}
+
+	if e.action == MaintenanceEnter {
+		err = e.mgr.enterMaintenance(e.nodeNames, e.reason, e.expiry)
+	} else {
+		err = e.mgr.exitMaintenance(e.nodeNames)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the job just records the change in job history
+	go e.mgr.runActiveJob(job)
+
+	return nil
+}
+
+func (e *maintenanceEvent) eventValidate() error {
+	if len(e.nodeNames) == 0 {
+		return errored.Errorf("atleast one node should be specified")
+	}
+
+	switch e.action {
+	case MaintenanceEnter:
+		if e.reason == "" {
+			return errEmptyMaintenanceReason
+		}
+		if e.expiry < 0 {
+			return errInvalidMaintenanceExpiry(e.expiry.String())
+		}
+		// the nodes need not be discovered, as a node may be put in maintenance
+		// after it has disappeared
+		for _, name := range e.nodeNames {
+			asset := e.mgr.inventory.GetAsset(name)
+			if asset == nil {
+				return nodeInventoryNotExistsError(name)
+			}
+			if status, _ := asset.GetStatus(); status != inventory.Allocated {
+				return errored.Errorf("node %q is in %q status, only the commissioned nodes can be put in maintenance", name, status)
+			}
+		}
+	case MaintenanceExit:
+		for _, name := range e.nodeNames {
+			if _, ok := e.mgr.maintenance[name]; !ok {
+				return errNodeNotInMaintenance(name)
+			}
+		}
+	default:
+		return errInvalidMaintenanceAction(e.action)
+	}
+	return nil
+}
+
+// maintenanceRunner is the job runner that records the nodes that entered or
+// exited maintenance in the job logs
+func (e *maintenanceEvent) maintenanceRunner(cancelCh CancelChannel, jobLogs io.Writer) error {
+	for _, name := range e.nodeNames {
+		if e.action == MaintenanceExit {
+			fmt.Fprintf(jobLogs, "node %q exited maintenance\n", name)
+			continue
+		}
+		expiry := "none"
+		if e.expiry > 0 {
+			expiry = e.expiry.String()
+		}
+		fmt.Fprintf(jobLogs, "node %q entered maintenance. Reason: %s Expiry: %s\n", name, e.reason, expiry)
+	}
+	return nil
+}
+
+// enterMaintenance puts the nodes in maintenance and records the maintenance
+// info. The nodes exit maintenance automatically once the expiry, if any, is reached.
+func (m *Manager) enterMaintenance(names []string, reason string, expiry time.Duration) error {
+	if err := m.setAssetsStatusAtomic(names, m.inventory.SetAssetInMaintenance,
+		m.inventory.SetAssetCommissioned); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, name := range names {
+		info := &MaintenanceInfo{
+			Reason:    reason,
+			StartTime: now,
+		}
+		if expiry > 0 {
+			expiryTime := now.Add(expiry)
+			info.Expiry = &expiryTime
+			m.scheduleMaintenanceExpiry(name, expiryTime)
+		}
+		m.setMaintenance(name, info)
+	}
+	return nil
+}
+
+// exitMaintenance takes the nodes out of maintenance and sets them back as commissioned
+func (m *Manager) exitMaintenance(names []string) error {
+	if err := m.setAssetsStatusAtomic(names, m.inventory.SetAssetCommissioned,
+		m.inventory.SetAssetInMaintenance); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		m.setMaintenance(name, nil)
+	}
+	return nil
+}
+
+// setMaintenance records the maintenance info of a node, a nil info clears it.
+// The info is persisted so that it is restored across restarts of clusterm.
+// Failures to persist are logged and ignored as the node's status in inventory
+// is already updated by then.
+func (m *Manager) setMaintenance(name string, info *MaintenanceInfo) {
+	if info == nil {
+		delete(m.maintenance, name)
+	} else {
+		m.maintenance[name] = info
+	}
+	if node, ok := m.nodes[name]; ok {
+		node.Maint = info
+	}
+
+	if m.db == nil {
+		return
+	}
+	var err error
+	if info == nil {
+		err = m.db.DeleteMaintenance(name)
+	} else {
+		bm := boltdb.Maintenance{
+			Name:      name,
+			Reason:    info.Reason,
+			StartTime: info.StartTime,
+		}
+		if info.Expiry != nil {
+			bm.Expiry = *info.Expiry
+		}
+		err = m.db.SetMaintenance(bm)
+	}
+	if err != nil {
+		logrus.Errorf("failed to save maintenance info of node %q. Error: %v", name, err)
+	}
+}
+
+// restoreMaintenance restores the maintenance info of the nodes that were in
+// maintenance when clusterm stopped
+func (m *Manager) restoreMaintenance() error {
+	if m.db == nil {
+		return nil
+	}
+
+	maint, err := m.db.GetAllMaintenance()
+	if err != nil {
+		return err
+	}
+
+	for _, bm := range maint {
+		info := &MaintenanceInfo{
+			Reason:    bm.Reason,
+			StartTime: bm.StartTime,
+		}
+		if !bm.Expiry.IsZero() {
+			expiry := bm.Expiry
+			info.Expiry = &expiry
+			m.scheduleMaintenanceExpiry(bm.Name, expiry)
+		}
+		m.maintenance[bm.Name] = info
+	}
+	return nil
+}
+
+// scheduleMaintenanceExpiry posts the event to take the node out of maintenance
+// at the expiry. An expiry in the past is posted right away.
+func (m *Manager) scheduleMaintenanceExpiry(name string, expiry time.Time) {
+	time.AfterFunc(expiry.Sub(time.Now()), func() {
+		m.reqQ <- newMaintenanceExpiryEvent(m, name, expiry)
+	})
+}
+
+// maintenanceExpiryEvent takes a node out of maintenance when it's maintenance expires
+type maintenanceExpiryEvent struct {
+	mgr    *Manager
+	name   string
+	expiry time.Time
+}
+
+// newMaintenanceExpiryEvent creates and returns maintenanceExpiryEvent
+func newMaintenanceExpiryEvent(mgr *Manager, name string, expiry time.Time) *maintenanceExpiryEvent {
+	return &maintenanceExpiryEvent{
+		mgr:    mgr,
+		name:   name,
+		expiry: expiry,
+	}
+}
+
+func (e *maintenanceExpiryEvent) String() string {
+	return fmt.Sprintf("maintenanceExpiryEvent: node: %s expiry: %s", e.name, e.expiry)
+}
+
+func (e *maintenanceExpiryEvent) process() error {
+	// the node may have exited the maintenance, or entered it again with a
+	// different expiry, since the expiry was scheduled
+	info, ok := e.mgr.maintenance[e.name]
+	if !ok || info.Expiry == nil || !info.Expiry.Equal(e.expiry) {
+		return nil
+	}
+
+	err := newMaintenanceEvent(e.mgr, []string{e.name}, MaintenanceExit, "", 0).process()
+	if err != nil && err != errJobQueued {
+		logrus.Errorf("failed to exit maintenance of node %q on expiry. Error: %v", e.name, err)
+		return err
+	}
+	return nil
+}
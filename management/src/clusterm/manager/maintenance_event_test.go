@@ -0,0 +1,141 @@
+// +build unittest
+
+package manager
+
+import (
+	"time"
+
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type maintenanceSuite struct {
+}
+
+var _ = Suite(&maintenanceSuite{})
+
+var maintenanceTestAssets = map[string]inventory.AssetStatus{
+	"node1": inventory.Allocated,
+	"node2": inventory.Allocated,
+	"node3": inventory.Unallocated,
+}
+
+func (s *maintenanceSuite) TestMaintenanceEnterExit(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newTestManager(c, ctrl, maintenanceTestAssets, inventory.Disappeared)
+	e := newMaintenanceEvent(mgr, []string{"node1", "node2"}, MaintenanceEnter, "disk replacement", time.Hour)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	for _, name := range []string{"node1", "node2"} {
+		c.Assert(assetStatus(mgr, name), Equals, inventory.Maintenance)
+		info, ok := mgr.maintenance[name]
+		c.Assert(ok, Equals, true)
+		c.Assert(info.Reason, Equals, "disk replacement")
+		c.Assert(info.Expiry, NotNil)
+		c.Assert(info.Expiry.Sub(info.StartTime), Equals, time.Hour)
+	}
+	// the nodes in maintenance are not reconciled as stuck
+	c.Assert(mgr.stuckAssets(), HasLen, 0)
+
+	e = newMaintenanceEvent(mgr, []string{"node1"}, MaintenanceExit, "", 0)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated)
+	c.Assert(assetStatus(mgr, "node2"), Equals, inventory.Maintenance)
+	c.Assert(mgr.maintenance, HasLen, 1)
+}
+
+func (s *maintenanceSuite) TestMaintenanceValidateError(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newTestManager(c, ctrl, maintenanceTestAssets, inventory.Disappeared)
+	tests := map[string]struct {
+		e        *maintenanceEvent
+		exptdErr error
+	}{
+		"invalid-action": {
+			e:        newMaintenanceEvent(mgr, []string{"node1"}, "foo", "", 0),
+			exptdErr: errInvalidMaintenanceAction("foo"),
+		},
+		"empty-reason": {
+			e:        newMaintenanceEvent(mgr, []string{"node1"}, MaintenanceEnter, "", 0),
+			exptdErr: errEmptyMaintenanceReason,
+		},
+		"non-existent-node": {
+			e:        newMaintenanceEvent(mgr, []string{"node4"}, MaintenanceEnter, "foo", 0),
+			exptdErr: nodeInventoryNotExistsError("node4"),
+		},
+		"uncommissioned-node": {
+			e: newMaintenanceEvent(mgr, []string{"node3"}, MaintenanceEnter, "foo", 0),
+			exptdErr: errored.Errorf("node %q is in %q status, only the commissioned nodes can be put in maintenance",
+				"node3", inventory.Unallocated),
+		},
+		"not-in-maintenance": {
+			e:        newMaintenanceEvent(mgr, []string{"node1"}, MaintenanceExit, "", 0),
+			exptdErr: errNodeNotInMaintenance("node1"),
+		},
+	}
+	for testname, test := range tests {
+		err := test.e.process()
+		c.Assert(err, NotNil, Commentf("test: %s", testname))
+		c.Assert(err.Error(), Equals, test.exptdErr.Error(), Commentf("test: %s", testname))
+		c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated, Commentf("test: %s", testname))
+	}
+}
+
+func (s *maintenanceSuite) TestMaintenanceExpiry(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newTestManager(c, ctrl, maintenanceTestAssets, inventory.Disappeared)
+	c.Assert(mgr.enterMaintenance([]string{"node1"}, "foo", time.Hour), IsNil)
+	expiry := *mgr.maintenance["node1"].Expiry
+
+	// a stale expiry, like one scheduled before the node re-entered maintenance, is ignored
+	c.Assert(newMaintenanceExpiryEvent(mgr, "node1", expiry.Add(-time.Minute)).process(), IsNil)
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Maintenance)
+
+	c.Assert(newMaintenanceExpiryEvent(mgr, "node1", expiry).process(), IsNil)
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated)
+	c.Assert(mgr.maintenance, HasLen, 0)
+}
+
+func (s *maintenanceSuite) TestMaintenanceRejectsLifecycleEvents(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newVerifyTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+		"node2": inventory.Unallocated,
+	}, nil, nil)
+	mgr.maintenance = map[string]*MaintenanceInfo{}
+	e := newMaintenanceEvent(mgr, []string{"node1"}, MaintenanceEnter, "disk replacement", 0)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	mgr.resetActiveJob(e._job)
+
+	// the node in maintenance is rejected by the common as well as the forced validation
+	tests := map[string]event{
+		"commission":         newCommissionEvent(mgr, []string{"node1"}, "", ansibleMasterGroupName, 0),
+		"decommission":       newDecommissionEvent(mgr, []string{"node1"}, "", 0, false),
+		"decommission-all":   newDecommissionEvent(mgr, []string{"node1", "node2"}, "", 0, false),
+		"force-decommission": newDecommissionEvent(mgr, []string{"node1"}, "", 0, true),
+		"update":             newUpdateEvent(mgr, []string{"node1"}, "", "", 0, false),
+		"force-update":       newUpdateEvent(mgr, []string{"node1"}, "", "", 0, true),
+		"upgrade":            newUpgradeEvent(mgr, []string{"node1"}, "", 1, 0, 0),
+		"replace":            newReplaceEvent(mgr, "node1", "node2", "", 0),
+	}
+	for testname, e := range tests {
+		err := e.process()
+		c.Assert(err, NotNil, Commentf("test: %s", testname))
+		c.Assert(err.Error(), Equals, errNodeInMaintenance("node1").Error(), Commentf("test: %s", testname))
+		c.Assert(mgr.getActiveJobs(), HasLen, 0, Commentf("test: %s", testname))
+		c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Maintenance, Commentf("test: %s", testname))
+	}
+}
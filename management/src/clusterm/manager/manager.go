@@ -12,6 +12,10 @@
 package manager
 
 import (
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
 	"github.com/contiv/cluster/management/src/boltdb"
 	"github.com/contiv/cluster/management/src/configuration"
 	"github.com/contiv/cluster/management/src/inventory"
@@ -29,6 +33,8 @@ type node struct {
 	Mon monitor.SubsysNode       `json:"monitoring_state"`
 	Inv inventory.SubsysAsset    `json:"inventory_state"`
 	Cfg configuration.SubsysHost `json:"configuration_state"`
+	// Maint is set when the node is put in maintenance by the operator
+	Maint *MaintenanceInfo `json:"maintenance_state,omitempty"`
 }
 
 // Manager integrates the cluster infra services like node discovery, inventory
@@ -40,10 +46,34 @@ type Manager struct {
 	reqQ          chan event
 	addr          string
 	nodes         map[string]*node
-	activeJob     *Job // there can be only one active job at a time
+	locks         nodeLocks  // per-node locks, jobs on disjoint set of nodes can run concurrently
+	jobsMutex     sync.Mutex // protects the activeJobs, lastJob and lastJobID
+	activeJobs    []*Job
+	queue         jobQueue // jobs waiting for the nodes locked by the active jobs
 	lastJob       *Job
+	lastJobID     uint64
+	db            *boltdb.Client // boltdb for clusterm's persistent state like the job history
 	config        *Config
 	configFile    string // file containing clusterm config, when clusterm is started with a config file
+	// assets whose interrupted job is re-run once they are discovered, as per the reconcile policy
+	interrupted map[string]inventory.AssetStatus
+	// the jobs that were interrupted when clusterm stopped, as per the job
+	// history, keyed by the names of their nodes
+	interruptedJobs map[string]boltdb.Job
+	// nodes put in maintenance by the operator
+	maintenance map[string]*MaintenanceInfo
+	// auto-commission policy, nil when it is not enabled
+	autoCommission *autoCommissioner
+	// commissioned nodes that have disappeared and are due for remediation,
+	// along with the time they disappeared
+	disappeared map[string]time.Time
+	// desired state of the cluster applied by the operator, nil when none is applied
+	spec *ClusterSpec
+	// the last job started to converge the cluster to the spec
+	specJob *Job
+	// the event being processed by the event loop, protected by the eventMutex
+	eventMutex   sync.Mutex
+	currentEvent event
 }
 
 // NewManager initializes and returns an instance of the Manager. It returns nil
@@ -60,30 +90,76 @@ func NewManager(config *Config, configFile string) (*Manager, error) {
 		return nil, err
 	}
 
+	if !isValidReconcilePolicy(config.Manager.ReconcilePolicy) {
+		return nil, errInvalidReconcilePolicy(config.Manager.ReconcilePolicy)
+	}
+
 	m := &Manager{
-		monitor:       monitor.NewSerfSubsys(&config.Serf),
-		configuration: configuration.NewAnsibleSubsys(&config.Ansible),
-		reqQ:          make(chan event, 100),
-		addr:          config.Manager.Addr,
-		nodes:         make(map[string]*node),
-		config:        config,
-		configFile:    configFile,
-	}
-	// We give priority to boltdb inventory if both are set in config
-	if config.Inventory.BoltDB != nil {
-		if m.inventory, err = boltdbinv.NewBoltdbSubsys(*config.Inventory.BoltDB); err != nil {
+		monitor:         monitor.NewSerfSubsys(&config.Serf),
+		configuration:   configuration.NewAnsibleSubsys(&config.Ansible),
+		reqQ:            make(chan event, 100),
+		addr:            config.Manager.Addr,
+		nodes:           make(map[string]*node),
+		interrupted:     make(map[string]inventory.AssetStatus),
+		interruptedJobs: make(map[string]boltdb.Job),
+		maintenance:     make(map[string]*MaintenanceInfo),
+		disappeared:     make(map[string]time.Time),
+		config:          config,
+		configFile:      configFile,
+	}
+	if err := validateHostGroups(config.Manager.HostGroups); err != nil {
+		return nil, err
+	}
+	if err := validateRemediationPolicies(config.Manager.Remediation, config.Manager.HostGroups); err != nil {
+		return nil, err
+	}
+	if m.autoCommission, err = newAutoCommissioner(config.Manager.AutoCommission, config.Manager.HostGroups); err != nil {
+		return nil, err
+	}
+	if d, err := time.ParseDuration(config.Manager.SpecReconcileInterval); err != nil || d < 0 {
+		return nil, errored.Errorf("invalid spec reconcile interval specified: %q", config.Manager.SpecReconcileInterval)
+	}
+	if err := validateJobHistoryConfig(config.Manager.JobHistory); err != nil {
+		return nil, err
+	}
+
+	invDBConfig := config.inventoryDBConfig()
+	histDBConfig := config.jobHistoryDBConfig()
+	if histDBConfig != nil {
+		if m.db, err = boltdb.NewClientFromConfig(*histDBConfig); err != nil {
 			return nil, err
 		}
-	} else if config.Inventory.Collins != nil {
+	} else {
+		logrus.Warnf("no boltdb is configured for the job history, the job history, maintenance info and applied spec won't be kept across restarts")
+	}
+	if err := m.restoreJobHistory(); err != nil {
+		return nil, errored.Errorf("failed to restore job history. Error: %s", err)
+	}
+	m.pruneJobHistory()
+	if err := m.restoreMaintenance(); err != nil {
+		return nil, errored.Errorf("failed to restore maintenance info. Error: %s", err)
+	}
+	if err := m.restoreSpec(); err != nil {
+		return nil, errored.Errorf("failed to restore the applied spec. Error: %s", err)
+	}
+
+	if invDBConfig == nil {
 		if m.inventory, err = collinsinv.NewCollinsSubsys(*config.Inventory.Collins); err != nil {
 			return nil, err
 		}
 	} else {
-		// if no inventory config was provided then we default to boltDb
-		if m.inventory, err = boltdbinv.NewBoltdbSubsys(boltdb.DefaultConfig()); err != nil {
+		// the boltdb is shared with the job history, when it's the same file
+		invDB := m.db
+		if invDB == nil || histDBConfig.DBFile != invDBConfig.DBFile {
+			if invDB, err = boltdb.NewClientFromConfig(*invDBConfig); err != nil {
+				return nil, err
+			}
+		}
+		if m.inventory, err = boltdbinv.NewBoltdbSubsysFromClient(invDB); err != nil {
 			return nil, err
 		}
 	}
+	m.inventory.SetTransitionCauser(m.transitionCause)
 
 	if err := m.monitor.RegisterCb(monitor.Discovered, m.enqueueMonitorEvent); err != nil {
 		return nil, errored.Errorf("failed to register node discovery callback. Error: %s", err)
@@ -101,6 +177,15 @@ func (m *Manager) Run() error {
 
 	eg, _ := errgroup.WithContext(context.Background())
 
+	// reconcile the assets left in a transitional status by the jobs that were
+	// interrupted when clusterm stopped. It is queued ahead of the monitor
+	// events, so that the assets are reconciled before the nodes are discovered.
+	m.reqQ <- newReconcileEvent(m, m.config.Manager.ReconcilePolicy)
+
+	// reschedule the remediation of the nodes that had disappeared when clusterm
+	// stopped. It is done before the event loop starts, which owns the nodes.
+	m.restoreRemediations()
+
 	// start http server for servicing REST api endpoints. It feeds api/ux events.
 	apiServingCh := make(chan struct{}, 1)
 	eg.Go(func() error { return m.apiLoop(apiServingCh) })
@@ -119,6 +204,11 @@ func (m *Manager) Run() error {
 			return nil
 		})
 
+	// start the periodic reconcile of the cluster to the applied spec, if enabled
+	if interval, _ := time.ParseDuration(m.config.Manager.SpecReconcileInterval); interval > 0 {
+		go m.specReconcileLoop(interval)
+	}
+
 	// start the event loop. It processes the events.
 	eg.Go(
 		func() error {
@@ -0,0 +1,288 @@
+package manager
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/contiv/cluster/management/src/inventory"
+)
+
+// the fields of a node's info, as returned by the REST interface
+var nodeFields = []string{
+	"monitoring_state",
+	"inventory_state",
+	"configuration_state",
+	"maintenance_state",
+}
+
+// the keys that the nodes can be sorted by
+const (
+	nodesSortName         = "name"
+	nodesSortStatus       = "status"
+	nodesSortState        = "state"
+	nodesSortHostGroup    = "host_group"
+	nodesSortMonitorLabel = "monitor_label"
+	nodesSortAddr         = "addr"
+)
+
+// NodesQuery specifies the criteria to select, sort and paginate the nodes
+// returned by the REST interface. The empty criteria match all the nodes.
+type NodesQuery struct {
+	// Status and State are the inventory status and state of the nodes, like
+	// Allocated and Discovered
+	Status string
+	State  string
+	// HostGroup is the host-group of the nodes in the configuration
+	HostGroup string
+	// MonitorLabel and Addr are the label and management address of the nodes
+	// in the monitoring system
+	MonitorLabel string
+	Addr         string
+	// Selector is a label selector, like `rack=r1,owner!=team1`
+	Selector string
+	// Sort is the key that the nodes are sorted by, prefixed with '-' for
+	// descending order. The nodes are sorted by name by default.
+	Sort string
+	// Fields are the fields of the node's info to return, all by default.
+	// The node's name is always returned.
+	Fields []string
+	// Offset and Limit are used for pagination, a Limit of 0 returns all the
+	// nodes after the offset
+	Offset int
+	Limit  int
+}
+
+// NodesInfo is a page of nodes as returned by the REST interface. The fields
+// of a node's info are kept as pointers, as json.RawMessage values are marshalled
+// as raw json only by their pointer before go 1.8.
+type NodesInfo struct {
+	Total int                           `json:"total"`
+	Nodes []map[string]*json.RawMessage `json:"nodes"`
+}
+
+// values returns the query parameters of the nodes query
+func (q NodesQuery) values() url.Values {
+	query := url.Values{}
+	for name, val := range map[string]string{
+		nodesQueryStatus:       q.Status,
+		nodesQueryState:        q.State,
+		nodesQueryHostGroup:    q.HostGroup,
+		nodesQueryMonitorLabel: q.MonitorLabel,
+		nodesQueryAddr:         q.Addr,
+		nodesQuerySelector:     q.Selector,
+		nodesQuerySort:         q.Sort,
+		nodesQueryFields:       strings.Join(q.Fields, ","),
+	} {
+		if val != "" {
+			query.Set(name, val)
+		}
+	}
+	if q.Offset != 0 {
+		query.Set(nodesQueryOffset, strconv.Itoa(q.Offset))
+	}
+	if q.Limit != 0 {
+		query.Set(nodesQueryLimit, strconv.Itoa(q.Limit))
+	}
+	return query
+}
+
+// parseNodesQuery returns the nodes query specified by the query parameters
+func parseNodesQuery(query url.Values) (NodesQuery, error) {
+	var err error
+	q := NodesQuery{
+		Status:       query.Get(nodesQueryStatus),
+		State:        query.Get(nodesQueryState),
+		HostGroup:    query.Get(nodesQueryHostGroup),
+		MonitorLabel: query.Get(nodesQueryMonitorLabel),
+		Addr:         query.Get(nodesQueryAddr),
+		Selector:     query.Get(nodesQuerySelector),
+		Sort:         query.Get(nodesQuerySort),
+	}
+	if val := query.Get(nodesQueryFields); val != "" {
+		for _, f := range strings.Split(val, ",") {
+			q.Fields = append(q.Fields, strings.TrimSpace(f))
+		}
+	}
+	if q.Offset, err = parseQueryInt(query, nodesQueryOffset, 0); err != nil {
+		return q, err
+	}
+	if q.Limit, err = parseQueryInt(query, nodesQueryLimit, 0); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// nodeSortValue returns the value of a node for the sort key
+func nodeSortValue(name string, n *node, key string) string {
+	switch key {
+	case nodesSortStatus, nodesSortState:
+		if n.Inv == nil {
+			return ""
+		}
+		status, state := n.Inv.GetStatus()
+		if key == nodesSortStatus {
+			return status.String()
+		}
+		return state.String()
+	case nodesSortHostGroup:
+		if n.Cfg == nil {
+			return ""
+		}
+		return n.Cfg.GetGroup()
+	case nodesSortMonitorLabel:
+		if n.Mon == nil {
+			return ""
+		}
+		return n.Mon.GetLabel()
+	case nodesSortAddr:
+		if n.Mon == nil {
+			return ""
+		}
+		return n.Mon.GetMgmtAddress()
+	default:
+		return name
+	}
+}
+
+// nodesSorter sorts the node names by their values for a sort key
+type nodesSorter struct {
+	names  []string
+	values []string
+	desc   bool
+}
+
+func (s *nodesSorter) Len() int {
+	return len(s.names)
+}
+
+func (s *nodesSorter) Less(i, j int) bool {
+	if s.desc {
+		return s.values[i] > s.values[j]
+	}
+	return s.values[i] < s.values[j]
+}
+
+func (s *nodesSorter) Swap(i, j int) {
+	s.names[i], s.names[j] = s.names[j], s.names[i]
+	s.values[i], s.values[j] = s.values[j], s.values[i]
+}
+
+// validate validates the criteria of the nodes query
+func (q NodesQuery) validate() error {
+	if _, ok := inventory.AssetStatusVals[q.Status]; q.Status != "" && !ok {
+		return errInvalidQueryValue(nodesQueryStatus, q.Status)
+	}
+	if _, ok := inventory.AssetStateVals[strings.ToUpper(q.State)]; q.State != "" && !ok {
+		return errInvalidQueryValue(nodesQueryState, q.State)
+	}
+	switch strings.TrimPrefix(q.Sort, "-") {
+	case "", nodesSortName, nodesSortStatus, nodesSortState, nodesSortHostGroup,
+		nodesSortMonitorLabel, nodesSortAddr:
+	default:
+		return errInvalidQueryValue(nodesQuerySort, q.Sort)
+	}
+	for _, f := range q.Fields {
+		if !containsString(nodeFields, f) {
+			return errInvalidQueryValue(nodesQueryFields, strings.Join(q.Fields, ","))
+		}
+	}
+	return nil
+}
+
+// getNodes returns a page of the nodes that match the query, sorted as per the query
+func (m *Manager) getNodes(q NodesQuery) (*NodesInfo, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	var reqs []labelRequirement
+	if q.Selector != "" {
+		var err error
+		if reqs, err = parseSelector(q.Selector); err != nil {
+			return nil, err
+		}
+	}
+
+	names := []string{}
+	for _, name := range m.sortedNodeNames() {
+		n := m.nodes[name]
+		if q.Status != "" && nodeSortValue(name, n, nodesSortStatus) != q.Status {
+			continue
+		}
+		if q.State != "" && !strings.EqualFold(nodeSortValue(name, n, nodesSortState), q.State) {
+			continue
+		}
+		if q.HostGroup != "" && nodeSortValue(name, n, nodesSortHostGroup) != q.HostGroup {
+			continue
+		}
+		if q.MonitorLabel != "" && nodeSortValue(name, n, nodesSortMonitorLabel) != q.MonitorLabel {
+			continue
+		}
+		if q.Addr != "" && nodeSortValue(name, n, nodesSortAddr) != q.Addr {
+			continue
+		}
+		if reqs != nil && (n.Inv == nil || !matchesSelector(reqs, n.Inv.GetAttributes())) {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	// the nodes are sorted by name already, the stable sort keeps them sorted
+	// by name for the nodes with equal values
+	key := strings.TrimPrefix(q.Sort, "-")
+	sorter := &nodesSorter{names: names, desc: strings.HasPrefix(q.Sort, "-")}
+	for _, name := range names {
+		sorter.values = append(sorter.values, nodeSortValue(name, m.nodes[name], key))
+	}
+	sort.Stable(sorter)
+
+	page := &NodesInfo{
+		Total: len(names),
+		Nodes: []map[string]*json.RawMessage{},
+	}
+	if q.Offset < len(names) {
+		names = names[q.Offset:]
+		if q.Limit > 0 && q.Limit < len(names) {
+			names = names[:q.Limit]
+		}
+	} else {
+		names = nil
+	}
+	for _, name := range names {
+		info, err := nodeFieldsInfo(name, m.nodes[name], q.Fields)
+		if err != nil {
+			return nil, err
+		}
+		page.Nodes = append(page.Nodes, info)
+	}
+	return page, nil
+}
+
+// nodeFieldsInfo returns the specified fields of a node's info, along with
+// it's name. All the fields are returned if none are specified.
+func nodeFieldsInfo(name string, n *node, fields []string) (map[string]*json.RawMessage, error) {
+	out, err := json.Marshal(n)
+	if err != nil {
+		return nil, err
+	}
+	info := map[string]*json.RawMessage{}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		for f := range info {
+			if !containsString(fields, f) {
+				delete(info, f)
+			}
+		}
+	}
+	out, err = json.Marshal(name)
+	if err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(out)
+	info["name"] = &raw
+	return info, nil
+}
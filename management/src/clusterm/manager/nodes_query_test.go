@@ -0,0 +1,211 @@
+// +build unittest
+
+package manager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/cluster/management/src/monitor"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type nodesQuerySuite struct {
+}
+
+var _ = Suite(&nodesQuerySuite{})
+
+// newNodesQueryTestManager returns a manager with three commissioned nodes,
+// where node1 and node2 are in rack r1 and node3 is a worker in rack r2 whose
+// inventory state is disappeared
+func newNodesQueryTestManager(c *C, ctrl *gomock.Controller) *Manager {
+	mgr := newTestManager(c, ctrl, labelsTestAssets, inventory.Discovered)
+	setTestLabels(c, mgr)
+	for name, addr := range map[string]string{"node1": "1.1.1.3", "node2": "1.1.1.2", "node3": "1.1.1.1"} {
+		mgr.nodes[name].Mon = monitor.NewNode(name+"-host", "serial-"+name, addr)
+	}
+	mgr.nodes["node3"].Cfg = configuration.NewAnsibleHost("node3", "1.1.1.1", ansibleWorkerGroupName, map[string]string{})
+	c.Assert(mgr.inventory.SetAssetDisappeared("node3"), IsNil)
+	return mgr
+}
+
+func pageNodeNames(c *C, page *NodesInfo) []string {
+	names := []string{}
+	for _, n := range page.Nodes {
+		var name string
+		c.Assert(json.Unmarshal(*n["name"], &name), IsNil)
+		names = append(names, name)
+	}
+	return names
+}
+
+func (s *nodesQuerySuite) TestGetNodes(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newNodesQueryTestManager(c, ctrl)
+	tests := map[string]struct {
+		q          NodesQuery
+		exptdTotal int
+		exptdNames []string
+	}{
+		"all": {
+			exptdTotal: 3,
+			exptdNames: []string{"node1", "node2", "node3"},
+		},
+		"status": {
+			q:          NodesQuery{Status: inventory.Allocated.String()},
+			exptdTotal: 3,
+			exptdNames: []string{"node1", "node2", "node3"},
+		},
+		"state": {
+			q:          NodesQuery{State: "disappeared"},
+			exptdTotal: 1,
+			exptdNames: []string{"node3"},
+		},
+		"host-group": {
+			q:          NodesQuery{HostGroup: ansibleMasterGroupName},
+			exptdTotal: 2,
+			exptdNames: []string{"node1", "node2"},
+		},
+		"monitor-label-and-addr": {
+			q:          NodesQuery{MonitorLabel: "node2-host", Addr: "1.1.1.2"},
+			exptdTotal: 1,
+			exptdNames: []string{"node2"},
+		},
+		"selector": {
+			q:          NodesQuery{Selector: "rack=r1"},
+			exptdTotal: 2,
+			exptdNames: []string{"node1", "node2"},
+		},
+		"sort-addr": {
+			q:          NodesQuery{Sort: "addr"},
+			exptdTotal: 3,
+			exptdNames: []string{"node3", "node2", "node1"},
+		},
+		"sort-group-desc": {
+			q:          NodesQuery{Sort: "-host_group"},
+			exptdTotal: 3,
+			exptdNames: []string{"node3", "node1", "node2"},
+		},
+		"paginated": {
+			q:          NodesQuery{Offset: 1, Limit: 1},
+			exptdTotal: 3,
+			exptdNames: []string{"node2"},
+		},
+		"offset-past-end": {
+			q:          NodesQuery{Offset: 3},
+			exptdTotal: 3,
+			exptdNames: []string{},
+		},
+		"no-match": {
+			q:          NodesQuery{Status: inventory.Decommissioned.String()},
+			exptdTotal: 0,
+			exptdNames: []string{},
+		},
+	}
+	for testname, test := range tests {
+		page, err := mgr.getNodes(test.q)
+		c.Assert(err, IsNil, Commentf("test: %s", testname))
+		c.Assert(page.Total, Equals, test.exptdTotal, Commentf("test: %s", testname))
+		c.Assert(pageNodeNames(c, page), DeepEquals, test.exptdNames, Commentf("test: %s", testname))
+	}
+}
+
+func (s *nodesQuerySuite) TestGetNodesFields(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newNodesQueryTestManager(c, ctrl)
+	page, err := mgr.getNodes(NodesQuery{Fields: []string{"monitoring_state"}, Limit: 1})
+	c.Assert(err, IsNil)
+	c.Assert(page.Nodes, HasLen, 1)
+	c.Assert(sortedRawKeys(page.Nodes[0]), DeepEquals, []string{"monitoring_state", "name"})
+
+	page, err = mgr.getNodes(NodesQuery{Limit: 1})
+	c.Assert(err, IsNil)
+	c.Assert(sortedRawKeys(page.Nodes[0]), DeepEquals,
+		[]string{"configuration_state", "inventory_state", "monitoring_state", "name"})
+}
+
+func sortedRawKeys(m map[string]*json.RawMessage) []string {
+	keys := map[string]string{}
+	for k := range m {
+		keys[k] = ""
+	}
+	return sortedKeys(keys)
+}
+
+func (s *nodesQuerySuite) TestGetNodesInvalidQuery(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newNodesQueryTestManager(c, ctrl)
+	tests := map[string]NodesQuery{
+		"status":   {Status: "Foo"},
+		"state":    {State: "Foo"},
+		"sort":     {Sort: "foo"},
+		"fields":   {Fields: []string{"inventory_state", "foo"}},
+		"selector": {Selector: "rack"},
+	}
+	for testname, q := range tests {
+		_, err := mgr.getNodes(q)
+		c.Assert(err, ErrorMatches, "(Invalid value specified for query parameter|invalid label selector).*",
+			Commentf("test: %s", testname))
+	}
+
+	_, err := parseNodesQuery(url.Values{nodesQueryLimit: []string{"-1"}})
+	c.Assert(err, ErrorMatches, "Invalid value specified for query parameter.*")
+	q, err := parseNodesQuery(url.Values{
+		nodesQueryStatus: []string{"Allocated"},
+		nodesQueryFields: []string{"inventory_state, monitoring_state"},
+		nodesQueryOffset: []string{"2"},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(q, DeepEquals, NodesQuery{
+		Status: "Allocated",
+		Fields: []string{"inventory_state", "monitoring_state"},
+		Offset: 2,
+	})
+}
+
+func (s *nodesQuerySuite) TestNodesGet(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newNodesQueryTestManager(c, ctrl)
+
+	// the nodes are returned keyed by name, the query parameters are not used
+	out, err := mgr.nodesGet(&APIRequest{Query: url.Values{nodesQueryLimit: []string{"2"}}})
+	c.Assert(err, IsNil)
+	body, err := ioutil.ReadAll(out)
+	c.Assert(err, IsNil)
+	nodes := map[string]struct {
+		Inv map[string]interface{} `json:"inventory_state"`
+	}{}
+	c.Assert(json.Unmarshal(body, &nodes), IsNil)
+	c.Assert(nodes, HasLen, 3)
+	c.Assert(nodes["node1"].Inv["name"], Equals, "node1")
+
+	// the nodes are returned as a page by the query endpoint, with the node's info as json
+	out, err = mgr.nodesQueryGet(&APIRequest{Query: url.Values{nodesQueryLimit: []string{"2"}}})
+	c.Assert(err, IsNil)
+	body, err = ioutil.ReadAll(out)
+	c.Assert(err, IsNil)
+	page := struct {
+		Total int `json:"total"`
+		Nodes []struct {
+			Name string                 `json:"name"`
+			Inv  map[string]interface{} `json:"inventory_state"`
+		} `json:"nodes"`
+	}{}
+	c.Assert(json.Unmarshal(body, &page), IsNil)
+	c.Assert(page.Total, Equals, 3)
+	c.Assert(page.Nodes, HasLen, 2)
+	c.Assert(page.Nodes[1].Name, Equals, "node2")
+	c.Assert(page.Nodes[1].Inv["name"], Equals, "node2")
+}
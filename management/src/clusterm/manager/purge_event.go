@@ -0,0 +1,155 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+)
+
+// purgeEvent removes the nodes from clusterm and the inventory, so that the
+// decommissioned or permanently dead nodes don't pile up and their names can
+// be reused. Only the decommissioned nodes are purged, unless the purge is
+// forced. No playbooks are run on the nodes.
+type purgeEvent struct {
+	mgr       *Manager
+	nodeNames []string
+	force     bool
+
+	_job    *Job
+	_purged []string
+}
+
+// newPurgeEvent creates and returns purgeEvent
+func newPurgeEvent(mgr *Manager, nodeNames []string, force bool) *purgeEvent {
+	return &purgeEvent{
+		mgr:       mgr,
+		nodeNames: nodeNames,
+		force:     force,
+	}
+}
+
+func (e *purgeEvent) String() string {
+	return fmt.Sprintf("purgeEvent: nodes: %v force: %v", e.nodeNames, e.force)
+}
+
+func (e *purgeEvent) job() *Job {
+	return e._job
+}
+
+func (e *purgeEvent) process() error {
+	// err shouldn't be redefined below
+	var err error
+
+	// the job is created once, the event is processed again if the job is queued
+	if e._job == nil {
+		e._job = e.mgr.newJob(
+			e.String(),
+			e.purgeRunner,
+			func(status JobStatus, errRet error) {
+				if status == Errored {
+					logrus.Errorf("purge job failed. Error: %v", errRet)
+				}
+			},
+			e.nodeNames)
+	}
+	job := e._job
+	if err = e.mgr.checkAndSetActiveJob(job, e, 0); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			e.mgr.resetActiveJob(job)
+		}
+	}()
+
+	// validate event data
+	if err = e.eventValidate(); err != nil {
+		return err
+	}
+
+	// the nodes are purged as part of event processing, as the manager's view of
+	// the nodes is only changed by the event loop. The job records the purge in
+	// the job history.
+	for _, name := range e.nodeNames {
+		if err = e.mgr.purgeNode(name); err != nil {
+			break
+		}
+		e._purged = append(e._purged, name)
+	}
+	if err != nil && len(e._purged) == 0 {
+		return err
+	}
+	job.setResults(purgeResults(e.nodeNames, e._purged))
+	if err != nil {
+		// some nodes were purged already, so the job is still recorded
+		logrus.Errorf("failed to purge nodes %v. Error: %v", e.nodeNames, err)
+		err = nil
+	}
+
+	go e.mgr.runActiveJob(job)
+
+	return nil
+}
+
+func (e *purgeEvent) eventValidate() error {
+	if len(e.nodeNames) == 0 {
+		return errored.Errorf("atleast one node should be specified")
+	}
+
+	// the nodes need not be discovered, as a dead node may be purged
+	for _, name := range e.nodeNames {
+		asset := e.mgr.inventory.GetAsset(name)
+		if asset == nil {
+			return nodeInventoryNotExistsError(name)
+		}
+		if e.force {
+			continue
+		}
+		if status, _ := asset.GetStatus(); status != inventory.Decommissioned {
+			return errored.Errorf("node %q is in %q status, only the decommissioned nodes can be purged unless forced",
+				name, status)
+		}
+	}
+	return nil
+}
+
+// purgeRunner is the job runner that records the purged nodes in the job logs
+func (e *purgeEvent) purgeRunner(cancelCh CancelChannel, jobLogs io.Writer) error {
+	for _, name := range e._purged {
+		fmt.Fprintf(jobLogs, "node %q was purged\n", name)
+	}
+	if len(e._purged) < len(e.nodeNames) {
+		return errored.Errorf("only nodes %v of %v were purged, check clusterm logs for details",
+			e._purged, e.nodeNames)
+	}
+	return nil
+}
+
+// purgeResults returns the per node results of a purge
+func purgeResults(nodeNames, purged []string) map[string]configuration.HostResult {
+	results := uniformResults(nodeNames, configuration.HostResultFailed)
+	for _, name := range purged {
+		results[name] = configuration.HostResultOK
+	}
+	return results
+}
+
+// purgeNode deletes the node from the inventory and forgets all the state kept
+// for it. A purged node that is still alive is added back as a new node when
+// it is discovered again.
+func (m *Manager) purgeNode(name string) error {
+	if err := m.inventory.DeleteAsset(name); err != nil {
+		return err
+	}
+
+	if _, ok := m.maintenance[name]; ok {
+		m.setMaintenance(name, nil)
+	}
+	delete(m.disappeared, name)
+	delete(m.nodes, name)
+	return nil
+}
@@ -0,0 +1,85 @@
+// +build unittest
+
+package manager
+
+import (
+	"time"
+
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type purgeSuite struct {
+}
+
+var _ = Suite(&purgeSuite{})
+
+var purgeTestAssets = map[string]inventory.AssetStatus{
+	"node1": inventory.Decommissioned,
+	"node2": inventory.Allocated,
+}
+
+func (s *purgeSuite) TestPurgeDecommissioned(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newTestManager(c, ctrl, purgeTestAssets, inventory.Disappeared)
+	e := newPurgeEvent(mgr, []string{"node1"}, false)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(mgr.inventory.GetAsset("node1"), IsNil)
+	_, err := mgr.findNode("node1")
+	c.Assert(err, NotNil)
+	c.Assert(mgr.nodes, HasLen, 1)
+}
+
+func (s *purgeSuite) TestPurgeForced(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newTestManager(c, ctrl, purgeTestAssets, inventory.Disappeared)
+	mgr.disappeared["node2"] = time.Now()
+	e := newPurgeEvent(mgr, []string{"node2"}, true)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(mgr.inventory.GetAsset("node2"), IsNil)
+	// the pending remediation of the node is forgotten as well
+	c.Assert(mgr.disappeared, HasLen, 0)
+}
+
+func (s *purgeSuite) TestPurgeValidateError(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newTestManager(c, ctrl, purgeTestAssets, inventory.Disappeared)
+	tests := map[string]struct {
+		e        *purgeEvent
+		exptdErr error
+	}{
+		"no-nodes": {
+			e:        newPurgeEvent(mgr, []string{}, false),
+			exptdErr: errored.Errorf("atleast one node should be specified"),
+		},
+		"non-existent-node": {
+			e:        newPurgeEvent(mgr, []string{"node3"}, true),
+			exptdErr: nodeInventoryNotExistsError("node3"),
+		},
+		"not-decommissioned-node": {
+			e: newPurgeEvent(mgr, []string{"node1", "node2"}, false),
+			exptdErr: errored.Errorf("node %q is in %q status, only the decommissioned nodes can be purged unless forced",
+				"node2", inventory.Allocated),
+		},
+	}
+	for testname, test := range tests {
+		err := test.e.process()
+		c.Assert(err, NotNil, Commentf("test: %s", testname))
+		c.Assert(err.Error(), Equals, test.exptdErr.Error(), Commentf("test: %s", testname))
+	}
+	// nothing is purged on a validation failure
+	c.Assert(mgr.nodes, HasLen, 2)
+	c.Assert(mgr.inventory.GetAsset("node1"), NotNil)
+}
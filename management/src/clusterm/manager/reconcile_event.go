@@ -0,0 +1,290 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/boltdb"
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+)
+
+func errInvalidReconcilePolicy(policy string) error {
+	return errored.Errorf("invalid reconcile policy specified: %q, it should be one of %q, %q or %q",
+		policy, ReconcileRollback, ReconcileRetry, ReconcileFlag)
+}
+
+func isValidReconcilePolicy(policy string) bool {
+	switch policy {
+	case ReconcileRollback, ReconcileRetry, ReconcileFlag:
+		return true
+	}
+	return false
+}
+
+// interruptedKinds are the transitional status that an asset is left in by an
+// interrupted job, mapped to the kinds of the jobs that leave it in that status
+var interruptedKinds = map[inventory.AssetStatus][]string{
+	inventory.Provisioning: {jobKindCommission},
+	inventory.Provisioned:  {jobKindCommission},
+	inventory.Cancelled:    {jobKindDecommission},
+	inventory.Maintenance:  {jobKindUpdate, jobKindUpgrade},
+}
+
+// interruptedKind returns the kind of the job that left an asset in a
+// transitional status, as per the interrupted job in the job history. It
+// returns an empty kind if the job can't be told from the status alone and is
+// not found in the job history.
+func interruptedKind(status inventory.AssetStatus, hj boltdb.Job) string {
+	kinds := interruptedKinds[status]
+	if containsString(kinds, hj.Kind) {
+		return hj.Kind
+	}
+	if len(kinds) == 1 {
+		return kinds[0]
+	}
+	return ""
+}
+
+// interruptedTask returns the description of the job that left an asset in a
+// transitional status, used in the logs
+func (m *Manager) interruptedTask(name string, status inventory.AssetStatus) string {
+	if kind := interruptedKind(status, m.interruptedJobs[name]); kind != "" {
+		return kind
+	}
+	return strings.Join(interruptedKinds[status], "/")
+}
+
+// rollbackStatus returns the stable status that an asset left in a transitional
+// status is rolled back to. The cleanup of a decommission can't be undone, so a
+// cancelled asset is moved forward to decommissioned instead, from where it can
+// be commissioned again. An asset in maintenance is still running it's services
+// unless it's update failed, so it is moved back to commissioned.
+func rollbackStatus(status inventory.AssetStatus) inventory.AssetStatus {
+	switch status {
+	case inventory.Cancelled:
+		return inventory.Decommissioned
+	case inventory.Maintenance:
+		return inventory.Allocated
+	}
+	return inventory.Unallocated
+}
+
+// reconcileEvent reconciles the assets that were left in a transitional status
+// by the jobs that were interrupted when clusterm stopped. It is processed once
+// on startup and the actions taken are recorded in the logs of it's job.
+type reconcileEvent struct {
+	mgr    *Manager
+	policy string
+
+	_job   *Job
+	_stuck map[string]inventory.AssetStatus
+	// the interrupted task of each stuck asset, as per the job history
+	_tasks map[string]string
+}
+
+// newReconcileEvent creates and returns reconcileEvent
+func newReconcileEvent(mgr *Manager, policy string) *reconcileEvent {
+	return &reconcileEvent{
+		mgr:    mgr,
+		policy: policy,
+	}
+}
+
+func (e *reconcileEvent) String() string {
+	return fmt.Sprintf("reconcileEvent: policy: %s", e.policy)
+}
+
+func (e *reconcileEvent) process() error {
+	// err shouldn't be redefined below
+	var err error
+
+	// the job is created once, the event is processed again if the job is queued
+	if e._job == nil {
+		e._stuck = e.mgr.stuckAssets()
+		if len(e._stuck) == 0 {
+			logrus.Infof("no assets to reconcile")
+			return nil
+		}
+		names := []string{}
+		for name := range e._stuck {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		// the tasks are looked up here, as the job history of the interrupted
+		// jobs is updated in the event loop while the job runs
+		e._tasks = map[string]string{}
+		for name, status := range e._stuck {
+			e._tasks[name] = e.mgr.interruptedTask(name, status)
+		}
+		e._job = e.mgr.newJob(
+			e.String(),
+			e.reconcileRunner,
+			func(status JobStatus, errRet error) {
+				if status == Errored {
+					logrus.Errorf("reconcile job failed. Error: %v", errRet)
+				}
+			},
+			names)
+	}
+	job := e._job
+	if err = e.mgr.checkAndSetActiveJob(job, e, 0); err != nil {
+		return err
+	}
+
+	// the assets are picked up for the retry as they are discovered, which
+	// happens in the event loop
+	if e.policy == ReconcileRetry {
+		for name, status := range e._stuck {
+			e.mgr.interrupted[name] = status
+		}
+	}
+
+	// trigger the reconciliation
+	go e.mgr.runActiveJob(job)
+
+	return nil
+}
+
+// reconcileRunner is the job runner that acts upon the stuck assets as per the
+// reconcile policy and records what was done in the job logs
+func (e *reconcileEvent) reconcileRunner(cancelCh CancelChannel, jobLogs io.Writer) error {
+	results := map[string]configuration.HostResult{}
+	failed := []string{}
+	for _, name := range e._job.nodes {
+		status := e._stuck[name]
+		task := e._tasks[name]
+		switch e.policy {
+		case ReconcileRollback:
+			target := rollbackStatus(status)
+			if err := e.mgr.setAssetStatus(name, target); err != nil {
+				fmt.Fprintf(jobLogs, "node %q: failed to roll back the interrupted %s from %q to %q status. Error: %s\n",
+					name, task, status, target, err)
+				results[name] = configuration.HostResultFailed
+				failed = append(failed, name)
+				continue
+			}
+			fmt.Fprintf(jobLogs, "node %q: rolled back the interrupted %s from %q to %q status\n",
+				name, task, status, target)
+			results[name] = configuration.HostResultOK
+		case ReconcileRetry:
+			fmt.Fprintf(jobLogs, "node %q: the interrupted %s will be re-run once the node is discovered\n",
+				name, task)
+		case ReconcileFlag:
+			logrus.Warnf("node %q was left in %q status by an interrupted %s, it needs operator's attention",
+				name, status, task)
+			fmt.Fprintf(jobLogs, "node %q: flagged for the operator, it was left in %q status by an interrupted %s\n",
+				name, status, task)
+		}
+	}
+	e._job.setResults(results)
+
+	if len(failed) > 0 {
+		return errored.Errorf("failed to reconcile nodes: %v", failed)
+	}
+	return nil
+}
+
+// stuckAssets returns the assets that are in a transitional status, along with their status
+func (m *Manager) stuckAssets() map[string]inventory.AssetStatus {
+	stuck := map[string]inventory.AssetStatus{}
+	assets, ok := m.inventory.GetAllAssets().(map[string]*inventory.Asset)
+	if !ok {
+		return stuck
+	}
+	for name, asset := range assets {
+		// the nodes put in maintenance by the operator are not stuck
+		if _, ok := m.maintenance[name]; ok {
+			continue
+		}
+		status, _ := asset.GetStatus()
+		if _, ok := interruptedKinds[status]; ok {
+			stuck[name] = status
+		}
+	}
+	return stuck
+}
+
+// setAssetStatus sets the asset to one of the stable status that an asset is rolled back to
+func (m *Manager) setAssetStatus(name string, status inventory.AssetStatus) error {
+	switch status {
+	case inventory.Unallocated:
+		return m.inventory.SetAssetUnallocated(name)
+	case inventory.Decommissioned:
+		return m.inventory.SetAssetDecommissioned(name)
+	case inventory.Allocated:
+		return m.inventory.SetAssetCommissioned(name)
+	}
+	return errored.Errorf("unexpected status %q to set for asset %q", status, name)
+}
+
+// retryInterruptedJob re-runs the job that was interrupted on the node, if any.
+// It is called once the node is discovered, as the playbooks can only be run on
+// a discovered node. The job is re-run with the extra variables and the target
+// host-group of the interrupted job, as per the job history. When the job is
+// not found in the job history, it is run with the host-group that the node has
+// in the configuration subsystem and with no extra variables, and a node left
+// in maintenance is moved back to commissioned as it's job can't be told.
+func (m *Manager) retryInterruptedJob(name string) {
+	status, ok := m.interrupted[name]
+	if !ok {
+		return
+	}
+	delete(m.interrupted, name)
+	hj := m.interruptedJobs[name]
+	delete(m.interruptedJobs, name)
+
+	kind := interruptedKind(status, hj)
+	extraVars := hj.ExtraVars
+	if extraVars == "" {
+		extraVars = configuration.DefaultValidJSON
+	}
+	hostGroup := hj.HostGroups[name]
+
+	var e event
+	switch kind {
+	case jobKindCommission:
+		// a provisioned node can't be provisioned again without being
+		// moved back to unallocated status
+		if status == inventory.Provisioned {
+			if err := m.inventory.SetAssetUnallocated(name); err != nil {
+				logrus.Errorf("failed to re-run the interrupted commission on node %q. Error: %s", name, err)
+				return
+			}
+		}
+		if hostGroup == "" {
+			hostGroup = m.nodes[name].Cfg.(*configuration.AnsibleHost).GetGroup()
+		}
+		e = newCommissionEvent(m, []string{name}, extraVars, hostGroup, 0)
+	case jobKindDecommission:
+		e = newDecommissionEvent(m, []string{name}, extraVars, 0, false)
+	case jobKindUpdate, jobKindUpgrade:
+		// the update and upgrade are run on commissioned nodes, the node is
+		// set back in maintenance when the job starts
+		if err := m.inventory.SetAssetCommissioned(name); err != nil {
+			logrus.Errorf("failed to re-run the interrupted %s on node %q. Error: %s", kind, name, err)
+			return
+		}
+		if kind == jobKindUpdate {
+			e = newUpdateEvent(m, []string{name}, extraVars, hostGroup, 0, false)
+		} else {
+			e = newUpgradeEvent(m, []string{name}, extraVars, 1, 0, 0)
+		}
+	default:
+		logrus.Warnf("the job that left node %q in %q status is not known, moving it back to %q status",
+			name, status, inventory.Allocated)
+		if err := m.inventory.SetAssetCommissioned(name); err != nil {
+			logrus.Errorf("failed to move node %q back to %q status. Error: %s", name, inventory.Allocated, err)
+		}
+		return
+	}
+
+	logrus.Infof("re-running the interrupted %s on node %q", kind, name)
+	if err := e.process(); err != nil && err != errJobQueued {
+		logrus.Errorf("failed to re-run the interrupted %s on node %q. Error: %s", kind, name, err)
+	}
+}
@@ -0,0 +1,197 @@
+// +build unittest
+
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/contiv/cluster/management/src/boltdb"
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type reconcileSuite struct {
+}
+
+var _ = Suite(&reconcileSuite{})
+
+// newReconcileTestManager returns a manager with an inventory containing assets
+// in the specified status. The nodes are not known yet, as on startup.
+func newReconcileTestManager(c *C, ctrl *gomock.Controller, assets map[string]inventory.AssetStatus) *Manager {
+	return &Manager{
+		reqQ:            make(chan event, 10),
+		inventory:       newTestInventory(c, ctrl, assets, inventory.Disappeared),
+		interrupted:     map[string]inventory.AssetStatus{},
+		interruptedJobs: map[string]boltdb.Job{},
+	}
+}
+
+var reconcileTestAssets = map[string]inventory.AssetStatus{
+	"node1": inventory.Provisioning,
+	"node2": inventory.Cancelled,
+	"node3": inventory.Maintenance,
+	"node4": inventory.Allocated,
+	"node5": inventory.Unallocated,
+}
+
+var reconcileTestStuckAssets = map[string]inventory.AssetStatus{
+	"node1": inventory.Provisioning,
+	"node2": inventory.Cancelled,
+	"node3": inventory.Maintenance,
+}
+
+func assetStatus(mgr *Manager, name string) inventory.AssetStatus {
+	status, _ := mgr.inventory.GetAsset(name).GetStatus()
+	return status
+}
+
+func (s *reconcileSuite) TestStuckAssets(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newReconcileTestManager(c, ctrl, reconcileTestAssets)
+	c.Assert(mgr.stuckAssets(), DeepEquals, reconcileTestStuckAssets)
+}
+
+func (s *reconcileSuite) TestReconcileRollback(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newReconcileTestManager(c, ctrl, reconcileTestAssets)
+	e := newReconcileEvent(mgr, ReconcileRollback)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(e._job.Info().Nodes, DeepEquals, []string{"node1", "node2", "node3"})
+	c.Assert(e._job.Results(), DeepEquals, uniformResults([]string{"node1", "node2", "node3"},
+		configuration.HostResultOK))
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Unallocated)
+	c.Assert(assetStatus(mgr, "node2"), Equals, inventory.Decommissioned)
+	// the node in maintenance is still running it's services
+	c.Assert(assetStatus(mgr, "node3"), Equals, inventory.Allocated)
+	c.Assert(assetStatus(mgr, "node4"), Equals, inventory.Allocated)
+	c.Assert(assetStatus(mgr, "node5"), Equals, inventory.Unallocated)
+	c.Assert(mgr.interrupted, HasLen, 0)
+}
+
+func (s *reconcileSuite) TestReconcileRetryAndFlag(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	exptdInterrupted := map[string]map[string]inventory.AssetStatus{
+		ReconcileRetry: reconcileTestStuckAssets,
+		ReconcileFlag:  {},
+	}
+	for policy, exptd := range exptdInterrupted {
+		mgr := newReconcileTestManager(c, ctrl, reconcileTestAssets)
+		e := newReconcileEvent(mgr, policy)
+		c.Assert(e.process(), IsNil)
+		e._job.Wait()
+
+		c.Assert(e._job.Info().Status, Equals, Complete.String(), Commentf("policy: %s", policy))
+		for name, status := range reconcileTestAssets {
+			c.Assert(assetStatus(mgr, name), Equals, status, Commentf("policy: %s", policy))
+		}
+		c.Assert(mgr.interrupted, DeepEquals, exptd, Commentf("policy: %s", policy))
+		c.Assert(e._job.Info().Logs, HasLen, 4, Commentf("policy: %s", policy))
+	}
+}
+
+func (s *reconcileSuite) TestReconcileNoStuckAssets(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newReconcileTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node4": inventory.Allocated,
+	})
+	e := newReconcileEvent(mgr, ReconcileRollback)
+	c.Assert(e.process(), IsNil)
+	c.Assert(e._job, IsNil)
+	c.Assert(mgr.getActiveJobs(), HasLen, 0)
+}
+
+func (s *reconcileSuite) TestIsValidReconcilePolicy(c *C) {
+	for _, policy := range []string{ReconcileRollback, ReconcileRetry, ReconcileFlag} {
+		c.Assert(isValidReconcilePolicy(policy), Equals, true)
+	}
+	c.Assert(isValidReconcilePolicy(""), Equals, false)
+	c.Assert(isValidReconcilePolicy("foo"), Equals, false)
+}
+
+// waitForLastJob waits for a job to be done and returns it
+func waitForLastJob(c *C, mgr *Manager) *Job {
+	for i := 0; i < 50 && mgr.getLastJob() == nil; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	c.Assert(mgr.getLastJob(), NotNil)
+	return mgr.getLastJob()
+}
+
+func (s *reconcileSuite) TestRetryInterruptedUpdate(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newVerifyTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Maintenance,
+	}, nil, nil)
+	mgr.interrupted["node1"] = inventory.Maintenance
+	mgr.interruptedJobs["node1"] = boltdb.Job{
+		Kind:       jobKindUpdate,
+		Nodes:      []string{"node1"},
+		ExtraVars:  `{"foo":"bar"}`,
+		HostGroups: map[string]string{"node1": ansibleMasterGroupName},
+	}
+	mgr.retryInterruptedJob("node1")
+
+	j := waitForLastJob(c, mgr)
+	c.Assert(j.Info().Desc, Matches, `updateEvent: nodes: \[node1\] extra-vars: {"foo":"bar"} host-group: "service-master".*`)
+	c.Assert(j.Info().Status, Equals, Complete.String())
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated)
+	c.Assert(mgr.interrupted, HasLen, 0)
+	c.Assert(mgr.interruptedJobs, HasLen, 0)
+}
+
+func (s *reconcileSuite) TestRetryInterruptedUnknownJob(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	// a node in maintenance is not re-run when it's job is not known
+	mgr := newVerifyTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Maintenance,
+	}, nil, nil)
+	mgr.interrupted["node1"] = inventory.Maintenance
+	mgr.retryInterruptedJob("node1")
+	c.Assert(mgr.getActiveJobs(), HasLen, 0)
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated)
+}
+
+func (s *reconcileSuite) TestRestoreInterruptedJobs(c *C) {
+	dir, err := ioutil.TempDir("", "reconcile")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	db, err := boltdb.NewClientFromConfig(boltdb.Config{DBFile: filepath.Join(dir, "test.boltdb")})
+	c.Assert(err, IsNil)
+
+	jobs := []boltdb.Job{
+		{ID: 1, Nodes: []string{"node1"}, Status: Errored.String(), Kind: jobKindCommission},
+		{ID: 2, Nodes: []string{"node1", "node2"}, Status: Running.String(), Kind: jobKindUpdate},
+		{ID: 3, Nodes: []string{"node2"}, Status: Running.String(), Kind: jobKindUpgrade},
+	}
+	for _, hj := range jobs {
+		c.Assert(db.SetJob(hj), IsNil)
+	}
+	mgr := &Manager{db: db, interruptedJobs: map[string]boltdb.Job{}}
+	c.Assert(mgr.restoreJobHistory(), IsNil)
+	c.Assert(mgr.lastJobID, Equals, uint64(3))
+	c.Assert(mgr.interruptedJobs["node1"].ID, Equals, uint64(2))
+	c.Assert(mgr.interruptedJobs["node2"].ID, Equals, uint64(3))
+	c.Assert(interruptedKind(inventory.Maintenance, mgr.interruptedJobs["node1"]), Equals, jobKindUpdate)
+	c.Assert(interruptedKind(inventory.Provisioning, mgr.interruptedJobs["node1"]), Equals, jobKindCommission)
+	c.Assert(interruptedKind(inventory.Maintenance, boltdb.Job{}), Equals, "")
+}
@@ -0,0 +1,271 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+)
+
+const (
+	// RemediateAlert is the remediation action to alert the operator about a node
+	// that stays disappeared
+	RemediateAlert = "alert"
+	// RemediateDecommission is the remediation action to decommission a node that
+	// stays disappeared, with a forced cleanup
+	RemediateDecommission = "decommission"
+	// RemediateReplace is the remediation action to replace a node that stays
+	// disappeared by a spare node, which takes it's host-group and host variables.
+	// The node itself is decommissioned in the same job, without a cleanup.
+	RemediateReplace = "replace"
+)
+
+// remediationPolicy is the action to take on a commissioned node of a host-group
+// that stays disappeared for the grace period, like 5m
+type remediationPolicy struct {
+	Action      string `json:"action"`
+	GracePeriod string `json:"grace_period"`
+}
+
+func errInvalidRemediationPolicy(hostGroup, field, val string) error {
+	return errored.Errorf("invalid remediation %s specified for host-group %q: %q", field, hostGroup, val)
+}
+
+// validateRemediationPolicies validates the remediation policies, which are keyed by host-group
+func validateRemediationPolicies(policies map[string]remediationPolicy, groups map[string]hostGroupConfig) error {
+	for hostGroup, policy := range policies {
+		if _, ok := groups[hostGroup]; !ok {
+			return errored.Errorf("invalid host-group specified for remediation: %q", hostGroup)
+		}
+		switch policy.Action {
+		case RemediateAlert, RemediateDecommission, RemediateReplace:
+		default:
+			return errInvalidRemediationPolicy(hostGroup, "action", policy.Action)
+		}
+		if d, err := time.ParseDuration(policy.GracePeriod); err != nil || d < 0 {
+			return errInvalidRemediationPolicy(hostGroup, "grace period", policy.GracePeriod)
+		}
+	}
+	return nil
+}
+
+// remediationPolicyOf returns the remediation policy of a node as per it's
+// host-group, if any
+func (m *Manager) remediationPolicyOf(name string) (remediationPolicy, bool) {
+	node, err := m.findNode(name)
+	if err != nil || node.Cfg == nil {
+		return remediationPolicy{}, false
+	}
+	policy, ok := m.config.Manager.Remediation[node.Cfg.GetGroup()]
+	return policy, ok
+}
+
+// needsRemediation returns true if the node is a commissioned node that has
+// disappeared. The nodes in maintenance or being acted upon by a job are not
+// in commissioned status, so they are not remediated.
+func (m *Manager) needsRemediation(name string) bool {
+	node, err := m.findNode(name)
+	if err != nil || node.Inv == nil {
+		return false
+	}
+	if _, ok := m.maintenance[name]; ok {
+		return false
+	}
+	status, state := node.Inv.GetStatus()
+	return status == inventory.Allocated && state == inventory.Disappeared
+}
+
+// scheduleRemediation schedules the remediation of a disappeared node once it's
+// grace period is over, when the node's host-group has a remediation policy.
+func (m *Manager) scheduleRemediation(name string) {
+	policy, ok := m.remediationPolicyOf(name)
+	if !ok || !m.needsRemediation(name) {
+		return
+	}
+	// the policies are validated on startup
+	grace, _ := time.ParseDuration(policy.GracePeriod)
+	disappearedAt := time.Now()
+	m.disappeared[name] = disappearedAt
+	logrus.Infof("node %q has disappeared, it will be remediated with action %q if it doesn't reappear in %s",
+		name, policy.Action, grace)
+	time.AfterFunc(grace, func() {
+		m.reqQ <- newRemediationEvent(m, name, disappearedAt)
+	})
+}
+
+// cancelRemediation cancels the scheduled remediation of a node, if any, as it has reappeared
+func (m *Manager) cancelRemediation(name string) {
+	if _, ok := m.disappeared[name]; ok {
+		logrus.Infof("node %q has reappeared, cancelling it's remediation", name)
+		delete(m.disappeared, name)
+	}
+}
+
+// restoreRemediations reschedules the remediation of the commissioned nodes that
+// had disappeared when clusterm stopped, as the scheduled remediations are kept
+// in memory only. Such nodes are not known until they are discovered again, so
+// they are restored from the inventory and their grace period starts over.
+func (m *Manager) restoreRemediations() {
+	assets, ok := m.inventory.GetAllAssets().(map[string]*inventory.Asset)
+	if !ok {
+		return
+	}
+	for name, asset := range assets {
+		status, state := asset.GetStatus()
+		if status != inventory.Allocated || state != inventory.Disappeared {
+			continue
+		}
+		if _, err := m.findNode(name); err != nil {
+			m.nodes[name] = &node{
+				Cfg:   m.newNodeConfig(name, asset.GetConfig().Addr),
+				Inv:   asset,
+				Maint: m.maintenance[name],
+			}
+		}
+		m.scheduleRemediation(name)
+	}
+}
+
+// remediationEvent remediates a node that stayed disappeared for the grace
+// period, as per the remediation policy of it's host-group
+type remediationEvent struct {
+	mgr           *Manager
+	name          string
+	disappearedAt time.Time
+
+	_job   *Job
+	_alert string
+}
+
+// newRemediationEvent creates and returns remediationEvent
+func newRemediationEvent(mgr *Manager, name string, disappearedAt time.Time) *remediationEvent {
+	return &remediationEvent{
+		mgr:           mgr,
+		name:          name,
+		disappearedAt: disappearedAt,
+	}
+}
+
+func (e *remediationEvent) String() string {
+	return fmt.Sprintf("remediationEvent: node: %s disappeared at: %s", e.name, e.disappearedAt)
+}
+
+func (e *remediationEvent) process() error {
+	// the event is processed again if the alert's job was queued
+	if e._job != nil {
+		return e.recordAlert()
+	}
+
+	// the node may have reappeared, or disappeared again, since the remediation
+	// was scheduled
+	disappearedAt, ok := e.mgr.disappeared[e.name]
+	if !ok || !disappearedAt.Equal(e.disappearedAt) {
+		return nil
+	}
+	delete(e.mgr.disappeared, e.name)
+
+	policy, ok := e.mgr.remediationPolicyOf(e.name)
+	if !ok || !e.mgr.needsRemediation(e.name) {
+		return nil
+	}
+
+	switch policy.Action {
+	case RemediateDecommission:
+		if err := e.decommission(); err != nil {
+			return e.alert(policy, fmt.Sprintf("decommission failed. Error: %v", err))
+		}
+		return nil
+	case RemediateReplace:
+		spare := e.mgr.spareNode()
+		if spare == "" {
+			logrus.Errorf("no spare node found to replace disappeared node %q", e.name)
+			return e.alert(policy, "no spare node was found to replace it")
+		}
+		if err := e.replace(spare); err != nil {
+			return e.alert(policy, fmt.Sprintf("replacement by spare node %q failed. Error: %v", spare, err))
+		}
+		return nil
+	}
+	return e.alert(policy, "")
+}
+
+// alert records the alert about the node as a job, so that it shows up in
+// the job history, along with a warning in the logs
+func (e *remediationEvent) alert(policy remediationPolicy, reason string) error {
+	msg := fmt.Sprintf("node %q disappeared at %s and didn't reappear within the grace period of %s, it needs operator's attention",
+		e.name, e.disappearedAt.Format(time.RFC3339), policy.GracePeriod)
+	if reason != "" {
+		msg = fmt.Sprintf("%s. Remediation action %q was not done as %s", msg, policy.Action, reason)
+	}
+	logrus.Warnf("%s", msg)
+	e.mgr.addAssetLog(e.name, inventory.LogTypeWarning, "%s", msg)
+
+	e._alert = msg
+	e._job = e.mgr.newJob(
+		e.String(),
+		func(cancelCh CancelChannel, jobLogs io.Writer) error {
+			fmt.Fprintf(jobLogs, "%s\n", e._alert)
+			return nil
+		},
+		func(status JobStatus, errRet error) {},
+		[]string{e.name})
+	return e.recordAlert()
+}
+
+// recordAlert runs the job that records the alert. The alert is already logged,
+// the job is just for the record.
+func (e *remediationEvent) recordAlert() error {
+	if err := e.mgr.checkAndSetActiveJob(e._job, e, 0); err != nil {
+		if err != errJobQueued {
+			logrus.Errorf("failed to record the alert about node %q. Error: %v", e.name, err)
+		}
+		return nil
+	}
+	go e.mgr.runActiveJob(e._job)
+	return nil
+}
+
+// decommission decommissions the node with a forced cleanup, as the node is not reachable
+func (e *remediationEvent) decommission() error {
+	logrus.Infof("decommissioning disappeared node %q", e.name)
+	de := newDecommissionEvent(e.mgr, []string{e.name}, configuration.DefaultValidJSON, 0, true)
+	err := de.process()
+	if err != nil && err != errJobQueued {
+		return err
+	}
+	return nil
+}
+
+// replace replaces the disappeared node by the spare node in one job
+func (e *remediationEvent) replace(spare string) error {
+	logrus.Infof("replacing disappeared node %q by spare node %q", e.name, spare)
+	err := newReplaceEvent(e.mgr, e.name, spare, configuration.DefaultValidJSON, 0).process()
+	if err != nil && err != errJobQueued {
+		return err
+	}
+	return nil
+}
+
+// spareNode returns a discovered node that is not commissioned, which can take
+// the place of a disappeared node. It returns an empty string if there is none.
+func (m *Manager) spareNode() string {
+	spares := []string{}
+	for name, node := range m.nodes {
+		if node.Inv == nil {
+			continue
+		}
+		if status, state := node.Inv.GetStatus(); status == inventory.Unallocated && state == inventory.Discovered {
+			spares = append(spares, name)
+		}
+	}
+	if len(spares) == 0 {
+		return ""
+	}
+	sort.Strings(spares)
+	return spares[0]
+}
@@ -0,0 +1,164 @@
+// +build unittest
+
+package manager
+
+import (
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/cluster/management/src/mock"
+	"github.com/contiv/errored"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type remediationSuite struct {
+}
+
+var _ = Suite(&remediationSuite{})
+
+// newRemediationTestManager returns a manager with disappeared nodes in the
+// specified status, all in master host-group
+func newRemediationTestManager(c *C, ctrl *gomock.Controller, assets map[string]inventory.AssetStatus,
+	policy remediationPolicy) *Manager {
+	mgr := newTestManager(c, ctrl, assets, inventory.Disappeared)
+	mgr.config.Manager.Remediation = map[string]remediationPolicy{
+		ansibleMasterGroupName: policy,
+	}
+	return mgr
+}
+
+func (s *remediationSuite) TestValidateRemediationPolicies(c *C) {
+	c.Assert(validateRemediationPolicies(nil, defaultHostGroups()), IsNil)
+	c.Assert(validateRemediationPolicies(map[string]remediationPolicy{
+		ansibleMasterGroupName: {Action: RemediateReplace, GracePeriod: "5m"},
+		ansibleWorkerGroupName: {Action: RemediateDecommission, GracePeriod: "0s"},
+	}, defaultHostGroups()), IsNil)
+
+	tests := map[string]map[string]remediationPolicy{
+		"invalid-host-group":   {"foo": {Action: RemediateAlert, GracePeriod: "5m"}},
+		"invalid-action":       {ansibleMasterGroupName: {Action: "foo", GracePeriod: "5m"}},
+		"invalid-grace-period": {ansibleMasterGroupName: {Action: RemediateAlert, GracePeriod: "foo"}},
+		"negative-grace":       {ansibleMasterGroupName: {Action: RemediateAlert, GracePeriod: "-1m"}},
+	}
+	for testname, policies := range tests {
+		c.Assert(validateRemediationPolicies(policies, defaultHostGroups()), NotNil, Commentf("test: %s", testname))
+	}
+}
+
+func (s *remediationSuite) TestScheduleRemediation(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newRemediationTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+		"node2": inventory.Unallocated,
+		"node3": inventory.Allocated,
+	}, remediationPolicy{Action: RemediateAlert, GracePeriod: "0s"})
+	mgr.maintenance["node3"] = &MaintenanceInfo{Reason: "foo"}
+
+	// only the commissioned node that is not in maintenance is remediated
+	for _, name := range []string{"node1", "node2", "node3"} {
+		mgr.scheduleRemediation(name)
+	}
+	c.Assert(mgr.disappeared, HasLen, 1)
+	_, ok := mgr.disappeared["node1"]
+	c.Assert(ok, Equals, true)
+
+	// the node reappears before the remediation is processed
+	e := <-mgr.reqQ
+	mgr.cancelRemediation("node1")
+	c.Assert(e.process(), IsNil)
+	c.Assert(e.(*remediationEvent)._job, IsNil)
+}
+
+func (s *remediationSuite) TestRemediationAlert(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newRemediationTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+	}, remediationPolicy{Action: RemediateAlert, GracePeriod: "0s"})
+
+	mgr.scheduleRemediation("node1")
+	e := (<-mgr.reqQ).(*remediationEvent)
+	c.Assert(e.process(), IsNil)
+	c.Assert(e._job, NotNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(e._job.Info().Nodes, DeepEquals, []string{"node1"})
+	c.Assert(mgr.disappeared, HasLen, 0)
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated)
+}
+
+func (s *remediationSuite) TestRemediationReplaceNoSpare(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newRemediationTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+		"node2": inventory.Unallocated,
+	}, remediationPolicy{Action: RemediateReplace, GracePeriod: "0s"})
+
+	// node2 has disappeared as well, so it can't be a spare
+	c.Assert(mgr.spareNode(), Equals, "")
+
+	// the replace falls back to alert
+	mgr.scheduleRemediation("node1")
+	e := (<-mgr.reqQ).(*remediationEvent)
+	c.Assert(e.process(), IsNil)
+	c.Assert(e._job, NotNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+}
+
+func (s *remediationSuite) TestRemediationDecommissionFailed(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newRemediationTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+	}, remediationPolicy{Action: RemediateDecommission, GracePeriod: "0s"})
+	// the node can't be set as cancelled in the inventory, which fails the decommission
+	mClient := mock.NewMockSubsysClient(ctrl)
+	mClient.EXPECT().SetAssetStatus("node1", inventory.Cancelled.String(), gomock.Any(), gomock.Any()).
+		Return(errored.Errorf("test error"))
+	mClient.EXPECT().AddAssetLog(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	inv := inventory.NewGeneralSubsys(mClient)
+	c.Assert(inv.RestoreAsset("node1",
+		inventory.NewAssetWithState(mClient, "node1", inventory.Allocated, inventory.Disappeared)), IsNil)
+	mgr.inventory = inv
+	mgr.nodes["node1"].Inv = inv.GetAsset("node1")
+
+	// the decommission falls back to alert
+	mgr.scheduleRemediation("node1")
+	e := (<-mgr.reqQ).(*remediationEvent)
+	c.Assert(e.process(), IsNil)
+	c.Assert(e._job, NotNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(e._alert, Matches, `.*Remediation action "decommission" was not done as decommission failed.*`)
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated)
+}
+
+func (s *remediationSuite) TestRestoreRemediations(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newRemediationTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+		"node2": inventory.Unallocated,
+	}, remediationPolicy{Action: RemediateAlert, GracePeriod: "1h"})
+	// the nodes are not known on startup till they are discovered
+	mgr.nodes = map[string]*node{}
+
+	mgr.restoreRemediations()
+	c.Assert(mgr.nodes, HasLen, 1)
+	c.Assert(mgr.nodes["node1"].Inv, NotNil)
+	c.Assert(mgr.nodes["node1"].Cfg.GetGroup(), Equals, ansibleMasterGroupName)
+	c.Assert(mgr.disappeared, HasLen, 1)
+	_, ok := mgr.disappeared["node1"]
+	c.Assert(ok, Equals, true)
+
+	// the node reappears before it's grace period is over
+	mgr.cancelRemediation("node1")
+	c.Assert(mgr.disappeared, HasLen, 0)
+}
@@ -0,0 +1,242 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+)
+
+// replaceEvent replaces a commissioned node, usually a failed one, by a spare
+// node in one job. The spare node gets the host-group and the host variables
+// of the node and is commissioned, after which the node is decommissioned.
+// The node is left as is if the commission of the spare node fails. The
+// cleanup of the node is skipped if it is not reachable and it's failure is
+// ignored, as with a forced decommission.
+type replaceEvent struct {
+	mgr       *Manager
+	nodeName  string
+	spareName string
+	extraVars string
+	priority  int
+
+	_job       *Job
+	_nodeHost  *configuration.AnsibleHost
+	_spareHost *configuration.AnsibleHost
+	_spareNode *node
+	_reachable bool
+	// the spare node's original host-group and host variables, which are
+	// restored if the spare node is not commissioned
+	_spareGroup string
+	_spareVars  map[string]string
+}
+
+// newReplaceEvent creates and returns replaceEvent
+func newReplaceEvent(mgr *Manager, nodeName, spareName, extraVars string, priority int) *replaceEvent {
+	return &replaceEvent{
+		mgr:       mgr,
+		nodeName:  nodeName,
+		spareName: spareName,
+		extraVars: extraVars,
+		priority:  priority,
+	}
+}
+
+func (e *replaceEvent) String() string {
+	return fmt.Sprintf("replaceEvent: node: %s spare: %s extra-vars: %v", e.nodeName, e.spareName, e.extraVars)
+}
+
+func (e *replaceEvent) job() *Job {
+	return e._job
+}
+
+func (e *replaceEvent) process() error {
+	// err shouldn't be redefined below
+	var err error
+
+	// the job is created once, the event is processed again if the job is queued
+	if e._job == nil {
+		e._job = e.mgr.newJob(
+			e.String(),
+			e.replaceRunner,
+			func(status JobStatus, errRet error) {
+				if status == Errored {
+					logrus.Errorf("replace job failed. Error: %v", errRet)
+				}
+				e.setReplaced()
+			},
+			[]string{e.nodeName, e.spareName})
+	}
+	job := e._job
+	if err = e.mgr.checkAndSetActiveJob(job, e, e.priority); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			e.mgr.resetActiveJob(job)
+		}
+	}()
+
+	// validate event data
+	if err = e.eventValidate(); err != nil {
+		return err
+	}
+
+	// prepare inventory
+	if err = e.prepareInventory(); err != nil {
+		return err
+	}
+
+	// set the spare asset as provisioning, the node is left commissioned till
+	// the spare node is commissioned
+	if err = e.mgr.setAssetsStatusAtomic([]string{e.spareName}, e.mgr.inventory.SetAssetProvisioning,
+		e.mgr.inventory.SetAssetUnallocated); err != nil {
+		e.restoreSpareConfig()
+		return err
+	}
+
+	// trigger the replacement
+	go e.mgr.runActiveJob(job)
+
+	return nil
+}
+
+// eventValidate makes sure that the node is commissioned and that the spare
+// node is reachable and not commissioned. The host-groups are not checked as
+// the spare node takes the place of the node in it's host-group.
+func (e *replaceEvent) eventValidate() error {
+	if e.nodeName == e.spareName {
+		return errored.Errorf("node %q can't be replaced by itself", e.nodeName)
+	}
+	enodes, err := e.mgr.forcedEventValidate([]string{e.nodeName, e.spareName})
+	if err != nil {
+		return err
+	}
+	if enodes[e.nodeName].Inv == nil {
+		return nodeInventoryNotExistsError(e.nodeName)
+	}
+	if status, _ := enodes[e.nodeName].Inv.GetStatus(); status != inventory.Allocated {
+		return errored.Errorf("node %q is in %q status, only a commissioned node can be replaced", e.nodeName, status)
+	}
+	if err := e.mgr.areDiscoveredNodes([]string{e.spareName}); err != nil {
+		return err
+	}
+	if status, _ := enodes[e.spareName].Inv.GetStatus(); status != inventory.Unallocated && status != inventory.Decommissioned {
+		return errored.Errorf("spare node %q is in %q status, only a node that is not commissioned can be a spare", e.spareName, status)
+	}
+
+	e._nodeHost = enodes[e.nodeName].Cfg.(*configuration.AnsibleHost)
+	e._spareHost = enodes[e.spareName].Cfg.(*configuration.AnsibleHost)
+	e._spareNode = enodes[e.spareName]
+	e._reachable, _ = e.mgr.isDiscoveredNode(e.nodeName)
+	return nil
+}
+
+// prepareInventory gives the spare node the host-group and the host variables
+// of the node, except the variables that identify the node itself. The spare
+// node's own host-group and variables are kept to be restored on failure.
+func (e *replaceEvent) prepareInventory() error {
+	e._spareGroup = e._spareHost.GetGroup()
+	e._spareVars = e._spareHost.GetVars()
+	for k, v := range e._nodeHost.GetVars() {
+		if k == ansibleNodeNameHostVar || k == ansibleNodeAddrHostVar {
+			continue
+		}
+		e._spareHost.SetVar(k, v)
+	}
+	e._spareHost.SetGroup(e._nodeHost.GetGroup())
+	return e.mgr.saveNodeConfig(e.spareName)
+}
+
+// restoreSpareConfig restores the spare node's original host-group and host
+// variables, as the spare node didn't take the node's place
+func (e *replaceEvent) restoreSpareConfig() {
+	// the host is recreated, as the variables copied from the node are to be
+	// removed. The spare node is the one found when the event was processed, as
+	// the nodes shall not be looked up once the job is running.
+	e._spareHost = configuration.NewAnsibleHost(e.spareName, e._spareHost.GetAddr(), e._spareGroup, e._spareVars)
+	e._spareNode.Cfg = e._spareHost
+	if err := e.mgr.saveHostConfig(e._spareHost); err != nil {
+		logrus.Errorf("failed to restore %s's configuration in inventory. Error: %v", e.spareName, err)
+	}
+}
+
+// replaceRunner is the job runner that commissions the spare node and then
+// cleans up the node. The spare node is cleaned up if it's configuration or
+// verification fails, in which case the node is left as is.
+func (e *replaceEvent) replaceRunner(cancelCh CancelChannel, jobLogs io.Writer) error {
+	spareHosts := []*configuration.AnsibleHost{e._spareHost}
+	fmt.Fprintf(jobLogs, "commissioning spare node %q in host-group %q\n", e.spareName, e._spareHost.GetGroup())
+	outReader, cancelFunc, errCh := e.mgr.configuration.Configure(spareHosts, e.extraVars)
+	err := logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs)
+	if err == nil {
+		e.mgr.setAssetsStatusBestEffort([]string{e.spareName}, e.mgr.inventory.SetAssetProvisioned)
+		err = e.mgr.verifyProvisioned(spareHosts, e.extraVars, cancelCh, jobLogs)
+	}
+	if err != nil {
+		e._job.setResults(map[string]configuration.HostResult{
+			e.spareName: configuration.HostResultFailed,
+		})
+		logrus.Errorf("commission of spare node %q failed, starting cleanup. Error: %s", e.spareName, err)
+		outReader, cancelFunc, errCh = e.mgr.configuration.Cleanup(spareHosts, e.extraVars)
+		if err := logOutputAndReturnStatus(outReader, errCh, cleanupCancelChannel(cancelCh, err),
+			cancelFunc, jobLogs); err != nil {
+			logrus.Errorf("cleanup failed. Error: %s", err)
+		}
+		return err
+	}
+
+	e.mgr.gatherFacts(spareHosts, jobLogs)
+
+	// the spare node has taken the node's place, so the node is decommissioned
+	// even if it's cleanup fails
+	results := map[string]configuration.HostResult{
+		e.spareName: configuration.HostResultOK,
+		e.nodeName:  configuration.HostResultUnreachable,
+	}
+	e.mgr.setAssetsStatusBestEffort([]string{e.nodeName}, e.mgr.inventory.SetAssetCancelled)
+	if !e._reachable {
+		fmt.Fprintf(jobLogs, "skipping the cleanup of node %q as it is not reachable\n", e.nodeName)
+		e._job.setResults(results)
+		return nil
+	}
+	fmt.Fprintf(jobLogs, "decommissioning node %q\n", e.nodeName)
+	outReader, cancelFunc, errCh = e.mgr.configuration.Cleanup([]*configuration.AnsibleHost{e._nodeHost}, e.extraVars)
+	err = logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs)
+	results[e.nodeName] = stepResults([]string{e.nodeName}, err)[e.nodeName]
+	e._job.setResults(results)
+	if err != nil {
+		fmt.Fprintf(jobLogs, "ignoring the cleanup failure of node %q as it has been replaced. Error: %v\n", e.nodeName, err)
+	}
+	return nil
+}
+
+// setReplaced sets the spare asset as commissioned and the node's asset as
+// decommissioned, recording the replacement in the inventory, when the spare
+// node was commissioned. Else the spare asset is set as unallocated, with it's
+// original host-group and host variables.
+func (e *replaceEvent) setReplaced() {
+	results := e._job.Results()
+	if results[e.spareName] != configuration.HostResultOK {
+		e.restoreSpareConfig()
+		e.mgr.setAssetsStatusBestEffort([]string{e.spareName}, e.mgr.inventory.SetAssetUnallocated)
+		return
+	}
+
+	e.mgr.setAssetsStatusBestEffort([]string{e.spareName}, e.mgr.inventory.SetAssetCommissioned)
+	e.mgr.clearDegraded([]string{e.spareName})
+	reason := fmt.Sprintf("Node was replaced by node %q", e.spareName)
+	switch results[e.nodeName] {
+	case configuration.HostResultOK:
+	case configuration.HostResultUnreachable:
+		reason = fmt.Sprintf("%s without cleanup, as it was not reachable", reason)
+	default:
+		reason = fmt.Sprintf("%s, though it's cleanup failed", reason)
+	}
+	if err := e.mgr.inventory.SetAssetDecommissionedWithReason(e.nodeName, reason); err != nil {
+		logrus.Errorf("failed to update %s's state in inventory, Error: %v", e.nodeName, err)
+	}
+}
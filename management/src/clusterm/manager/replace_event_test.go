@@ -0,0 +1,132 @@
+// +build unittest
+
+package manager
+
+import (
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type replaceSuite struct {
+}
+
+var _ = Suite(&replaceSuite{})
+
+// newReplaceTestManager returns a manager with a commissioned node1 in worker
+// host-group and a spare node2, with the configuration actions returning the
+// specified error
+func newReplaceTestManager(c *C, ctrl *gomock.Controller, err error) *Manager {
+	mgr := newTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+		"node2": inventory.Unallocated,
+	}, inventory.Discovered)
+	mgr.configuration = &fakeConfigSubsys{err: err}
+	mgr.nodes["node1"].Cfg = configuration.NewAnsibleHost("node1", "1.1.1.1", ansibleWorkerGroupName,
+		map[string]string{
+			ansibleNodeNameHostVar: "node1",
+			ansibleNodeAddrHostVar: "1.1.1.1",
+			"contiv_network_mode":  "aci",
+		})
+	mgr.nodes["node2"].Cfg = configuration.NewAnsibleHost("node2", "2.2.2.2", ansibleMasterGroupName,
+		map[string]string{
+			ansibleNodeNameHostVar: "node2",
+			ansibleNodeAddrHostVar: "2.2.2.2",
+		})
+	return mgr
+}
+
+func (s *replaceSuite) TestReplaceSuccess(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newReplaceTestManager(c, ctrl, nil)
+	e := newReplaceEvent(mgr, "node1", "node2", configuration.DefaultValidJSON, 0)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(e._job.Info().Nodes, DeepEquals, []string{"node1", "node2"})
+	c.Assert(e._job.Results(), DeepEquals, map[string]configuration.HostResult{
+		"node1": configuration.HostResultOK,
+		"node2": configuration.HostResultOK,
+	})
+
+	// the spare node takes the node's host-group and variables, but not it's identity
+	spare := mgr.nodes["node2"].Cfg.(*configuration.AnsibleHost)
+	c.Assert(spare.GetGroup(), Equals, ansibleWorkerGroupName)
+	c.Assert(spare.GetVars(), DeepEquals, map[string]string{
+		ansibleNodeNameHostVar: "node2",
+		ansibleNodeAddrHostVar: "2.2.2.2",
+		"contiv_network_mode":  "aci",
+	})
+	c.Assert(mgr.inventory.GetAsset("node2").GetConfig().Group, Equals, ansibleWorkerGroupName)
+	c.Assert(assetStatus(mgr, "node2"), Equals, inventory.Allocated)
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Decommissioned)
+}
+
+func (s *replaceSuite) TestReplaceUnreachableNode(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newReplaceTestManager(c, ctrl, nil)
+	c.Assert(mgr.inventory.SetAssetDisappeared("node1"), IsNil)
+	e := newReplaceEvent(mgr, "node1", "node2", configuration.DefaultValidJSON, 0)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(e._job.Results()["node1"], Equals, configuration.HostResultUnreachable)
+	c.Assert(assetStatus(mgr, "node2"), Equals, inventory.Allocated)
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Decommissioned)
+}
+
+func (s *replaceSuite) TestReplaceSpareFailure(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newReplaceTestManager(c, ctrl, errored.Errorf("test failure"))
+	e := newReplaceEvent(mgr, "node1", "node2", configuration.DefaultValidJSON, 0)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Errored.String())
+
+	// the node is left as is when the spare node can't be commissioned
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated)
+	c.Assert(assetStatus(mgr, "node2"), Equals, inventory.Unallocated)
+
+	// the spare node gets back it's own host-group and variables
+	exptdVars := map[string]string{
+		ansibleNodeNameHostVar: "node2",
+		ansibleNodeAddrHostVar: "2.2.2.2",
+	}
+	spareHost := mgr.nodes["node2"].Cfg.(*configuration.AnsibleHost)
+	c.Assert(spareHost.GetGroup(), Equals, ansibleMasterGroupName)
+	c.Assert(spareHost.GetVars(), DeepEquals, exptdVars)
+	config := mgr.inventory.GetAsset("node2").GetConfig()
+	c.Assert(config.Group, Equals, ansibleMasterGroupName)
+	c.Assert(config.Vars, DeepEquals, exptdVars)
+}
+
+func (s *replaceSuite) TestReplaceValidation(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newReplaceTestManager(c, ctrl, nil)
+	c.Assert(mgr.inventory.SetAssetDisappeared("node2"), IsNil)
+	tests := map[string]struct {
+		node  string
+		spare string
+		exptd string
+	}{
+		"same-node":         {"node1", "node1", ".*can't be replaced by itself.*"},
+		"missing-spare":     {"node1", "node3", ".*doesn't exists.*"},
+		"uncommissioned":    {"node2", "node1", ".*only a commissioned node can be replaced.*"},
+		"unreachable-spare": {"node1", "node2", ".*one or more nodes are not in discovered state.*"},
+	}
+	for testname, test := range tests {
+		e := newReplaceEvent(mgr, test.node, test.spare, configuration.DefaultValidJSON, 0)
+		c.Assert(e.process(), ErrorMatches, test.exptd, Commentf("test: %s", testname))
+	}
+	c.Assert(mgr.getActiveJobs(), HasLen, 0)
+}
@@ -34,9 +34,10 @@ func (e *setConfigEvent) process() error {
 	// err shouldn't be redefined below
 	var err error
 
-	// we set a noop job to ensure that even for the short time this event is
-	// run no other job get's enqueued and catches us in middle of things
-	err = e.mgr.checkAndSetActiveJob(
+	// we set a noop job that locks the whole cluster to ensure that even for the
+	// short time this event is run no other job get's enqueued and catches us in
+	// middle of things
+	job, err := e.mgr.checkAndSetExclusiveJob(
 		e.String(),
 		e.noopRunner,
 		func(status JobStatus, errRet error) { return })
@@ -45,7 +46,7 @@ func (e *setConfigEvent) process() error {
 	}
 	defer func() {
 		if err != nil {
-			e.mgr.resetActiveJob()
+			e.mgr.resetActiveJob(job)
 		}
 	}()
 
@@ -64,7 +65,7 @@ func (e *setConfigEvent) process() error {
 	e.mgr.config = e.config
 
 	// trigger the noop job
-	go e.mgr.runActiveJob()
+	go e.mgr.runActiveJob(job)
 
 	return nil
 }
@@ -0,0 +1,453 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+)
+
+// ClusterSpec is the desired state of the cluster, as applied by the operator.
+// The nodes that are not part of any host-group of the spec are decommissioned.
+type ClusterSpec struct {
+	// HostGroups are the nodes of each host-group, keyed by host-group
+	HostGroups map[string]HostGroupSpec `json:"host_groups"`
+	// ExtraVars are the extra vars of the jobs that converge the cluster to the spec
+	ExtraVars string `json:"extra_vars,omitempty"`
+}
+
+// HostGroupSpec are the nodes of a host-group, either listed by name or
+// counted. The counted nodes are the commissioned nodes of the host-group,
+// followed by the spare nodes, that match the selector.
+type HostGroupSpec struct {
+	Nodes    []string     `json:"nodes,omitempty"`
+	Count    int          `json:"count,omitempty"`
+	Selector NodeSelector `json:"selector,omitempty"`
+}
+
+// NodeSelector selects the nodes whose label and serial number match the
+// regular expressions. An empty pattern matches all nodes.
+type NodeSelector struct {
+	LabelPattern  string `json:"label_pattern,omitempty"`
+	SerialPattern string `json:"serial_pattern,omitempty"`
+}
+
+// SpecPlan is the difference between the spec and the state of the cluster,
+// as the actions that converge the cluster to the spec. Pending are the
+// reasons why the spec can't be met by the actions at present.
+type SpecPlan struct {
+	Commission   map[string]string `json:"commission,omitempty"`
+	Update       map[string]string `json:"update,omitempty"`
+	Decommission []string          `json:"decommission,omitempty"`
+	Pending      []string          `json:"pending,omitempty"`
+}
+
+// SpecInfo is the applied spec along with it's current plan. Both are nil when
+// no spec is applied.
+type SpecInfo struct {
+	Spec *ClusterSpec `json:"spec"`
+	Plan *SpecPlan    `json:"plan"`
+}
+
+func (p *SpecPlan) pending(format string, args ...interface{}) {
+	p.Pending = append(p.Pending, fmt.Sprintf(format, args...))
+}
+
+// converged returns true if no action is needed to converge the cluster to the spec
+func (p *SpecPlan) converged() bool {
+	return len(p.Commission) == 0 && len(p.Update) == 0 && len(p.Decommission) == 0
+}
+
+func (s NodeSelector) compile() (*regexp.Regexp, *regexp.Regexp, error) {
+	labelRe, err := regexp.Compile(s.LabelPattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	serialRe, err := regexp.Compile(s.SerialPattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	return labelRe, serialRe, nil
+}
+
+// validateSpec validates the spec against the defined host-groups and
+// sanitizes it's extra vars
+func validateSpec(spec *ClusterSpec, groups map[string]hostGroupConfig) error {
+	var err error
+	if spec.ExtraVars, err = validateAndSanitizeEmptyExtraVars("spec's extra_vars", spec.ExtraVars); err != nil {
+		return err
+	}
+
+	nodeGroups := map[string]string{}
+	for _, hostGroup := range specHostGroups(spec) {
+		gs := spec.HostGroups[hostGroup]
+		if _, ok := groups[hostGroup]; !ok {
+			return errored.Errorf("invalid host-group specified in the spec: %q", hostGroup)
+		}
+		if gs.Count < 0 {
+			return errored.Errorf("invalid count specified for host-group %q in the spec: %d", hostGroup, gs.Count)
+		}
+		if gs.Count > 0 && len(gs.Nodes) > 0 {
+			return errored.Errorf("either the nodes or the count should be specified for host-group %q in the spec, not both", hostGroup)
+		}
+		if _, _, err := gs.Selector.compile(); err != nil {
+			return errored.Errorf("invalid selector specified for host-group %q in the spec. Error: %v", hostGroup, err)
+		}
+		for _, name := range gs.Nodes {
+			if other, ok := nodeGroups[name]; ok {
+				return errored.Errorf("node %q is specified in host-groups %q and %q in the spec", name, other, hostGroup)
+			}
+			nodeGroups[name] = hostGroup
+		}
+	}
+	return nil
+}
+
+// specHostGroups returns the sorted host-groups of the spec
+func specHostGroups(spec *ClusterSpec) []string {
+	hostGroups := []string{}
+	for hostGroup := range spec.HostGroups {
+		hostGroups = append(hostGroups, hostGroup)
+	}
+	sort.Strings(hostGroups)
+	return hostGroups
+}
+
+// sortedNodeNames returns the sorted names of the known nodes
+func (m *Manager) sortedNodeNames() []string {
+	names := []string{}
+	for name := range m.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isCommissionedIn returns true if the node is commissioned, or being
+// commissioned, in the host-group
+func isCommissionedIn(n *node, hostGroup string) bool {
+	if n.Inv == nil || n.Cfg == nil || n.Cfg.GetGroup() != hostGroup {
+		return false
+	}
+	switch status, _ := n.Inv.GetStatus(); status {
+	case inventory.Allocated, inventory.Provisioning, inventory.Provisioned, inventory.Maintenance:
+		return true
+	}
+	return false
+}
+
+// isSpare returns true if the node is discovered and not commissioned
+func isSpare(n *node) bool {
+	if n.Inv == nil {
+		return false
+	}
+	status, state := n.Inv.GetStatus()
+	return status == inventory.Unallocated && state == inventory.Discovered
+}
+
+// selectNodes returns the nodes of a counted host-group of the spec. The nodes
+// commissioned in the host-group are kept first, followed by the spare nodes,
+// skipping the nodes that are claimed by the other host-groups.
+func (m *Manager) selectNodes(hostGroup string, gs HostGroupSpec, claimed map[string]string) []string {
+	// the selector is validated when the spec is applied
+	labelRe, serialRe, _ := gs.Selector.compile()
+	matches := func(n *node) bool {
+		return n.Mon != nil && labelRe.MatchString(n.Mon.GetLabel()) && serialRe.MatchString(n.Mon.GetSerial())
+	}
+
+	selected := []string{}
+	for _, pass := range []func(n *node) bool{
+		func(n *node) bool { return isCommissionedIn(n, hostGroup) },
+		isSpare,
+	} {
+		for _, name := range m.sortedNodeNames() {
+			if len(selected) == gs.Count {
+				return selected
+			}
+			if _, ok := claimed[name]; ok {
+				continue
+			}
+			if n := m.nodes[name]; pass(n) && matches(n) {
+				selected = append(selected, name)
+				claimed[name] = hostGroup
+			}
+		}
+	}
+	return selected
+}
+
+// planSpec compares the spec with the state of the cluster and returns the
+// actions that converge the cluster to the spec
+func (m *Manager) planSpec(spec *ClusterSpec) *SpecPlan {
+	plan := &SpecPlan{
+		Commission: map[string]string{},
+		Update:     map[string]string{},
+	}
+
+	// the desired host-group of the nodes, the listed nodes are claimed before
+	// the counted ones
+	desired := map[string]string{}
+	for _, hostGroup := range specHostGroups(spec) {
+		for _, name := range spec.HostGroups[hostGroup].Nodes {
+			if _, ok := m.nodes[name]; !ok {
+				plan.pending("node %q of host-group %q doesn't exist", name, hostGroup)
+				continue
+			}
+			desired[name] = hostGroup
+		}
+	}
+	for _, hostGroup := range specHostGroups(spec) {
+		gs := spec.HostGroups[hostGroup]
+		if gs.Count == 0 {
+			continue
+		}
+		if selected := m.selectNodes(hostGroup, gs, desired); len(selected) < gs.Count {
+			plan.pending("host-group %q has %d of %d nodes, there are no more spare nodes that match it's selector",
+				hostGroup, len(selected), gs.Count)
+		}
+	}
+
+	for _, name := range m.sortedNodeNames() {
+		n := m.nodes[name]
+		hostGroup, wanted := desired[name]
+		if n.Inv == nil || n.Cfg == nil {
+			if wanted {
+				plan.pending("node %q has no inventory or configuration state", name)
+			}
+			continue
+		}
+		status, state := n.Inv.GetStatus()
+		reachable := state == inventory.Discovered || state == inventory.Degraded
+		switch status {
+		case inventory.Allocated:
+			if wanted && n.Cfg.GetGroup() == hostGroup {
+				continue
+			}
+			if !reachable {
+				plan.pending("node %q is not reachable, it is left commissioned in host-group %q", name, n.Cfg.GetGroup())
+				continue
+			}
+			if wanted {
+				plan.Update[name] = hostGroup
+				continue
+			}
+			plan.Decommission = append(plan.Decommission, name)
+		case inventory.Unallocated, inventory.Decommissioned:
+			if !wanted {
+				continue
+			}
+			if !reachable {
+				plan.pending("node %q is not reachable, it is commissioned in host-group %q once it is discovered", name, hostGroup)
+				continue
+			}
+			plan.Commission[name] = hostGroup
+		default:
+			// the nodes that are acted upon by a job or are in maintenance are
+			// reconciled once they are back in a stable status
+			if wanted && !isCommissionedIn(n, hostGroup) {
+				plan.pending("node %q is in %q status, it is reconciled once it's job is done or it exits maintenance", name, status)
+			}
+		}
+	}
+	return plan
+}
+
+// setSpec sets the applied spec, a nil spec means that no spec is applied. The
+// spec is persisted, so that it is reconciled across restarts of clusterm.
+func (m *Manager) setSpec(spec *ClusterSpec) error {
+	if m.db != nil {
+		var err error
+		if spec == nil {
+			err = m.db.DeleteSpec()
+		} else {
+			var val []byte
+			if val, err = json.Marshal(spec); err == nil {
+				err = m.db.SetSpec(val)
+			}
+		}
+		if err != nil {
+			return errored.Errorf("failed to persist the spec. Error: %v", err)
+		}
+	}
+	m.spec = spec
+	return nil
+}
+
+// restoreSpec restores the spec that was applied when clusterm stopped. A spec
+// that is no longer valid, for instance when a host-group is removed from the
+// configuration, is not restored.
+func (m *Manager) restoreSpec() error {
+	if m.db == nil {
+		return nil
+	}
+
+	val, err := m.db.GetSpec()
+	if err != nil || val == nil {
+		return err
+	}
+	spec := &ClusterSpec{}
+	if err := json.Unmarshal(val, spec); err != nil {
+		return err
+	}
+	if err := validateSpec(spec, m.config.Manager.HostGroups); err != nil {
+		logrus.Errorf("the applied spec is not valid anymore, it needs to be applied again. Error: %v", err)
+		return nil
+	}
+	m.spec = spec
+	return nil
+}
+
+// specReconcileLoop periodically posts the event to reconcile the cluster to
+// the applied spec
+func (m *Manager) specReconcileLoop(interval time.Duration) {
+	for range time.Tick(interval) {
+		m.reqQ <- newSpecReconcileEvent(m)
+	}
+}
+
+// applySpecEvent applies a spec, or removes the applied spec when the spec is
+// nil. The cluster is reconciled to an applied spec right away.
+type applySpecEvent struct {
+	mgr  *Manager
+	spec *ClusterSpec
+
+	_plan *SpecPlan
+}
+
+// newApplySpecEvent creates and returns applySpecEvent
+func newApplySpecEvent(mgr *Manager, spec *ClusterSpec) *applySpecEvent {
+	return &applySpecEvent{
+		mgr:  mgr,
+		spec: spec,
+	}
+}
+
+func (e *applySpecEvent) String() string {
+	return fmt.Sprintf("applySpecEvent: %+v", e.spec)
+}
+
+func (e *applySpecEvent) process() error {
+	if e.spec == nil {
+		logrus.Infof("removing the applied spec, the cluster is not reconciled anymore")
+		return e.mgr.setSpec(nil)
+	}
+
+	if err := validateSpec(e.spec, e.mgr.hostGroups()); err != nil {
+		return err
+	}
+	if err := e.mgr.setSpec(e.spec); err != nil {
+		return err
+	}
+	e._plan = e.mgr.planSpec(e.spec)
+	return newSpecReconcileEvent(e.mgr).process()
+}
+
+// planSpecEvent returns the plan of a spec, or of the applied spec when the
+// spec is nil, without acting on it
+type planSpecEvent struct {
+	mgr  *Manager
+	spec *ClusterSpec
+
+	_info *SpecInfo
+}
+
+// newPlanSpecEvent creates and returns planSpecEvent
+func newPlanSpecEvent(mgr *Manager, spec *ClusterSpec) *planSpecEvent {
+	return &planSpecEvent{
+		mgr:  mgr,
+		spec: spec,
+	}
+}
+
+func (e *planSpecEvent) String() string {
+	return fmt.Sprintf("planSpecEvent: %+v", e.spec)
+}
+
+func (e *planSpecEvent) process() error {
+	spec := e.spec
+	if spec == nil {
+		spec = e.mgr.spec
+	} else if err := validateSpec(spec, e.mgr.hostGroups()); err != nil {
+		return err
+	}
+
+	e._info = &SpecInfo{Spec: spec}
+	if spec != nil {
+		e._info.Plan = e.mgr.planSpec(spec)
+	}
+	return nil
+}
+
+// specReconcileEvent starts the job for the next action that converges the
+// cluster to the applied spec. The nodes are commissioned first, followed by
+// the updates of host-group and then the decommissions, one job at a time. The
+// cluster is reconciled again when the job succeeds, a failed job is retried
+// on the next periodic reconcile.
+type specReconcileEvent struct {
+	mgr *Manager
+}
+
+// newSpecReconcileEvent creates and returns specReconcileEvent
+func newSpecReconcileEvent(mgr *Manager) *specReconcileEvent {
+	return &specReconcileEvent{
+		mgr: mgr,
+	}
+}
+
+func (e *specReconcileEvent) String() string {
+	return "specReconcileEvent"
+}
+
+func (e *specReconcileEvent) process() error {
+	m := e.mgr
+	if m.spec == nil {
+		return nil
+	}
+	if m.specJob != nil {
+		if status, _ := m.specJob.Status(); status == Queued || status == Running {
+			return nil
+		}
+	}
+
+	plan := m.planSpec(m.spec)
+	if plan.converged() {
+		return nil
+	}
+	var je jobEvent
+	switch {
+	case len(plan.Commission) > 0:
+		je = newCommissionEventByHostGroup(m, plan.Commission, m.spec.ExtraVars, 0)
+	case len(plan.Update) > 0:
+		// the nodes are updated one host-group at a time
+		hostGroup := targetHostGroups(plan.Update)[0]
+		names := []string{}
+		for _, name := range sortedKeys(plan.Update) {
+			if plan.Update[name] == hostGroup {
+				names = append(names, name)
+			}
+		}
+		je = newUpdateEvent(m, names, m.spec.ExtraVars, hostGroup, 0, false)
+	default:
+		je = newDecommissionEvent(m, plan.Decommission, m.spec.ExtraVars, 0, false)
+	}
+
+	logrus.Infof("reconciling the cluster to the applied spec with %s", je)
+	if err := je.process(); err != nil && err != errJobQueued {
+		logrus.Errorf("failed to reconcile the cluster to the applied spec. Error: %v", err)
+		return err
+	}
+	job := je.job()
+	m.specJob = job
+	go func() {
+		job.Wait()
+		if status, _ := job.Status(); status == Complete {
+			m.reqQ <- newSpecReconcileEvent(m)
+		}
+	}()
+	return nil
+}
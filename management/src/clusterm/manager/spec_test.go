@@ -0,0 +1,193 @@
+// +build unittest
+
+package manager
+
+import (
+	"time"
+
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/cluster/management/src/monitor"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type specSuite struct {
+}
+
+var _ = Suite(&specSuite{})
+
+func (s *specSuite) TestValidateSpec(c *C) {
+	groups := testHostGroups()
+	spec := &ClusterSpec{
+		HostGroups: map[string]HostGroupSpec{
+			ansibleMasterGroupName: {Nodes: []string{"node1"}},
+			storageGroupName:       {Count: 2, Selector: NodeSelector{LabelPattern: "^rack1-"}},
+		},
+	}
+	c.Assert(validateSpec(spec, groups), IsNil)
+	c.Assert(spec.ExtraVars, Equals, configuration.DefaultValidJSON)
+
+	tests := map[string]*ClusterSpec{
+		"undefined-host-group": {
+			HostGroups: map[string]HostGroupSpec{"edge": {Count: 1}},
+		},
+		"negative-count": {
+			HostGroups: map[string]HostGroupSpec{storageGroupName: {Count: -1}},
+		},
+		"nodes-and-count": {
+			HostGroups: map[string]HostGroupSpec{storageGroupName: {Count: 1, Nodes: []string{"node1"}}},
+		},
+		"invalid-selector": {
+			HostGroups: map[string]HostGroupSpec{storageGroupName: {Count: 1, Selector: NodeSelector{SerialPattern: "("}}},
+		},
+		"node-in-two-host-groups": {
+			HostGroups: map[string]HostGroupSpec{
+				ansibleMasterGroupName: {Nodes: []string{"node1"}},
+				ansibleWorkerGroupName: {Nodes: []string{"node1"}},
+			},
+		},
+		"invalid-extra-vars": {
+			HostGroups: map[string]HostGroupSpec{ansibleMasterGroupName: {Nodes: []string{"node1"}}},
+			ExtraVars:  "{",
+		},
+	}
+	for testname, spec := range tests {
+		c.Assert(validateSpec(spec, groups), NotNil, Commentf("test: %s", testname))
+	}
+}
+
+// newSpecTestManager returns a manager with reachable nodes in the specified
+// status, commissioned in the specified host-groups
+func newSpecTestManager(c *C, ctrl *gomock.Controller, assets map[string]inventory.AssetStatus,
+	groups map[string]string) *Manager {
+	mgr := newVerifyTestManager(c, ctrl, assets, nil, nil)
+	mgr.config.Ansible.VerifyPlaybook = ""
+	mgr.config.Manager.HostGroups = testHostGroups()
+	for name, n := range mgr.nodes {
+		n.Mon = monitor.NewNode(name, "", "")
+		if group, ok := groups[name]; ok {
+			n.Cfg.(*configuration.AnsibleHost).SetGroup(group)
+		}
+	}
+	return mgr
+}
+
+func (s *specSuite) TestPlanSpec(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newSpecTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"master1":   inventory.Allocated,
+		"master2":   inventory.Allocated,
+		"worker1":   inventory.Allocated,
+		"storage1":  inventory.Allocated,
+		"rack1-a":   inventory.Unallocated,
+		"rack1-b":   inventory.Decommissioned,
+		"rack1-c":   inventory.Unallocated,
+		"rack2-a":   inventory.Unallocated,
+		"upgrading": inventory.Provisioning,
+	}, map[string]string{
+		"master1":  ansibleMasterGroupName,
+		"master2":  ansibleMasterGroupName,
+		"worker1":  ansibleWorkerGroupName,
+		"storage1": storageGroupName,
+	})
+	spec := &ClusterSpec{
+		HostGroups: map[string]HostGroupSpec{
+			ansibleMasterGroupName: {Nodes: []string{"master1", "missing"}},
+			ansibleWorkerGroupName: {Nodes: []string{"master2", "upgrading"}},
+			storageGroupName:       {Count: 3, Selector: NodeSelector{LabelPattern: "^(storage|rack1-)"}},
+		},
+	}
+	c.Assert(validateSpec(spec, mgr.hostGroups()), IsNil)
+
+	plan := mgr.planSpec(spec)
+	c.Assert(plan.Commission, DeepEquals, map[string]string{
+		"rack1-a": storageGroupName,
+		"rack1-c": storageGroupName,
+	})
+	c.Assert(plan.Update, DeepEquals, map[string]string{"master2": ansibleWorkerGroupName})
+	c.Assert(plan.Decommission, DeepEquals, []string{"worker1"})
+	c.Assert(plan.Pending, HasLen, 2)
+	c.Assert(plan.Pending[0], Matches, ".*node \"missing\" of host-group \"service-master\" doesn't exist.*")
+	c.Assert(plan.Pending[1], Matches, ".*node \"upgrading\" is in \"Provisioning\" status.*")
+	c.Assert(plan.converged(), Equals, false)
+
+	// the count can't be met when the selector doesn't match enough spare nodes
+	spec.HostGroups[storageGroupName] = HostGroupSpec{Count: 3, Selector: NodeSelector{LabelPattern: "^rack2-"}}
+	plan = mgr.planSpec(spec)
+	c.Assert(plan.Commission, DeepEquals, map[string]string{"rack2-a": storageGroupName})
+	c.Assert(plan.Decommission, DeepEquals, []string{"storage1", "worker1"})
+	c.Assert(plan.Pending[1], Matches, ".*host-group \"storage\" has 1 of 3 nodes.*")
+}
+
+// processNextEvent processes the next event posted to the manager's queue
+func processNextEvent(c *C, mgr *Manager) {
+	select {
+	case e := <-mgr.reqQ:
+		c.Assert(e.process(), IsNil)
+	case <-time.After(5 * time.Second):
+		c.Fatalf("timed out waiting for an event")
+	}
+}
+
+func (s *specSuite) TestApplySpec(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newSpecTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Unallocated,
+		"node2": inventory.Unallocated,
+		"node3": inventory.Unallocated,
+		"node4": inventory.Allocated,
+	}, map[string]string{"node4": ansibleMasterGroupName})
+	spec := &ClusterSpec{
+		HostGroups: map[string]HostGroupSpec{
+			ansibleMasterGroupName: {Nodes: []string{"node1"}},
+			ansibleWorkerGroupName: {Count: 2},
+		},
+	}
+
+	// the nodes are commissioned first
+	e := newApplySpecEvent(mgr, spec)
+	c.Assert(e.process(), IsNil)
+	c.Assert(e._plan.Commission, DeepEquals, map[string]string{
+		"node1": ansibleMasterGroupName,
+		"node2": ansibleWorkerGroupName,
+		"node3": ansibleWorkerGroupName,
+	})
+	c.Assert(e._plan.Decommission, DeepEquals, []string{"node4"})
+	c.Assert(mgr.spec, Equals, spec)
+	mgr.specJob.Wait()
+	c.Assert(mgr.specJob.Info().Status, Equals, Complete.String())
+
+	// followed by the decommission, once the commission succeeds
+	commissionJob := mgr.specJob
+	processNextEvent(c, mgr)
+	c.Assert(mgr.specJob, Not(Equals), commissionJob)
+	mgr.specJob.Wait()
+	c.Assert(mgr.specJob.Info().Status, Equals, Complete.String())
+	for _, name := range []string{"node1", "node2", "node3"} {
+		c.Assert(assetStatus(mgr, name), Equals, inventory.Allocated)
+	}
+	c.Assert(assetStatus(mgr, "node4"), Equals, inventory.Decommissioned)
+
+	// the cluster has converged to the spec
+	decommissionJob := mgr.specJob
+	processNextEvent(c, mgr)
+	c.Assert(mgr.specJob, Equals, decommissionJob)
+	c.Assert(mgr.planSpec(spec).converged(), Equals, true)
+
+	pe := newPlanSpecEvent(mgr, nil)
+	c.Assert(pe.process(), IsNil)
+	c.Assert(pe._info.Spec, Equals, spec)
+	c.Assert(pe._info.Plan.converged(), Equals, true)
+
+	// the cluster is not reconciled once the spec is removed
+	c.Assert(newApplySpecEvent(mgr, nil).process(), IsNil)
+	c.Assert(mgr.spec, IsNil)
+	pe = newPlanSpecEvent(mgr, nil)
+	c.Assert(pe.process(), IsNil)
+	c.Assert(pe._info.Plan, IsNil)
+}
@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/contiv/cluster/management/src/inventory"
+)
+
+// The actors that cause the status transitions of the assets. The actor of a
+// transition is the one that started the job on the node, or the one that
+// originated the event that changed the status outside of a job.
+const (
+	// ActorUser is the operator, through the REST api or clusterctl
+	ActorUser = "user"
+	// ActorMonitor is the node monitor, on the discovery or disappearance of a node
+	ActorMonitor = "monitor"
+	// ActorAutoCommission is the auto-commission policy
+	ActorAutoCommission = "auto-commission"
+	// ActorRemediation is the remediation policy
+	ActorRemediation = "remediation"
+	// ActorSpec is the periodic reconciliation of the cluster to the applied spec
+	ActorSpec = "spec"
+	// ActorReconcile is the reconciliation of the assets left in a transitional
+	// status when clusterm restarts
+	ActorReconcile = "reconcile"
+	// ActorMaintenanceExpiry is the expiry of a node's maintenance window
+	ActorMaintenanceExpiry = "maintenance-expiry"
+)
+
+// eventActor returns the actor that originated the event. The events that are
+// not originated by clusterm itself are posted by the operator.
+func eventActor(e event) string {
+	switch ev := e.(type) {
+	case nil:
+		return ActorUser
+	case *waitableEvent:
+		return eventActor(ev.inEvent)
+	case *discoveredEvent, *disappearedEvent:
+		return ActorMonitor
+	case *autoCommissionEvent, *autoCommissionGroupEvent:
+		return ActorAutoCommission
+	case *remediationEvent:
+		return ActorRemediation
+	case *specReconcileEvent:
+		return ActorSpec
+	case *reconcileEvent:
+		return ActorReconcile
+	case *maintenanceExpiryEvent:
+		return ActorMaintenanceExpiry
+	default:
+		return ActorUser
+	}
+}
+
+// setCurrentEvent sets the event being processed by the event loop
+func (m *Manager) setCurrentEvent(e event) {
+	m.eventMutex.Lock()
+	defer m.eventMutex.Unlock()
+	m.currentEvent = e
+}
+
+// getCurrentEvent returns the event being processed by the event loop, nil if none
+func (m *Manager) getCurrentEvent() event {
+	m.eventMutex.Lock()
+	defer m.eventMutex.Unlock()
+	return m.currentEvent
+}
+
+// currentActor returns the actor of the event being processed by the event loop
+func (m *Manager) currentActor() string {
+	return eventActor(m.getCurrentEvent())
+}
+
+// transitionCause returns the cause of a status transition of the asset, that
+// is recorded in it's transition history by the inventory. The transitions of
+// a node locked by a job are caused by that job, the rest are caused by the
+// event being processed.
+func (m *Manager) transitionCause(name string) inventory.TransitionCause {
+	if j := m.locks.owner(name); j != nil {
+		actor := j.actor
+		if actor == "" {
+			actor = ActorUser
+		}
+		return inventory.TransitionCause{
+			Trigger: fmt.Sprintf("job %d", j.id),
+			Actor:   actor,
+		}
+	}
+
+	e := m.getCurrentEvent()
+	cause := inventory.TransitionCause{Actor: eventActor(e)}
+	if e != nil {
+		cause.Trigger = e.String()
+	}
+	return cause
+}
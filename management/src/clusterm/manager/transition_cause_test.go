@@ -0,0 +1,89 @@
+// +build unittest
+
+package manager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+	"github.com/golang/mock/gomock"
+	. "gopkg.in/check.v1"
+)
+
+type transitionCauseSuite struct {
+}
+
+var _ = Suite(&transitionCauseSuite{})
+
+func (s *transitionCauseSuite) TestEventActor(c *C) {
+	tests := map[string]struct {
+		e     event
+		exptd string
+	}{
+		"none":               {nil, ActorUser},
+		"user":               {&commissionEvent{}, ActorUser},
+		"waitable-user":      {newWaitableEvent(&decommissionEvent{}), ActorUser},
+		"monitor":            {&discoveredEvent{}, ActorMonitor},
+		"auto-commission":    {&autoCommissionGroupEvent{}, ActorAutoCommission},
+		"remediation":        {&remediationEvent{}, ActorRemediation},
+		"waitable-reconcile": {newWaitableEvent(&reconcileEvent{}), ActorReconcile},
+	}
+	for testname, test := range tests {
+		c.Assert(eventActor(test.e), Equals, test.exptd, Commentf("test: %s", testname))
+	}
+}
+
+func (s *transitionCauseSuite) TestJobTransitionCause(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr, client := newAssetLogsTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Unallocated,
+	}, errored.Errorf("test failure"))
+
+	// the job started while processing an event takes the event's actor
+	mgr.setCurrentEvent(&remediationEvent{mgr: mgr, name: "node2"})
+	e := newCommissionEvent(mgr, []string{"node1"}, configuration.DefaultValidJSON, ansibleMasterGroupName, 0)
+	c.Assert(e.process(), IsNil)
+	mgr.setCurrentEvent(nil)
+	waitForJobReset(c, mgr, e._job)
+
+	transitions := client.transitions["node1"]
+	c.Assert(transitions, HasLen, 2)
+	c.Assert(transitions[0].FromStatus, Equals, inventory.Unallocated.String())
+	c.Assert(transitions[0].ToStatus, Equals, inventory.Provisioning.String())
+	c.Assert(transitions[1].FromStatus, Equals, inventory.Provisioning.String())
+	c.Assert(transitions[1].ToStatus, Equals, inventory.Unallocated.String())
+	for _, t := range transitions {
+		c.Assert(t.TransitionCause, DeepEquals, inventory.TransitionCause{Trigger: "job 1", Actor: ActorRemediation})
+	}
+
+	// a transition outside of a job is caused by the event being processed
+	re := newReconcileEvent(mgr, ReconcileRollback)
+	mgr.setCurrentEvent(re)
+	c.Assert(mgr.inventory.SetAssetDisappeared("node1"), IsNil)
+	mgr.setCurrentEvent(nil)
+	transitions = client.transitions["node1"]
+	c.Assert(transitions, HasLen, 3)
+	c.Assert(transitions[2].TransitionCause, DeepEquals, inventory.TransitionCause{Trigger: re.String(), Actor: ActorReconcile})
+
+	// the history is served by the REST endpoint
+	out, err := mgr.nodeTransitionsGet(&APIRequest{Nodes: []string{"node1"}})
+	c.Assert(err, IsNil)
+	body, err := ioutil.ReadAll(out)
+	c.Assert(err, IsNil)
+	rcvd := []inventory.AssetTransition{}
+	c.Assert(json.Unmarshal(body, &rcvd), IsNil)
+	c.Assert(rcvd, HasLen, 3)
+
+	_, err = mgr.nodeTransitionsGet(&APIRequest{Nodes: []string{"node1"}, Query: map[string][]string{
+		transitionsQueryFrom: {time.Now().String()},
+	}})
+	c.Assert(err, ErrorMatches, ".*Invalid value specified for query parameter.*")
+	_, err = mgr.nodeTransitionsGet(&APIRequest{Nodes: []string{"node2"}})
+	c.Assert(err, ErrorMatches, ".*doesn't exists.*")
+}
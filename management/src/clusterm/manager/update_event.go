@@ -3,6 +3,7 @@ package manager
 import (
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/contiv/cluster/management/src/configuration"
@@ -15,48 +16,97 @@ type updateEvent struct {
 	nodeNames []string
 	extraVars string
 	hostGroup string
+	priority  int
+	// force updates the reachable nodes even if some of the nodes are not
+	// reachable. The nodes that are not reachable are left out of the update and
+	// are set as unallocated, and the failure of the first cleanup doesn't stop
+	// the configuration of the rest of the nodes.
+	force bool
+	// selector is the label selector of the nodes, used instead of nodeNames
+	selector string
 
+	_job    *Job
 	_hosts  configuration.SubsysHosts
 	_enodes map[string]*node
+	// the nodes that are not reachable and are left out, when the update is forced
+	_skipped []string
 }
 
 // newUpdateEvent creates and returns updateEvent
-func newUpdateEvent(mgr *Manager, nodeNames []string, extraVars, hostGroup string) *updateEvent {
+func newUpdateEvent(mgr *Manager, nodeNames []string, extraVars, hostGroup string, priority int, force bool) *updateEvent {
 	return &updateEvent{
 		mgr:       mgr,
 		nodeNames: nodeNames,
 		extraVars: extraVars,
 		hostGroup: hostGroup,
+		priority:  priority,
+		force:     force,
+	}
+}
+
+// newUpdateEventBySelector creates and returns updateEvent that updates the
+// nodes whose labels match the selector
+func newUpdateEventBySelector(mgr *Manager, selector, extraVars, hostGroup string, priority int, force bool) *updateEvent {
+	return &updateEvent{
+		mgr:       mgr,
+		selector:  selector,
+		extraVars: extraVars,
+		hostGroup: hostGroup,
+		priority:  priority,
+		force:     force,
 	}
 }
 
 func (e *updateEvent) String() string {
-	return fmt.Sprintf("updateEvent: nodes: %v extra-vars: %v host-group: %q", e.nodeNames, e.extraVars, e.hostGroup)
+	if e.selector != "" {
+		return fmt.Sprintf("updateEvent: nodes: %v selector: %q extra-vars: %v host-group: %q force: %v",
+			e.nodeNames, e.selector, e.extraVars, e.hostGroup, e.force)
+	}
+	return fmt.Sprintf("updateEvent: nodes: %v extra-vars: %v host-group: %q force: %v",
+		e.nodeNames, e.extraVars, e.hostGroup, e.force)
+}
+
+func (e *updateEvent) job() *Job {
+	return e._job
 }
 
 func (e *updateEvent) process() error {
 	// err shouldn't be redefined below
 	var err error
 
-	err = e.mgr.checkAndSetActiveJob(
-		e.String(),
-		e.updateRunner,
-		func(status JobStatus, errRet error) {
-			if status == Errored {
-				logrus.Errorf("configuration job failed. Error: %v", errRet)
-				// set assets as unallocated
-				e.mgr.setAssetsStatusBestEffort(e.nodeNames, e.mgr.inventory.SetAssetUnallocated)
-				return
+	// the job is created once, the event is processed again if the job is queued
+	if e._job == nil {
+		// the nodes are selected once, when the event is first processed
+		if e.selector != "" {
+			if e.nodeNames, err = e.mgr.nodesByLabels(e.selector); err != nil {
+				return err
 			}
-			// set assets as commissioned
-			e.mgr.setAssetsStatusBestEffort(e.nodeNames, e.mgr.inventory.SetAssetCommissioned)
-		})
-	if err != nil {
+		}
+		e._job = e.mgr.newJob(
+			e.String(),
+			e.updateRunner,
+			func(status JobStatus, errRet error) {
+				if status == Errored {
+					logrus.Errorf("configuration job failed. Error: %v", errRet)
+				}
+				// set the assets that were configured as commissioned and
+				// rest of the assets as unallocated
+				okNodes, failedNodes := splitNodesByResult(e.reachableNodes(), e._job.Results())
+				e.mgr.setAssetsStatusBestEffort(okNodes, e.mgr.inventory.SetAssetCommissioned)
+				e.mgr.clearDegraded(okNodes)
+				e.mgr.setAssetsStatusBestEffort(failedNodes, e.mgr.inventory.SetAssetUnallocated)
+				e.setSkippedUnallocated()
+			},
+			e.nodeNames)
+		e._job.setParams(jobKindUpdate, e.extraVars, uniformHostGroups(e.nodeNames, e.hostGroup))
+	}
+	job := e._job
+	if err = e.mgr.checkAndSetActiveJob(job, e, e.priority); err != nil {
 		return err
 	}
 	defer func() {
 		if err != nil {
-			e.mgr.resetActiveJob()
+			e.mgr.resetActiveJob(job)
 		}
 	}()
 
@@ -77,7 +127,7 @@ func (e *updateEvent) process() error {
 	}
 
 	// trigger node upgrade event
-	go e.mgr.runActiveJob()
+	go e.mgr.runActiveJob(job)
 
 	return nil
 }
@@ -85,60 +135,51 @@ func (e *updateEvent) process() error {
 // eventValidate perfoms the validations
 func (e *updateEvent) eventValidate() error {
 	var err error
-	e._enodes, err = e.mgr.commonEventValidate(e.nodeNames)
+	if e.force {
+		e._enodes, err = e.mgr.forcedEventValidate(e.nodeNames)
+	} else {
+		e._enodes, err = e.mgr.commonEventValidate(e.nodeNames)
+	}
 	if err != nil {
 		return err
 	}
 
-	if e.hostGroup != "" && !IsValidHostGroup(e.hostGroup) {
-		return errored.Errorf("invalid host-group specified: %q", e.hostGroup)
-	}
-
-	// when workers are being configured, make sure that there is atleast one service-master
-	if e.hostGroup == ansibleWorkerGroupName {
-		masterCommissioned := false
-		for name := range e.mgr.nodes {
-			if _, ok := e._enodes[name]; ok {
-				// skip nodes in the event
-				continue
-			}
-
-			isDiscoveredAndAllocated, err := e.mgr.isDiscoveredAndAllocatedNode(name)
-			if err != nil || !isDiscoveredAndAllocated {
-				if err != nil {
-					logrus.Debugf("a node check failed for %q. Error: %s", name, err)
-				}
-				// skip hosts that are not yet provisioned or not in discovered state
-				continue
-			}
-
-			isMasterNode, err := e.mgr.isMasterNode(name)
-			if err != nil || !isMasterNode {
-				if err != nil {
-					logrus.Debugf("a node check failed for %q. Error: %s", name, err)
-				}
-				//skip the hosts that are not in master group
-				continue
+	// the nodes that are not reachable are found here, as the nodes shall not
+	// be looked up once the job is running
+	e._skipped = nil
+	if e.force {
+		for _, name := range e.nodeNames {
+			if isDiscovered, _ := e.mgr.isDiscoveredNode(name); !isDiscovered {
+				e._skipped = append(e._skipped, name)
 			}
-
-			// found a master node
-			masterCommissioned = true
-			break
 		}
-		if !masterCommissioned {
-			return errored.Errorf("Updating these nodes as worker will result in no master node in the cluster, make sure atleast one node is commissioned as master.")
+		sort.Strings(e._skipped)
+		if len(e._skipped) == len(e.nodeNames) {
+			return errored.Errorf("none of the nodes %v are reachable, atleast one node should be reachable to update by force", e._skipped)
 		}
 	}
-	return nil
+
+	// the host-groups are checked only when the nodes are moved to a host-group
+	if e.hostGroup == "" {
+		return nil
+	}
+	if !e.mgr.isValidHostGroup(e.hostGroup) {
+		return errored.Errorf("invalid host-group specified: %q", e.hostGroup)
+	}
+
+	return e.mgr.checkHostGroups(e._enodes, e.hostGroup, "update")
 }
 
 // pepareInventory prepares the inventory for update event.
 func (e *updateEvent) pepareInventory() error {
 	hosts := []*configuration.AnsibleHost{}
-	for _, node := range e._enodes {
+	for name, node := range e._enodes {
 		host := node.Cfg.(*configuration.AnsibleHost)
 		if e.hostGroup != "" {
 			host.SetGroup(e.hostGroup)
+			if err := e.mgr.saveNodeConfig(name); err != nil {
+				return err
+			}
 		}
 		hosts = append(hosts, host)
 	}
@@ -147,23 +188,80 @@ func (e *updateEvent) pepareInventory() error {
 	return nil
 }
 
+// reachableNodes returns the nodes to update. When the update is forced, the
+// nodes that are not reachable are left out.
+func (e *updateEvent) reachableNodes() []string {
+	if len(e._skipped) == 0 {
+		return e.nodeNames
+	}
+	reachable := []string{}
+	for _, name := range e.nodeNames {
+		if !containsString(e._skipped, name) {
+			reachable = append(reachable, name)
+		}
+	}
+	return reachable
+}
+
+// setSkippedUnallocated sets the assets of the nodes that were left out of a
+// forced update as unallocated, recording the reason in the inventory
+func (e *updateEvent) setSkippedUnallocated() {
+	for _, name := range e._skipped {
+		if err := e.mgr.inventory.SetAssetUnallocatedWithReason(name,
+			"Node was not updated by the forced update, as it was not reachable"); err != nil {
+			logrus.Errorf("failed to update %s's state in inventory, Error: %v", name, err)
+		}
+	}
+}
+
 // updateRunner is the job runner that runs a cleanup playbook followed by provision playbook
-// on one or more nodes. In case of provision failure the cleanup playbook it run again.
+// on one or more nodes. In case of provision failure the cleanup playbook it run again on
+// the nodes where provisioning failed. If the job is cancelled, the cleanup playbook is run
+// to completion. When the update is forced, the nodes that are not reachable are left out
+// and a failure of the first cleanup is ignored.
 func (e *updateEvent) updateRunner(cancelCh CancelChannel, jobLogs io.Writer) error {
-	outReader, cancelFunc, errCh := e.mgr.configuration.Cleanup(e._hosts, e.extraVars)
+	nodeNames := e.reachableNodes()
+	hosts := e._hosts
+	// none of the nodes are configured, if the first cleanup fails
+	results := uniformResults(nodeNames, configuration.HostResultFailed)
+	if len(e._skipped) > 0 {
+		fmt.Fprintf(jobLogs, "skipping nodes %v as they are not reachable and the update is forced\n", e._skipped)
+		hosts = filterHosts(e._hosts, nodeNames)
+		for _, name := range e._skipped {
+			results[name] = configuration.HostResultUnreachable
+		}
+	}
+	e._job.setResults(results)
+	outReader, cancelFunc, errCh := e.mgr.configuration.Cleanup(hosts, e.extraVars)
 	if err := logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs); err != nil {
 		logrus.Errorf("first cleanup failed. Error: %s", err)
-		// XXX: is there a case where we should continue on error here?
-		return err
+		if err == errJobCancelled {
+			// finish the interrupted cleanup
+			outReader, cancelFunc, errCh = e.mgr.configuration.Cleanup(hosts, e.extraVars)
+			if err := logOutputAndReturnStatus(outReader, errCh, nil, cancelFunc, jobLogs); err != nil {
+				logrus.Errorf("cleanup after cancellation failed. Error: %s", err)
+			}
+			return err
+		}
+		if !e.force {
+			return err
+		}
+		fmt.Fprintf(jobLogs, "ignoring the cleanup failure as the update is forced. Error: %v\n", err)
 	}
-	outReader, cancelFunc, errCh = e.mgr.configuration.Configure(e._hosts, e.extraVars)
+	outReader, cancelFunc, errCh = e.mgr.configuration.Configure(hosts, e.extraVars)
 	cfgErr := logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs)
+	for name, result := range stepResults(nodeNames, cfgErr) {
+		results[name] = result
+	}
+	e._job.setResults(results)
 	if cfgErr == nil {
 		return nil
 	}
 	logrus.Errorf("configuration failed, starting cleanup. Error: %s", cfgErr)
-	outReader, cancelFunc, errCh = e.mgr.configuration.Cleanup(e._hosts, e.extraVars)
-	if err := logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs); err != nil {
+	_, failedNodes := splitNodesByResult(nodeNames, results)
+	outReader, cancelFunc, errCh = e.mgr.configuration.Cleanup(filterHosts(hosts, failedNodes), e.extraVars)
+	if err := logOutputAndReturnStatus(outReader, errCh, cleanupCancelChannel(cancelCh, cfgErr),
+		cancelFunc, jobLogs); err != nil {
 		logrus.Errorf("second cleanup failed. Error: %s", err)
 	}
 
@@ -0,0 +1,263 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+)
+
+func errInvalidBatchSize(batchSize int) error {
+	return errored.Errorf("invalid batch size specified: %d, it should be atleast 1", batchSize)
+}
+
+func errInvalidMaxUnavailable(maxUnavailable, batchSize int) error {
+	return errored.Errorf("invalid max-unavailable specified: %d, it should be 0 (no limit) or atleast the batch size %d", maxUnavailable, batchSize)
+}
+
+func errTooManyUnavailable(unavailable, maxUnavailable int) error {
+	return errored.Errorf("rollout stopped as %d node(s) in the cluster are unavailable, which leaves no room for a batch with max-unavailable %d", unavailable, maxUnavailable)
+}
+
+// upgradeEvent triggers the rolling upgrade workflow. The nodes are upgraded
+// in batches, one batch at a time, and the rollout stops when a batch fails.
+type upgradeEvent struct {
+	mgr            *Manager
+	nodeNames      []string
+	extraVars      string
+	batchSize      int
+	maxUnavailable int
+	priority       int
+
+	_job    *Job
+	_hosts  map[string]*configuration.AnsibleHost
+	_enodes map[string]*node
+}
+
+// newUpgradeEvent creates and returns upgradeEvent
+func newUpgradeEvent(mgr *Manager, nodeNames []string, extraVars string, batchSize, maxUnavailable, priority int) *upgradeEvent {
+	return &upgradeEvent{
+		mgr:            mgr,
+		nodeNames:      nodeNames,
+		extraVars:      extraVars,
+		batchSize:      batchSize,
+		maxUnavailable: maxUnavailable,
+		priority:       priority,
+	}
+}
+
+func (e *upgradeEvent) String() string {
+	return fmt.Sprintf("upgradeEvent: nodes: %v extra-vars: %v batch-size: %d max-unavailable: %d",
+		e.nodeNames, e.extraVars, e.batchSize, e.maxUnavailable)
+}
+
+func (e *upgradeEvent) job() *Job {
+	return e._job
+}
+
+func (e *upgradeEvent) process() error {
+	// err shouldn't be redefined below
+	var err error
+
+	// the job is created once, the event is processed again if the job is queued
+	if e._job == nil {
+		e._job = e.mgr.newJob(
+			e.String(),
+			e.upgradeRunner,
+			func(status JobStatus, errRet error) {
+				// the status of the assets is set by the runner as each batch is done
+				if status == Errored {
+					logrus.Errorf("upgrade job failed. Error: %v", errRet)
+				}
+			},
+			e.nodeNames)
+		e._job.setParams(jobKindUpgrade, e.extraVars, nil)
+	}
+	job := e._job
+	if err = e.mgr.checkAndSetActiveJob(job, e, e.priority); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			e.mgr.resetActiveJob(job)
+		}
+	}()
+
+	// validate event data
+	if err = e.eventValidate(); err != nil {
+		return err
+	}
+
+	// prepare inventory
+	if err = e.prepareInventory(); err != nil {
+		return err
+	}
+
+	// trigger the rolling upgrade
+	go e.mgr.runActiveJob(job)
+
+	return nil
+}
+
+// eventValidate perfoms the validations
+func (e *upgradeEvent) eventValidate() error {
+	var err error
+	e._enodes, err = e.mgr.commonEventValidate(e.nodeNames)
+	if err != nil {
+		return err
+	}
+
+	if e.batchSize < 1 {
+		return errInvalidBatchSize(e.batchSize)
+	}
+	if e.maxUnavailable < 0 || (e.maxUnavailable > 0 && e.maxUnavailable < e.batchSize) {
+		return errInvalidMaxUnavailable(e.maxUnavailable, e.batchSize)
+	}
+
+	// only the commissioned nodes can be upgraded
+	for _, name := range e.nodeNames {
+		isDiscoveredAndAllocated, err := e.mgr.isDiscoveredAndAllocatedNode(name)
+		if err != nil {
+			return err
+		}
+		if !isDiscoveredAndAllocated {
+			return errored.Errorf("node %q is not commissioned, only the commissioned nodes can be upgraded", name)
+		}
+	}
+	return nil
+}
+
+// prepareInventory prepares the inventory for upgrade event.
+func (e *upgradeEvent) prepareInventory() error {
+	e._hosts = map[string]*configuration.AnsibleHost{}
+	for name, node := range e._enodes {
+		e._hosts[name] = node.Cfg.(*configuration.AnsibleHost)
+	}
+	return nil
+}
+
+// upgradeRunner is the job runner that runs the upgrade playbook on the nodes,
+// one batch at a time. The nodes of a batch are put in maintenance while they
+// are upgraded and are set back as commissioned once the batch is done. If a
+// batch fails or the job is cancelled, the nodes of the batch where the upgrade
+// didn't succeed are set as unallocated and the rest of the nodes are left as is.
+func (e *upgradeEvent) upgradeRunner(cancelCh CancelChannel, jobLogs io.Writer) error {
+	pending := append([]string{}, e.nodeNames...)
+	sort.Strings(pending)
+	results := map[string]configuration.HostResult{}
+	for batchNum := 1; len(pending) > 0; batchNum++ {
+		select {
+		case <-cancelCh:
+			return errJobCancelled
+		default:
+		}
+
+		// the batch is picked and set in maintenance in the event loop, as
+		// it needs to look at the status of all the nodes in the cluster
+		be := newUpgradeBatchEvent(e, pending)
+		me := newWaitableEvent(be)
+		e.mgr.reqQ <- me
+		if err := me.waitForCompletion(); err != nil {
+			logrus.Errorf("failed to start batch %d of upgrade. Error: %s", batchNum, err)
+			return err
+		}
+		batch := be._batch
+		pending = pending[len(batch):]
+
+		fmt.Fprintf(jobLogs, "upgrading batch %d, nodes: %v\n", batchNum, batch)
+		hosts := []*configuration.AnsibleHost{}
+		for _, name := range batch {
+			hosts = append(hosts, e._hosts[name])
+		}
+		outReader, cancelFunc, errCh := e.mgr.configuration.Upgrade(hosts, e.extraVars)
+		err := logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs)
+		for name, result := range stepResults(batch, err) {
+			results[name] = result
+		}
+		e._job.setResults(results)
+		okNodes, failedNodes := splitNodesByResult(batch, results)
+		e.mgr.setAssetsStatusBestEffort(okNodes, e.mgr.inventory.SetAssetCommissioned)
+		e.mgr.clearDegraded(okNodes)
+		if err != nil {
+			logrus.Errorf("upgrade of batch %d failed, stopping the rollout. Error: %s", batchNum, err)
+			e.mgr.setAssetsStatusBestEffort(failedNodes, e.mgr.inventory.SetAssetUnallocated)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nextUpgradeBatch returns the nodes to upgrade next from the pending nodes. The
+// batch is trimmed so that the nodes in the cluster that are unavailable, along
+// with the batch, don't exceed maxUnavailable. A maxUnavailable of 0 means no limit.
+func nextUpgradeBatch(pending []string, batchSize, maxUnavailable, unavailable int) ([]string, error) {
+	size := batchSize
+	if maxUnavailable > 0 && unavailable+size > maxUnavailable {
+		size = maxUnavailable - unavailable
+	}
+	if size < 1 {
+		return nil, errTooManyUnavailable(unavailable, maxUnavailable)
+	}
+	if size > len(pending) {
+		size = len(pending)
+	}
+	return pending[:size], nil
+}
+
+// upgradeBatchEvent picks the next batch of a rolling upgrade and sets the
+// nodes of the batch in maintenance
+type upgradeBatchEvent struct {
+	ue      *upgradeEvent
+	pending []string
+
+	_batch []string
+}
+
+// newUpgradeBatchEvent creates and returns upgradeBatchEvent
+func newUpgradeBatchEvent(ue *upgradeEvent, pending []string) *upgradeBatchEvent {
+	return &upgradeBatchEvent{
+		ue:      ue,
+		pending: pending,
+	}
+}
+
+func (e *upgradeBatchEvent) String() string {
+	return fmt.Sprintf("upgradeBatchEvent: pending nodes: %v", e.pending)
+}
+
+func (e *upgradeBatchEvent) process() error {
+	var err error
+	mgr := e.ue.mgr
+	if e._batch, err = nextUpgradeBatch(e.pending, e.ue.batchSize, e.ue.maxUnavailable,
+		mgr.countUnavailableNodes(e.pending)); err != nil {
+		return err
+	}
+
+	return mgr.setAssetsStatusAtomic(e._batch, mgr.inventory.SetAssetInMaintenance,
+		mgr.inventory.SetAssetCommissioned)
+}
+
+// countUnavailableNodes returns the number of nodes, other than the specified
+// ones, that are being provisioned, are in maintenance or are commissioned but
+// have disappeared.
+func (m *Manager) countUnavailableNodes(skipNames []string) int {
+	count := 0
+	for name, node := range m.nodes {
+		if containsString(skipNames, name) || node.Inv == nil {
+			continue
+		}
+		status, state := node.Inv.GetStatus()
+		switch {
+		case status == inventory.Provisioning, status == inventory.Provisioned, status == inventory.Maintenance:
+			count++
+		case status == inventory.Allocated && state != inventory.Discovered:
+			count++
+		}
+	}
+	return count
+}
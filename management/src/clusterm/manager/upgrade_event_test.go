@@ -0,0 +1,67 @@
+// +build unittest
+
+package manager
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type upgradeSuite struct {
+}
+
+var _ = Suite(&upgradeSuite{})
+
+func (s *upgradeSuite) TestNextUpgradeBatch(c *C) {
+	pending := []string{"n1", "n2", "n3", "n4", "n5"}
+	tests := map[string]struct {
+		pending        []string
+		batchSize      int
+		maxUnavailable int
+		unavailable    int
+		exptdBatch     []string
+		exptdErr       error
+	}{
+		"batch-size": {
+			pending:    pending,
+			batchSize:  2,
+			exptdBatch: []string{"n1", "n2"},
+		},
+		"batch-size-more-than-pending": {
+			pending:    pending[3:],
+			batchSize:  3,
+			exptdBatch: []string{"n4", "n5"},
+		},
+		"max-unavailable": {
+			pending:        pending,
+			batchSize:      3,
+			maxUnavailable: 3,
+			unavailable:    1,
+			exptdBatch:     []string{"n1", "n2"},
+		},
+		"max-unavailable-not-reached": {
+			pending:        pending,
+			batchSize:      2,
+			maxUnavailable: 4,
+			unavailable:    1,
+			exptdBatch:     []string{"n1", "n2"},
+		},
+		"max-unavailable-reached": {
+			pending:        pending,
+			batchSize:      2,
+			maxUnavailable: 2,
+			unavailable:    2,
+			exptdErr:       errTooManyUnavailable(2, 2),
+		},
+	}
+
+	for key, test := range tests {
+		batch, err := nextUpgradeBatch(test.pending, test.batchSize, test.maxUnavailable, test.unavailable)
+		if test.exptdErr != nil {
+			c.Assert(err, NotNil, Commentf("key: %s", key))
+			c.Assert(err.Error(), Equals, test.exptdErr.Error(), Commentf("key: %s", key))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf("key: %s", key))
+		c.Assert(batch, DeepEquals, test.exptdBatch, Commentf("key: %s", key))
+	}
+}
@@ -2,6 +2,7 @@ package manager
 
 import (
 	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/configuration"
 	"github.com/contiv/cluster/management/src/inventory"
 	"github.com/contiv/errored"
 )
@@ -28,35 +29,13 @@ func (m *Manager) findNode(name string) (*node, error) {
 
 func (m *Manager) findNodeByMgmtAddr(addr string) (*node, error) {
 	for _, node := range m.nodes {
-		if node.Mon.GetMgmtAddress() == addr {
+		if node.Mon != nil && node.Mon.GetMgmtAddress() == addr {
 			return node, nil
 		}
 	}
 	return nil, nodeNotExistsError(addr)
 }
 
-func (m *Manager) isMasterNode(name string) (bool, error) {
-	n, err := m.findNode(name)
-	if err != nil {
-		return false, err
-	}
-	if n.Cfg == nil {
-		return false, nodeConfigNotExistsError(name)
-	}
-	return n.Cfg.GetGroup() == ansibleMasterGroupName, nil
-}
-
-func (m *Manager) isWorkerNode(name string) (bool, error) {
-	n, err := m.findNode(name)
-	if err != nil {
-		return false, err
-	}
-	if n.Cfg == nil {
-		return false, nodeConfigNotExistsError(name)
-	}
-	return n.Cfg.GetGroup() == ansibleWorkerGroupName, nil
-}
-
 func (m *Manager) isDiscoveredNode(name string) (bool, error) {
 	n, err := m.findNode(name)
 	if err != nil {
@@ -66,7 +45,8 @@ func (m *Manager) isDiscoveredNode(name string) (bool, error) {
 		return false, nodeInventoryNotExistsError(name)
 	}
 	_, state := n.Inv.GetStatus()
-	return state == inventory.Discovered, nil
+	// a degraded node is alive in monitoring subsystem as well
+	return state == inventory.Discovered || state == inventory.Degraded, nil
 }
 
 // areDiscovered checks if all nodes are in discovered state.
@@ -97,7 +77,67 @@ func (m *Manager) isDiscoveredAndAllocatedNode(name string) (bool, error) {
 		return false, nodeInventoryNotExistsError(name)
 	}
 	status, state := n.Inv.GetStatus()
-	return state == inventory.Discovered && status == inventory.Allocated, nil
+	return (state == inventory.Discovered || state == inventory.Degraded) && status == inventory.Allocated, nil
+}
+
+// clearDegraded sets the degraded nodes, among the specified ones, back to
+// discovered state. It is called for the nodes where the configuration was
+// pushed successfully.
+func (m *Manager) clearDegraded(names []string) {
+	degraded := []string{}
+	for _, name := range names {
+		asset := m.inventory.GetAsset(name)
+		if asset == nil {
+			continue
+		}
+		if _, state := asset.GetStatus(); state == inventory.Degraded {
+			degraded = append(degraded, name)
+		}
+	}
+	m.setAssetsStatusBestEffort(degraded, m.inventory.SetAssetDiscovered)
+}
+
+// newNodeConfig returns the configuration state for a discovered node. The host-group
+// and variables are restored from the inventory when the node was known before,
+// while the node's name and address are as discovered.
+func (m *Manager) newNodeConfig(name, addr string) *configuration.AnsibleHost {
+	// XXX: node's role/group shall come from manager's role assignment logic or
+	// from user configuration
+	group := ansibleMasterGroupName
+	vars := map[string]string{}
+	if asset := m.inventory.GetAsset(name); asset != nil && asset.GetConfig().Group != "" {
+		config := asset.GetConfig()
+		group = config.Group
+		for k, v := range config.Vars {
+			vars[k] = v
+		}
+	}
+	vars[ansibleNodeNameHostVar] = name
+	vars[ansibleNodeAddrHostVar] = addr
+	return configuration.NewAnsibleHost(name, addr, group, vars)
+}
+
+// saveNodeConfig records the configuration state of a node in the inventory, so
+// that it can be restored across restarts of clusterm
+func (m *Manager) saveNodeConfig(name string) error {
+	node, err := m.findNode(name)
+	if err != nil {
+		return err
+	}
+	if node.Cfg == nil {
+		return nodeConfigNotExistsError(name)
+	}
+	return m.saveHostConfig(node.Cfg.(*configuration.AnsibleHost))
+}
+
+// saveHostConfig is like saveNodeConfig, except that the configuration state is
+// taken from the host instead of looking up the node
+func (m *Manager) saveHostConfig(host *configuration.AnsibleHost) error {
+	return m.inventory.SetAssetConfig(host.GetTag(), inventory.AssetConfig{
+		Group: host.GetGroup(),
+		Addr:  host.GetAddr(),
+		Vars:  host.GetVars(),
+	})
 }
 
 type setInvStateCallback func(name string) error
@@ -107,6 +147,7 @@ func (m *Manager) setAssetsStatusBestEffort(names []string, newStatusCb setInvSt
 	for _, name := range names {
 		if err := newStatusCb(name); err != nil {
 			logrus.Errorf("failed to update %s's state in inventory, Error: %v", name, err)
+			m.addAssetLog(name, inventory.LogTypeError, "failed to update the status in inventory. Error: %v", err)
 			continue
 		}
 	}
@@ -117,6 +158,7 @@ func (m *Manager) setAssetsStatusAtomic(names []string, newStatusCb setInvStateC
 	for i, name := range names {
 		if err := newStatusCb(name); err != nil {
 			// try to revert back to original state in case of failure
+			m.addAssetLog(name, inventory.LogTypeError, "failed to update the status in inventory. Error: %v", err)
 			m.setAssetsStatusBestEffort(names[0:i+1], revertStatusCb)
 			return errored.Errorf("failed to update %s's state in inventory, Error: %v", name, err)
 		}
@@ -124,41 +166,103 @@ func (m *Manager) setAssetsStatusAtomic(names []string, newStatusCb setInvStateC
 	return nil
 }
 
-// checkAndGetNewJob() is a wrapper to check that there are no active jobs before a job is run
-func (m *Manager) checkAndSetActiveJob(jobDesc string, runner JobRunner, doneCb DoneCallback) error {
-	if m.activeJob != nil {
-		return errActiveJob(m.activeJob.String())
+// checkAndSetActiveJob() is a wrapper to check that there are no conflicting active
+// or queued jobs on the nodes of the job before it is run. On success the nodes stay
+// locked for the job, till the job is reset. On conflict the job is queued along
+// with the event that created it and errJobQueued is returned. The event is
+// processed again when the conflicting jobs are done, which validates it again.
+// An event that fails validation is not queued and the validation error is returned.
+func (m *Manager) checkAndSetActiveJob(j *Job, e event, priority int) error {
+	if m.queue.conflicts(j, priority) {
+		return m.validateAndEnqueueJob(j, e, priority)
+	}
+	if err := m.locks.lock(j, j.nodes); err != nil {
+		logrus.Debugf("queueing job %d. Error: %v", j.id, err)
+		return m.validateAndEnqueueJob(j, e, priority)
 	}
-	m.activeJob = NewJob(jobDesc, runner, doneCb)
+	m.addActiveJob(j)
 	return nil
 }
 
-// resetActiveJob() is a helper to reset active jobs if any
-func (m *Manager) resetActiveJob() {
-	if m.activeJob != nil {
-		m.lastJob = m.activeJob
+// validateAndEnqueueJob() queues the job along with the event that created it,
+// if the event is valid. It returns errJobQueued on success.
+func (m *Manager) validateAndEnqueueJob(j *Job, e event, priority int) error {
+	if ve, ok := e.(validatedEvent); ok {
+		if err := ve.eventValidate(); err != nil {
+			return err
+		}
+	}
+	m.enqueueJob(j, e, priority)
+	return errJobQueued
+}
+
+// checkAndSetExclusiveJob() is a wrapper to check that there are no active jobs
+// before a job is run. On success the whole cluster stays locked for the returned
+// job, till the job is reset.
+func (m *Manager) checkAndSetExclusiveJob(jobDesc string, runner JobRunner, doneCb DoneCallback) (*Job, error) {
+	j := m.newJob(jobDesc, runner, doneCb, nil)
+	if err := m.locks.lockAll(j); err != nil {
+		return nil, err
 	}
-	m.activeJob = nil
+	m.addActiveJob(j)
+	return j, nil
+}
+
+// addActiveJob() is a helper to add a job to the list of active jobs
+func (m *Manager) addActiveJob(j *Job) {
+	m.jobsMutex.Lock()
+	defer m.jobsMutex.Unlock()
+	m.activeJobs = append(m.activeJobs, j)
 }
 
-// runActiveJob() is a wrapper to run the job and reset the active job once the actual job is done
-func (m *Manager) runActiveJob() {
-	if m.activeJob == nil {
-		logrus.Errorf("run called without an active job")
-		return
+// resetActiveJob() is a helper to reset an active job and release the locks held by it
+func (m *Manager) resetActiveJob(j *Job) {
+	m.jobsMutex.Lock()
+	for i, aj := range m.activeJobs {
+		if aj == j {
+			m.activeJobs = append(m.activeJobs[:i], m.activeJobs[i+1:]...)
+			m.lastJob = j
+			break
+		}
 	}
-	m.activeJob.Run()
+	m.jobsMutex.Unlock()
+	m.locks.unlock(j)
+	// the released nodes may unblock the queued jobs
+	m.signalJobQueue()
+}
+
+// runActiveJob() is a wrapper to run the job and reset the active job once the actual job is done.
+// The job is recorded in the job history before it is run and updated once it is done. The start
+// and outcome of the job are recorded in the logs of it's nodes as well.
+func (m *Manager) runActiveJob(j *Job) {
+	m.saveJob(j)
+	m.logJobStarted(j)
+	j.Run()
+	m.saveJob(j)
+	m.logJobDone(j)
 	// reset the active job once done
-	m.resetActiveJob()
+	m.resetActiveJob(j)
 }
 
-// IsValidHostGroup checks if the passed hostGroup is valid
+// IsValidHostGroup checks if the passed hostGroup is one of the default host-groups
+//
+// Deprecated: the host-groups are configurable, a host-group is valid if it is
+// defined in clusterm's configuration.
 func IsValidHostGroup(hostGroup string) bool {
-	switch hostGroup {
-	case
-		ansibleMasterGroupName,
-		ansibleWorkerGroupName:
-		return true
-	}
-	return false
+	_, ok := defaultHostGroups()[hostGroup]
+	return ok
+}
+
+// getActiveJobs() returns the jobs that are active at the time of call
+func (m *Manager) getActiveJobs() []*Job {
+	m.jobsMutex.Lock()
+	defer m.jobsMutex.Unlock()
+	return append([]*Job{}, m.activeJobs...)
+}
+
+// getLastJob() returns the job that was done last
+func (m *Manager) getLastJob() *Job {
+	m.jobsMutex.Lock()
+	defer m.jobsMutex.Unlock()
+	return m.lastJob
 }
@@ -3,7 +3,11 @@
 package manager
 
 import (
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/cluster/management/src/mock"
 	"github.com/contiv/errored"
+	"github.com/golang/mock/gomock"
 	. "gopkg.in/check.v1"
 )
 
@@ -64,3 +68,134 @@ func (s *eventUtilsSuite) TestSetStatusBestEffortFailure(c *C) {
 	mgr.setAssetsStatusBestEffort(strs, failureCb(&setStrs, 2))
 	c.Assert(strs, DeepEquals, setStrs)
 }
+
+// setActiveJob is a helper to create a job on the specified nodes and set it as active
+func setActiveJob(mgr *Manager, desc string, nodeNames []string) (*Job, error) {
+	j := mgr.newJob(desc, nil, nil, nodeNames)
+	return j, mgr.checkAndSetActiveJob(j, nil, 0)
+}
+
+func (s *eventUtilsSuite) TestActiveJobs(c *C) {
+	mgr := &Manager{reqQ: make(chan event, 10)}
+	j1, err := setActiveJob(mgr, "job1", []string{"foo"})
+	c.Assert(err, IsNil)
+	j2, err := setActiveJob(mgr, "job2", []string{"bar"})
+	c.Assert(err, IsNil)
+	j3, err := setActiveJob(mgr, "job3", []string{"bar"})
+	c.Assert(err, Equals, errJobQueued)
+	c.Assert(mgr.getActiveJobs(), DeepEquals, []*Job{j1, j2})
+	c.Assert(mgr.getQueuedJobs(), DeepEquals, []*Job{j3})
+	c.Assert(mgr.getLastJob(), IsNil)
+
+	mgr.resetActiveJob(j1)
+	c.Assert(mgr.getActiveJobs(), DeepEquals, []*Job{j2})
+	c.Assert(mgr.getLastJob(), Equals, j1)
+
+	// a cluster wide job can't be set while a job is active
+	_, err = mgr.checkAndSetExclusiveJob("job4", nil, nil)
+	c.Assert(err, NotNil)
+	mgr.resetActiveJob(j2)
+	c.Assert(len(mgr.getActiveJobs()), Equals, 0)
+	c.Assert(mgr.getLastJob(), Equals, j2)
+	_, err = mgr.checkAndSetExclusiveJob("job4", nil, nil)
+	c.Assert(err, IsNil)
+}
+
+func (s *eventUtilsSuite) TestCleanupCancelChannel(c *C) {
+	cancelCh := make(CancelChannel)
+	c.Assert(cleanupCancelChannel(cancelCh, nil), Equals, cancelCh)
+	c.Assert(cleanupCancelChannel(cancelCh, errored.Errorf("test failure")), Equals, cancelCh)
+	c.Assert(cleanupCancelChannel(cancelCh, errJobCancelled), IsNil)
+}
+
+func (s *eventUtilsSuite) TestStepResults(c *C) {
+	names := []string{"foo", "bar", "baz"}
+	ok := configuration.HostResultOK
+	failed := configuration.HostResultFailed
+	unreachable := configuration.HostResultUnreachable
+
+	tests := map[string]struct {
+		err          error
+		exptdResults map[string]configuration.HostResult
+		exptdOK      []string
+		exptdFailed  []string
+	}{
+		"success": {
+			err:          nil,
+			exptdResults: map[string]configuration.HostResult{"foo": ok, "bar": ok, "baz": ok},
+			exptdOK:      []string{"foo", "bar", "baz"},
+			exptdFailed:  []string{},
+		},
+		"failure": {
+			err:          errJobCancelled,
+			exptdResults: map[string]configuration.HostResult{"foo": failed, "bar": failed, "baz": failed},
+			exptdOK:      []string{},
+			exptdFailed:  []string{"foo", "bar", "baz"},
+		},
+		"partial-failure": {
+			err: &configuration.HostsError{
+				Err:     errored.Errorf("test failure"),
+				Results: map[string]configuration.HostResult{"foo": ok, "bar": unreachable},
+			},
+			exptdResults: map[string]configuration.HostResult{"foo": ok, "bar": unreachable, "baz": failed},
+			exptdOK:      []string{"foo"},
+			exptdFailed:  []string{"bar", "baz"},
+		},
+	}
+
+	for key, test := range tests {
+		results := stepResults(names, test.err)
+		c.Assert(results, DeepEquals, test.exptdResults, Commentf("test key: %s", key))
+		okNodes, failedNodes := splitNodesByResult(names, results)
+		c.Assert(okNodes, DeepEquals, test.exptdOK, Commentf("test key: %s", key))
+		c.Assert(failedNodes, DeepEquals, test.exptdFailed, Commentf("test key: %s", key))
+	}
+}
+
+func (s *eventUtilsSuite) TestFilterHosts(c *C) {
+	hosts := []*configuration.AnsibleHost{
+		configuration.NewAnsibleHost("foo", "1.1.1.1", ansibleMasterGroupName, nil),
+		configuration.NewAnsibleHost("bar", "1.1.1.2", ansibleMasterGroupName, nil),
+		configuration.NewAnsibleHost("baz", "1.1.1.3", ansibleWorkerGroupName, nil),
+	}
+	filtered := filterHosts(hosts, []string{"baz", "foo"})
+	c.Assert(filtered, DeepEquals, []*configuration.AnsibleHost{hosts[0], hosts[2]})
+}
+
+func (s *eventUtilsSuite) TestNodeConfigRestore(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mClient := mock.NewMockSubsysClient(ctrl)
+	inv := inventory.NewGeneralSubsys(mClient)
+	asset := inventory.NewAssetWithState(mClient, "node1", inventory.Allocated, inventory.Disappeared)
+	asset.RestoreConfig(inventory.AssetConfig{
+		Group: ansibleWorkerGroupName,
+		Addr:  "1.1.1.1",
+		Vars:  map[string]string{ansibleNodeAddrHostVar: "1.1.1.1", "foo": "bar"},
+	})
+	c.Assert(inv.RestoreAsset("node1", asset), IsNil)
+	mgr := &Manager{inventory: inv, nodes: map[string]*node{}}
+
+	// a known node gets it's group and vars restored, with the address as discovered
+	host := mgr.newNodeConfig("node1", "2.2.2.2")
+	exptdVars := map[string]string{
+		ansibleNodeNameHostVar: "node1",
+		ansibleNodeAddrHostVar: "2.2.2.2",
+		"foo":                  "bar",
+	}
+	c.Assert(host.GetGroup(), Equals, ansibleWorkerGroupName)
+	c.Assert(host.GetAddr(), Equals, "2.2.2.2")
+	c.Assert(host.GetVars(), DeepEquals, exptdVars)
+
+	// a new node defaults to master group
+	newHost := mgr.newNodeConfig("node2", "3.3.3.3")
+	c.Assert(newHost.GetGroup(), Equals, ansibleMasterGroupName)
+
+	mgr.nodes["node1"] = &node{Inv: asset, Cfg: host}
+	mClient.EXPECT().SetAssetConfig("node1", ansibleWorkerGroupName, "2.2.2.2", exptdVars)
+	c.Assert(mgr.saveNodeConfig("node1"), IsNil)
+	c.Assert(asset.GetConfig().Addr, Equals, "2.2.2.2")
+
+	c.Assert(mgr.saveNodeConfig("node2"), ErrorMatches, ".*doesn't exists")
+}
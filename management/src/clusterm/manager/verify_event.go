@@ -0,0 +1,135 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/errored"
+)
+
+// verifyEvent triggers the verification of the configuration of commissioned
+// nodes, which is done when a node reappears as it may have rebooted. The nodes
+// where the verification fails are moved to degraded state.
+type verifyEvent struct {
+	mgr       *Manager
+	nodeNames []string
+
+	_job   *Job
+	_hosts configuration.SubsysHosts
+}
+
+// newVerifyEvent creates and returns verifyEvent
+func newVerifyEvent(mgr *Manager, nodeNames []string) *verifyEvent {
+	return &verifyEvent{
+		mgr:       mgr,
+		nodeNames: nodeNames,
+	}
+}
+
+func (e *verifyEvent) String() string {
+	return fmt.Sprintf("verifyEvent: nodes: %v", e.nodeNames)
+}
+
+func (e *verifyEvent) job() *Job {
+	return e._job
+}
+
+func (e *verifyEvent) process() error {
+	// err shouldn't be redefined below
+	var err error
+
+	// the job is created once, the event is processed again if the job is queued
+	if e._job == nil {
+		e._job = e.mgr.newJob(
+			e.String(),
+			e.verifyRunner,
+			func(status JobStatus, errRet error) {
+				if status == Errored {
+					logrus.Errorf("verification job failed. Error: %v", errRet)
+				}
+				// set the nodes where the verification failed as degraded
+				okNodes, failedNodes := splitNodesByResult(e.nodeNames, e._job.Results())
+				e.mgr.clearDegraded(okNodes)
+				e.mgr.setAssetsStatusBestEffort(failedNodes, e.mgr.inventory.SetAssetDegraded)
+			},
+			e.nodeNames)
+	}
+	job := e._job
+	if err = e.mgr.checkAndSetActiveJob(job, e, 0); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			e.mgr.resetActiveJob(job)
+		}
+	}()
+
+	// validate event data
+	if err = e.eventValidate(); err != nil {
+		return err
+	}
+
+	// trigger the verification
+	go e.mgr.runActiveJob(job)
+
+	return nil
+}
+
+// eventValidate makes sure that the nodes are still commissioned and reachable,
+// as the node may have changed while the job was queued
+func (e *verifyEvent) eventValidate() error {
+	enodes, err := e.mgr.commonEventValidate(e.nodeNames)
+	if err != nil {
+		return err
+	}
+
+	hosts := []*configuration.AnsibleHost{}
+	for _, name := range e.nodeNames {
+		isDiscoveredAndAllocated, err := e.mgr.isDiscoveredAndAllocatedNode(name)
+		if err != nil {
+			return err
+		}
+		if !isDiscoveredAndAllocated {
+			return errored.Errorf("node %q is not commissioned, only the commissioned nodes are verified", name)
+		}
+		hosts = append(hosts, enodes[name].Cfg.(*configuration.AnsibleHost))
+	}
+	e._hosts = hosts
+	return nil
+}
+
+// verifyRunner is the job runner that runs the verification playbook on the nodes
+func (e *verifyEvent) verifyRunner(cancelCh CancelChannel, jobLogs io.Writer) error {
+	outReader, cancelFunc, errCh := e.mgr.configuration.Verify(e._hosts, configuration.DefaultValidJSON)
+	err := logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs)
+	e._job.setResults(stepResults(e.nodeNames, err))
+	return err
+}
+
+// verifyNode triggers the verification of a commissioned node, when a
+// verification playbook is configured
+func (m *Manager) verifyNode(name string) {
+	if m.config == nil || m.config.Ansible.VerifyPlaybook == "" {
+		return
+	}
+
+	logrus.Infof("verifying the configuration of reappeared node %q", name)
+	if err := newVerifyEvent(m, []string{name}).process(); err != nil && err != errJobQueued {
+		logrus.Errorf("failed to verify the configuration of node %q. Error: %v", name, err)
+	}
+}
+
+// verifyProvisioned runs the verification playbook on the nodes that were
+// provisioned during commission, when a verification playbook is configured.
+// The nodes are commissioned only when the verification succeeds.
+func (m *Manager) verifyProvisioned(hosts configuration.SubsysHosts, extraVars string,
+	cancelCh CancelChannel, jobLogs io.Writer) error {
+	if m.config == nil || m.config.Ansible.VerifyPlaybook == "" {
+		return nil
+	}
+
+	outReader, cancelFunc, errCh := m.configuration.Verify(hosts, extraVars)
+	return logOutputAndReturnStatus(outReader, errCh, cancelCh, cancelFunc, jobLogs)
+}
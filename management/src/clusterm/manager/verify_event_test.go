@@ -0,0 +1,176 @@
+// +build unittest
+
+package manager
+
+import (
+	"io"
+
+	"github.com/contiv/cluster/management/src/configuration"
+	"github.com/contiv/cluster/management/src/inventory"
+	"github.com/contiv/errored"
+	"github.com/golang/mock/gomock"
+	"golang.org/x/net/context"
+	. "gopkg.in/check.v1"
+)
+
+type verifySuite struct {
+}
+
+var _ = Suite(&verifySuite{})
+
+// fakeConfigSubsys is a configuration subsystem whose actions return the
+// specified error. The verification returns verifyErr instead. The facts of
+// the nodes, if any, are returned by the facts gathering.
+type fakeConfigSubsys struct {
+	err       error
+	verifyErr error
+	facts     map[string]inventory.AssetFacts
+}
+
+func fakeRun(err error) (io.Reader, context.CancelFunc, chan error) {
+	errCh := make(chan error, 1)
+	errCh <- err
+	return nil, func() {}, errCh
+}
+
+func (f *fakeConfigSubsys) run() (io.Reader, context.CancelFunc, chan error) {
+	return fakeRun(f.err)
+}
+
+func (f *fakeConfigSubsys) Configure(nodes configuration.SubsysHosts, extraVars string) (io.Reader, context.CancelFunc, chan error) {
+	return f.run()
+}
+
+func (f *fakeConfigSubsys) Cleanup(nodes configuration.SubsysHosts, extraVars string) (io.Reader, context.CancelFunc, chan error) {
+	return f.run()
+}
+
+func (f *fakeConfigSubsys) Upgrade(nodes configuration.SubsysHosts, extraVars string) (io.Reader, context.CancelFunc, chan error) {
+	return f.run()
+}
+
+func (f *fakeConfigSubsys) Verify(nodes configuration.SubsysHosts, extraVars string) (io.Reader, context.CancelFunc, chan error) {
+	return fakeRun(f.verifyErr)
+}
+
+func (f *fakeConfigSubsys) GatherFacts(nodes configuration.SubsysHosts) (map[string]inventory.AssetFacts, error) {
+	facts := map[string]inventory.AssetFacts{}
+	for _, host := range nodes.([]*configuration.AnsibleHost) {
+		if hostFacts, ok := f.facts[host.GetTag()]; ok {
+			facts[host.GetTag()] = hostFacts
+		}
+	}
+	return facts, nil
+}
+
+func (f *fakeConfigSubsys) SetGlobals(extraVars string) error {
+	return nil
+}
+
+func (f *fakeConfigSubsys) GetGlobals() string {
+	return ""
+}
+
+// newVerifyTestManager returns a manager with reachable nodes in the specified
+// status, with the configuration actions returning the specified errors
+func newVerifyTestManager(c *C, ctrl *gomock.Controller, assets map[string]inventory.AssetStatus,
+	err, verifyErr error) *Manager {
+	mgr := newTestManager(c, ctrl, assets, inventory.Discovered)
+	mgr.config.Ansible.VerifyPlaybook = "verify.yml"
+	mgr.configuration = &fakeConfigSubsys{err: err, verifyErr: verifyErr}
+	return mgr
+}
+
+func assetState(mgr *Manager, name string) inventory.AssetState {
+	_, state := mgr.inventory.GetAsset(name).GetStatus()
+	return state
+}
+
+func (s *verifySuite) TestVerifyFailure(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newVerifyTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Allocated,
+		"node2": inventory.Allocated,
+	}, nil, &configuration.HostsError{
+		Err: errored.Errorf("test failure"),
+		Results: map[string]configuration.HostResult{
+			"node1": configuration.HostResultOK,
+			"node2": configuration.HostResultFailed,
+		},
+	})
+	e := newVerifyEvent(mgr, []string{"node1", "node2"})
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Errored.String())
+	c.Assert(assetState(mgr, "node1"), Equals, inventory.Discovered)
+	c.Assert(assetState(mgr, "node2"), Equals, inventory.Degraded)
+	c.Assert(assetStatus(mgr, "node2"), Equals, inventory.Allocated)
+
+	// a degraded node is still a reachable, commissioned node
+	isDiscoveredAndAllocated, err := mgr.isDiscoveredAndAllocatedNode("node2")
+	c.Assert(err, IsNil)
+	c.Assert(isDiscoveredAndAllocated, Equals, true)
+
+	// the degraded state is cleared once the verification succeeds
+	mgr.configuration = &fakeConfigSubsys{}
+	e = newVerifyEvent(mgr, []string{"node2"})
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(assetState(mgr, "node2"), Equals, inventory.Discovered)
+}
+
+func (s *verifySuite) TestVerifyNonCommissionedNode(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newVerifyTestManager(c, ctrl, map[string]inventory.AssetStatus{"node1": inventory.Allocated}, nil, nil)
+	c.Assert(mgr.inventory.SetAssetInMaintenance("node1"), IsNil)
+	e := newVerifyEvent(mgr, []string{"node1"})
+	c.Assert(e.process(), ErrorMatches, ".*only the commissioned nodes are verified")
+	c.Assert(assetState(mgr, "node1"), Equals, inventory.Discovered)
+}
+
+func (s *verifySuite) TestCommissionVerificationGate(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newVerifyTestManager(c, ctrl, map[string]inventory.AssetStatus{
+		"node1": inventory.Unallocated,
+		"node2": inventory.Unallocated,
+	}, nil, &configuration.HostsError{
+		Err: errored.Errorf("test failure"),
+		Results: map[string]configuration.HostResult{
+			"node1": configuration.HostResultOK,
+			"node2": configuration.HostResultFailed,
+		},
+	})
+	e := newCommissionEvent(mgr, []string{"node1", "node2"}, configuration.DefaultValidJSON, ansibleMasterGroupName, 0)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Errored.String())
+	c.Assert(e._job.Results(), DeepEquals, map[string]configuration.HostResult{
+		"node1": configuration.HostResultOK,
+		"node2": configuration.HostResultFailed,
+	})
+	// the node that failed the verification is cleaned up and set back as unallocated
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated)
+	c.Assert(assetStatus(mgr, "node2"), Equals, inventory.Unallocated)
+}
+
+func (s *verifySuite) TestCommissionNoVerification(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mgr := newVerifyTestManager(c, ctrl, map[string]inventory.AssetStatus{"node1": inventory.Unallocated},
+		nil, errored.Errorf("test failure"))
+	// the verification is skipped when no verification playbook is configured
+	mgr.config.Ansible.VerifyPlaybook = ""
+	e := newCommissionEvent(mgr, []string{"node1"}, configuration.DefaultValidJSON, ansibleMasterGroupName, 0)
+	c.Assert(e.process(), IsNil)
+	e._job.Wait()
+	c.Assert(e._job.Info().Status, Equals, Complete.String())
+	c.Assert(assetStatus(mgr, "node1"), Equals, inventory.Allocated)
+}
@@ -5,9 +5,12 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/contiv/cluster/management/src/inventory"
 	"github.com/contiv/errored"
 )
 
@@ -27,6 +30,32 @@ func DefaultConfig() Config {
 	}
 }
 
+const (
+	// the attributes that hold the configuration state of an asset
+	hostGroupAttrib = "HOST_GROUP"
+	hostAddrAttrib  = "HOST_ADDR"
+	hostVarsAttrib  = "HOST_VARS"
+	// the attribute that holds the hardware facts of an asset
+	hostFactsAttrib = "HOST_FACTS"
+	// the attribute that holds the generic attributes of an asset, like the
+	// labels set by the operator. They are kept as one attribute, as the
+	// attribute names are case insensitive in collins.
+	hostAttribsAttrib = "HOST_ATTRIBUTES"
+
+	// maxAssetLogs is the number of log entries fetched for an asset
+	maxAssetLogs = 1000
+	// assetLogsPageSize is the number of log entries fetched per query
+	assetLogsPageSize = 1000
+	// logTimeFormat is the format of the creation time of the log entries
+	logTimeFormat = "2006-01-02T15:04:05"
+	// transitionLogType is the type of the log entries that hold the transition
+	// history of an asset. Collins doesn't allow custom log types, so the
+	// transitions are told apart from the operator's notes by the prefix of
+	// their message.
+	transitionLogType   = "NOTE"
+	transitionLogPrefix = "TRANSITION: "
+)
+
 // Asset denotes the asset related information as read from collins. This is
 // not all of the information.
 type Asset struct {
@@ -35,6 +64,43 @@ type Asset struct {
 	State  struct {
 		Name string `json:"NAME"`
 	}
+	// the configuration state of the asset, as read from asset's attributes
+	HostGroup string            `json:"-"`
+	HostAddr  string            `json:"-"`
+	HostVars  map[string]string `json:"-"`
+	// the hardware facts of the asset, as read from asset's attributes
+	Facts *inventory.AssetFacts `json:"-"`
+	// the generic attributes of the asset, as read from asset's attributes
+	Attributes map[string]string `json:"-"`
+}
+
+// attribs denotes the attributes of an asset as read from collins. The attributes
+// are keyed by their dimension, only the default dimension ("0") is used.
+type attribs map[string]map[string]string
+
+// setHostConfig sets asset's configuration state, hardware facts and generic
+// attributes from it's attributes
+func (a *Asset) setHostConfig(attrs attribs) error {
+	attr := attrs["0"]
+	a.HostGroup = attr[hostGroupAttrib]
+	a.HostAddr = attr[hostAddrAttrib]
+	if attr[hostVarsAttrib] != "" {
+		if err := json.Unmarshal([]byte(attr[hostVarsAttrib]), &a.HostVars); err != nil {
+			return errored.Errorf("failed to unmarshal host vars of asset %q. Error: %s", a.Tag, err)
+		}
+	}
+	if attr[hostFactsAttrib] != "" {
+		a.Facts = &inventory.AssetFacts{}
+		if err := json.Unmarshal([]byte(attr[hostFactsAttrib]), a.Facts); err != nil {
+			return errored.Errorf("failed to unmarshal hardware facts of asset %q. Error: %s", a.Tag, err)
+		}
+	}
+	if attr[hostAttribsAttrib] != "" {
+		if err := json.Unmarshal([]byte(attr[hostAttribsAttrib]), &a.Attributes); err != nil {
+			return errored.Errorf("failed to unmarshal attributes of asset %q. Error: %s", a.Tag, err)
+		}
+	}
+	return nil
 }
 
 // Client denotes state for a collins client
@@ -116,20 +182,26 @@ func (c *Client) GetAsset(tag string) (Asset, error) {
 	logrus.Debugf("response: %s", body)
 	collinsResp := &struct {
 		Data struct {
-			Asset Asset `json:"ASSET"`
+			Asset   Asset   `json:"ASSET"`
+			Attribs attribs `json:"ATTRIBS"`
 		} `json:"data"`
 	}{}
 	if err := json.Unmarshal(body, collinsResp); err != nil {
 		return Asset{}, errored.Errorf("failed to unmarshal response. Error: %s", err)
 	}
 
-	logrus.Debugf("collins asset: %+v", collinsResp.Data.Asset)
-	return collinsResp.Data.Asset, nil
+	asset := collinsResp.Data.Asset
+	if err := asset.setHostConfig(collinsResp.Data.Attribs); err != nil {
+		return Asset{}, err
+	}
+	logrus.Debugf("collins asset: %+v", asset)
+	return asset, nil
 }
 
 // GetAllAssets queries and returns a all the assets
 func (c *Client) GetAllAssets() (interface{}, error) {
-	reqURL := c.config.URL + "/api/assets"
+	// the details of the assets are needed for their attributes
+	reqURL := c.config.URL + "/api/assets?details=true"
 	req, err := http.NewRequest("GET", reqURL, nil)
 	if err != nil {
 		return nil, err
@@ -155,7 +227,8 @@ func (c *Client) GetAllAssets() (interface{}, error) {
 	collinsResp := &struct {
 		Data struct {
 			Assets []struct {
-				Asset Asset `json:"ASSET"`
+				Asset   Asset   `json:"ASSET"`
+				Attribs attribs `json:"ATTRIBS"`
 			} `json:"Data"`
 		} `json:"data"`
 	}{}
@@ -165,6 +238,9 @@ func (c *Client) GetAllAssets() (interface{}, error) {
 
 	assets := []Asset{}
 	for _, d := range collinsResp.Data.Assets {
+		if err := d.Asset.setHostConfig(d.Attribs); err != nil {
+			return nil, err
+		}
 		logrus.Debugf("collins asset: %+v", d.Asset)
 		assets = append(assets, d.Asset)
 	}
@@ -210,7 +286,179 @@ func (c *Client) CreateState(name, description, status string) error {
 
 // AddAssetLog creates a log entry for an asset
 func (c *Client) AddAssetLog(tag, mtype, message string) error {
-	return errored.Errorf("not implemented")
+	params := &url.Values{}
+	params.Set("type", mtype)
+	params.Set("message", message)
+
+	reqURL := c.config.URL + "/api/asset/" + tag + "/log?" + params.Encode()
+	req, err := http.NewRequest("PUT", reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.config.User, c.config.Password)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			body = []byte{}
+		}
+		return errored.Errorf("status code %d unexpected. Response body: %q",
+			resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// isTransitionLog returns true if the log entry holds a transition of the asset
+func isTransitionLog(l inventory.AssetLog) bool {
+	return l.Type == transitionLogType && strings.HasPrefix(l.Message, transitionLogPrefix)
+}
+
+// GetAssetLogs queries and returns the latest log entries of an asset, oldest
+// first. The entries that hold the transition history of the asset are skipped.
+// The entries are queried newest first, page by page, till the latest
+// maxAssetLogs entries are found.
+func (c *Client) GetAssetLogs(tag string) ([]inventory.AssetLog, error) {
+	eventLogs := []inventory.AssetLog{}
+	for page, more := 0, true; more && len(eventLogs) < maxAssetLogs; page++ {
+		var (
+			logs []inventory.AssetLog
+			err  error
+		)
+		if logs, more, err = c.getAssetLogs(tag, "", "DESC", page); err != nil {
+			return nil, err
+		}
+		for _, l := range logs {
+			if !isTransitionLog(l) && len(eventLogs) < maxAssetLogs {
+				eventLogs = append(eventLogs, l)
+			}
+		}
+	}
+
+	// the entries are reversed to be returned oldest first
+	for i, j := 0, len(eventLogs)-1; i < j; i, j = i+1, j-1 {
+		eventLogs[i], eventLogs[j] = eventLogs[j], eventLogs[i]
+	}
+	return eventLogs, nil
+}
+
+// AddAssetTransition appends a transition to the transition history of an
+// asset. Collins has no notion of the transition history, so a transition is
+// kept as a note in the asset's log, with the transition encoded in json as
+// the message after the transition prefix.
+func (c *Client) AddAssetTransition(tag string, t inventory.AssetTransition) error {
+	msg, err := json.Marshal(t)
+	if err != nil {
+		return errored.Errorf("failed to marshal transition. Error: %s", err)
+	}
+	return c.AddAssetLog(tag, transitionLogType, transitionLogPrefix+string(msg))
+}
+
+// GetAssetTransitions queries and returns the transitions of an asset within
+// the time range, oldest first. A zero time leaves that end of the range open.
+// The notes of the asset are queried oldest first, page by page, till the end
+// of the range.
+func (c *Client) GetAssetTransitions(tag string, from, to time.Time) ([]inventory.AssetTransition, error) {
+	transitions := []inventory.AssetTransition{}
+	for page, more := 0, true; more; page++ {
+		var (
+			logs []inventory.AssetLog
+			err  error
+		)
+		if logs, more, err = c.getAssetLogs(tag, transitionLogType, "ASC", page); err != nil {
+			return nil, err
+		}
+		for _, l := range logs {
+			if !isTransitionLog(l) {
+				continue
+			}
+			msg := strings.TrimPrefix(l.Message, transitionLogPrefix)
+			var t inventory.AssetTransition
+			if err := json.Unmarshal([]byte(msg), &t); err != nil {
+				logrus.Warnf("skipping invalid transition of asset %q: %q. Error: %s", tag, msg, err)
+				continue
+			}
+			if !to.IsZero() && t.Time.After(to) {
+				return transitions, nil
+			}
+			if !from.IsZero() && t.Time.Before(from) {
+				continue
+			}
+			transitions = append(transitions, t)
+		}
+	}
+	return transitions, nil
+}
+
+// getAssetLogs queries and returns a page of the log entries of an asset, of
+// the types that match the filter, in the specified order (ASC or DESC) of
+// their creation. It also returns true if there are more pages.
+func (c *Client) getAssetLogs(tag, filter, order string, page int) ([]inventory.AssetLog, bool, error) {
+	params := &url.Values{}
+	params.Set("sort", order)
+	params.Set("page", strconv.Itoa(page))
+	params.Set("size", strconv.Itoa(assetLogsPageSize))
+	if filter != "" {
+		params.Set("filter", filter)
+	}
+
+	reqURL := c.config.URL + "/api/asset/" + tag + "/logs?" + params.Encode()
+	req, err := http.NewRequest("GET", reqURL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.SetBasicAuth(c.config.User, c.config.Password)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, errored.Errorf("failed to read response body. Error: %s", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, errored.Errorf("status code %d unexpected. Response body: %q",
+			resp.StatusCode, body)
+	}
+
+	collinsResp := &struct {
+		Data struct {
+			Pagination struct {
+				TotalResults int `json:"TotalResults"`
+			} `json:"Pagination"`
+			Logs []struct {
+				Created string `json:"CREATED"`
+				Type    string `json:"TYPE"`
+				Message string `json:"MESSAGE"`
+			} `json:"Data"`
+		} `json:"data"`
+	}{}
+	if err := json.Unmarshal(body, collinsResp); err != nil {
+		return nil, false, errored.Errorf("failed to unmarshal response. Error: %s", err)
+	}
+
+	logs := []inventory.AssetLog{}
+	for _, l := range collinsResp.Data.Logs {
+		// the time is left as zero value if collins returns it in an unknown format
+		created, _ := time.Parse(logTimeFormat, l.Created)
+		logs = append(logs, inventory.AssetLog{
+			Time:    created,
+			Type:    l.Type,
+			Message: l.Message,
+		})
+	}
+	more := len(logs) == assetLogsPageSize &&
+		(page+1)*assetLogsPageSize < collinsResp.Data.Pagination.TotalResults
+	return logs, more, nil
 }
 
 // SetAssetStatus sets the status of an asset
@@ -243,3 +491,99 @@ func (c *Client) SetAssetStatus(tag, status, state, reason string) error {
 
 	return nil
 }
+
+// SetAssetConfig sets the configuration state of an asset as it's attributes
+func (c *Client) SetAssetConfig(tag, group, addr string, vars map[string]string) error {
+	varsJSON, err := json.Marshal(vars)
+	if err != nil {
+		return errored.Errorf("failed to marshal host vars. Error: %s", err)
+	}
+
+	params := &url.Values{}
+	params.Add("attribute", hostGroupAttrib+";"+group)
+	params.Add("attribute", hostAddrAttrib+";"+addr)
+	params.Add("attribute", hostVarsAttrib+";"+string(varsJSON))
+	return c.setAttribs(tag, params)
+}
+
+// SetAssetFacts sets the hardware facts of an asset as it's attribute
+func (c *Client) SetAssetFacts(tag string, facts inventory.AssetFacts) error {
+	factsJSON, err := json.Marshal(facts)
+	if err != nil {
+		return errored.Errorf("failed to marshal hardware facts. Error: %s", err)
+	}
+
+	params := &url.Values{}
+	params.Add("attribute", hostFactsAttrib+";"+string(factsJSON))
+	return c.setAttribs(tag, params)
+}
+
+// SetAssetAttributes sets the generic attributes of an asset, like the labels
+// set by the operator, as it's attribute
+func (c *Client) SetAssetAttributes(tag string, attrs map[string]string) error {
+	attrsJSON, err := json.Marshal(attrs)
+	if err != nil {
+		return errored.Errorf("failed to marshal attributes. Error: %s", err)
+	}
+
+	params := &url.Values{}
+	params.Add("attribute", hostAttribsAttrib+";"+string(attrsJSON))
+	return c.setAttribs(tag, params)
+}
+
+// setAttribs sets the attributes of an asset, as specified in the params
+func (c *Client) setAttribs(tag string, params *url.Values) error {
+	reqURL := c.config.URL + "/api/asset/" + tag + "?" + params.Encode()
+	req, err := http.NewRequest("POST", reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.config.User, c.config.Password)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			body = []byte{}
+		}
+		return errored.Errorf("status code %d unexpected. Response body: %q",
+			resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// DeleteAsset deletes an asset. The asset is nuked i.e. it's removed from
+// collins along with it's logs and attributes, so that it's tag can be reused.
+func (c *Client) DeleteAsset(tag string) error {
+	params := &url.Values{}
+	params.Set("reason", "purged by clusterm")
+	params.Set("nuke", "true")
+
+	reqURL := c.config.URL + "/api/asset/" + tag + "?" + params.Encode()
+	req, err := http.NewRequest("DELETE", reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.config.User, c.config.Password)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			body = []byte{}
+		}
+		return errored.Errorf("status code %d unexpected. Response body: %q",
+			resp.StatusCode, body)
+	}
+
+	return nil
+}
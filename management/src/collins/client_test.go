@@ -7,9 +7,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/contiv/cluster/management/src/inventory"
 	. "gopkg.in/check.v1"
 )
 
@@ -350,3 +354,409 @@ func (s *collinsSuite) TestSetAssetStatusStatusFailure(c *C) {
 	err := client.SetAssetStatus("test", "status", "state", "reason")
 	c.Assert(err, ErrorMatches, errStr)
 }
+
+func (s *collinsSuite) TestGetAssetWithConfig(c *C) {
+	tag := "test"
+	srvr, httpC := getHTTPTestClientAndServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data": {
+				"ASSET": {"TAG": "test", "STATUS": "Allocated", "State": {"NAME": "DISCOVERED"}},
+				"ATTRIBS": {"0": {
+					"HOST_GROUP": "service-worker",
+					"HOST_ADDR": "1.1.1.1",
+					"HOST_VARS": "{\"node_name\":\"test\"}",
+					"HOST_FACTS": "{\"cpus\":4,\"memory_mb\":8192,\"os\":\"CentOS\",\"os_version\":\"7.2.1511\"}",
+					"HOST_ATTRIBUTES": "{\"rack\":\"r1\"}"
+				}}
+			}}`))
+		}))
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	rcvdAsset, err := client.GetAsset(tag)
+	c.Assert(err, IsNil)
+	c.Assert(rcvdAsset.Tag, Equals, tag)
+	c.Assert(rcvdAsset.HostGroup, Equals, "service-worker")
+	c.Assert(rcvdAsset.HostAddr, Equals, "1.1.1.1")
+	c.Assert(rcvdAsset.HostVars, DeepEquals, map[string]string{"node_name": "test"})
+	c.Assert(rcvdAsset.Facts, DeepEquals, &inventory.AssetFacts{
+		CPUs:      4,
+		MemoryMB:  8192,
+		OS:        "CentOS",
+		OSVersion: "7.2.1511",
+	})
+	c.Assert(rcvdAsset.Attributes, DeepEquals, map[string]string{"rack": "r1"})
+}
+
+func (s *collinsSuite) TestGetAllAssetsInvalidHostVars(c *C) {
+	srvr, httpC := getHTTPTestClientAndServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data": {"Data": [{
+				"ASSET": {"TAG": "test"},
+				"ATTRIBS": {"0": {"HOST_VARS": "invalid-json"}}
+			}]}}`))
+		}))
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	errStr := "failed to unmarshal host vars of asset \"test\".*"
+	_, err := client.GetAllAssets()
+	c.Assert(err, ErrorMatches, errStr)
+}
+
+func (s *collinsSuite) TestSetAssetConfig(c *C) {
+	tag := "test"
+	srvr, httpC := getHTTPTestClientAndServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			attrs := r.URL.Query()["attribute"]
+			exptdAttrs := []string{
+				"HOST_GROUP;service-worker",
+				"HOST_ADDR;1.1.1.1",
+				`HOST_VARS;{"node_name":"test"}`,
+			}
+			if r.Method != "POST" || !strings.Contains(r.RequestURI, "/api/asset/"+tag) ||
+				!reflect.DeepEqual(attrs, exptdAttrs) {
+				http.Error(w, "unexpected request", http.StatusInternalServerError)
+			} else {
+				w.WriteHeader(http.StatusOK)
+			}
+		}))
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	err := client.SetAssetConfig(tag, "service-worker", "1.1.1.1", map[string]string{"node_name": "test"})
+	c.Assert(err, IsNil)
+}
+
+func (s *collinsSuite) TestSetAssetConfigStatusFailure(c *C) {
+	srvr, httpC := getHTTPTestClientAndServer(failureReturner)
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	errStr := ".*unexpected. Response body.*test failure.*"
+	err := client.SetAssetConfig("test", "service-master", "1.1.1.1", nil)
+	c.Assert(err, ErrorMatches, errStr)
+}
+
+func (s *collinsSuite) TestSetAssetFacts(c *C) {
+	tag := "test"
+	facts := inventory.AssetFacts{
+		CPUs:     4,
+		MemoryMB: 8192,
+		NICs:     []inventory.NICFacts{{Name: "eth0", MAC: "08:00:27:a8:bd:1e"}},
+	}
+	srvr, httpC := getHTTPTestClientAndServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			attrs := r.URL.Query()["attribute"]
+			if r.Method != "POST" || !strings.Contains(r.RequestURI, "/api/asset/"+tag) ||
+				len(attrs) != 1 || !strings.HasPrefix(attrs[0], "HOST_FACTS;") {
+				http.Error(w, "unexpected request", http.StatusInternalServerError)
+				return
+			}
+			rcvdFacts := inventory.AssetFacts{}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(attrs[0], "HOST_FACTS;")), &rcvdFacts); err != nil ||
+				!reflect.DeepEqual(rcvdFacts, facts) {
+				http.Error(w, "unexpected facts", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	c.Assert(client.SetAssetFacts(tag, facts), IsNil)
+}
+
+func (s *collinsSuite) TestSetAssetAttributes(c *C) {
+	tag := "test"
+	srvr, httpC := getHTTPTestClientAndServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			attrs := r.URL.Query()["attribute"]
+			exptdAttrs := []string{`HOST_ATTRIBUTES;{"rack":"r1","zone":"z1"}`}
+			if r.Method != "POST" || !strings.Contains(r.RequestURI, "/api/asset/"+tag) ||
+				!reflect.DeepEqual(attrs, exptdAttrs) {
+				http.Error(w, "unexpected request", http.StatusInternalServerError)
+			} else {
+				w.WriteHeader(http.StatusOK)
+			}
+		}))
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	c.Assert(client.SetAssetAttributes(tag, map[string]string{"rack": "r1", "zone": "z1"}), IsNil)
+}
+
+func (s *collinsSuite) TestDeleteAsset(c *C) {
+	tag := "test"
+	srvr, httpC := getHTTPTestClientAndServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "DELETE" || !strings.Contains(r.RequestURI, "/api/asset/"+tag) ||
+				r.URL.Query().Get("nuke") != "true" {
+				http.Error(w, "unexpected request", http.StatusInternalServerError)
+			} else {
+				w.WriteHeader(http.StatusOK)
+			}
+		}))
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	err := client.DeleteAsset(tag)
+	c.Assert(err, IsNil)
+}
+
+func (s *collinsSuite) TestDeleteAssetStatusFailure(c *C) {
+	srvr, httpC := getHTTPTestClientAndServer(failureReturner)
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	errStr := ".*unexpected. Response body.*test failure.*"
+	err := client.DeleteAsset("test")
+	c.Assert(err, ErrorMatches, errStr)
+}
+
+func (s *collinsSuite) TestAddAssetLog(c *C) {
+	tag := "test"
+	srvr, httpC := getHTTPTestClientAndServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			reqStr := "/api/asset/" + tag + "/log"
+			if r.Method != "PUT" || !strings.Contains(r.RequestURI, reqStr) ||
+				r.URL.Query().Get("type") != "ERROR" ||
+				r.URL.Query().Get("message") != "job failed" {
+				http.Error(w, "unexpected request", http.StatusInternalServerError)
+			} else {
+				w.WriteHeader(http.StatusCreated)
+			}
+		}))
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	err := client.AddAssetLog(tag, "ERROR", "job failed")
+	c.Assert(err, IsNil)
+}
+
+func (s *collinsSuite) TestAddAssetLogStatusFailure(c *C) {
+	srvr, httpC := getHTTPTestClientAndServer(failureReturner)
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	errStr := ".*unexpected. Response body.*test failure.*"
+	err := client.AddAssetLog("test", "ERROR", "job failed")
+	c.Assert(err, ErrorMatches, errStr)
+}
+
+// fakeAssetLogs is a fake of the log entries of an asset in collins, that
+// serves them page by page as per the query
+type fakeAssetLogs struct {
+	tag  string
+	logs []map[string]string
+}
+
+func (f *fakeAssetLogs) add(created time.Time, mtype, message string) {
+	f.logs = append(f.logs, map[string]string{
+		"CREATED": created.Format(logTimeFormat),
+		"TYPE":    mtype,
+		"MESSAGE": message,
+	})
+}
+
+func (f *fakeAssetLogs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch {
+	case r.Method == "PUT" && strings.Contains(r.RequestURI, "/api/asset/"+f.tag+"/log?"):
+		f.add(time.Now().UTC(), query.Get("type"), query.Get("message"))
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == "GET" && strings.Contains(r.RequestURI, "/api/asset/"+f.tag+"/logs?"):
+		entries := []map[string]string{}
+		for _, l := range f.logs {
+			if filter := query.Get("filter"); filter == "" || l["TYPE"] == filter {
+				entries = append(entries, l)
+			}
+		}
+		if query.Get("sort") == "DESC" {
+			for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+				entries[i], entries[j] = entries[j], entries[i]
+			}
+		}
+		total := len(entries)
+		page, _ := strconv.Atoi(query.Get("page"))
+		size, _ := strconv.Atoi(query.Get("size"))
+		if page*size < len(entries) {
+			entries = entries[page*size:]
+		} else {
+			entries = nil
+		}
+		if len(entries) > size {
+			entries = entries[:size]
+		}
+		body, _ := json.Marshal(map[string]interface{}{
+			"data": map[string]interface{}{
+				"Pagination": map[string]int{"CurrentPage": page, "TotalResults": total},
+				"Data":       entries,
+			},
+		})
+		w.Write(body)
+	default:
+		http.Error(w, "unexpected request", http.StatusInternalServerError)
+	}
+}
+
+func (s *collinsSuite) TestGetAssetLogs(c *C) {
+	tag := "test"
+	start := time.Date(2016, 3, 1, 10, 0, 0, 0, time.UTC)
+	fake := &fakeAssetLogs{tag: tag}
+	// the logs span more than a page, along with the transitions and a note by the operator
+	for i := 0; i < maxAssetLogs+500; i++ {
+		created := start.Add(time.Duration(i) * time.Second)
+		switch {
+		case i%3 == 0:
+			fake.add(created, transitionLogType, transitionLogPrefix+"{}")
+		case i == maxAssetLogs+400:
+			fake.add(created, transitionLogType, "disk replaced")
+		default:
+			fake.add(created, "INFORMATIONAL", strconv.Itoa(i))
+		}
+	}
+	srvr, httpC := getHTTPTestClientAndServer(fake.ServeHTTP)
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	// the latest entries are returned oldest first, without the transitions
+	logs, err := client.GetAssetLogs(tag)
+	c.Assert(err, IsNil)
+	c.Assert(logs, HasLen, maxAssetLogs)
+	last := logs[len(logs)-1]
+	c.Assert(last.Time, Equals, start.Add(time.Duration(maxAssetLogs+499)*time.Second))
+	c.Assert(last.Type, Equals, "INFORMATIONAL")
+	c.Assert(last.Message, Equals, strconv.Itoa(maxAssetLogs+499))
+	notes := 0
+	for i, l := range logs {
+		c.Assert(strings.HasPrefix(l.Message, transitionLogPrefix), Equals, false)
+		if i > 0 {
+			c.Assert(l.Time.After(logs[i-1].Time), Equals, true)
+		}
+		if l.Type == transitionLogType {
+			c.Assert(l.Message, Equals, "disk replaced")
+			notes++
+		}
+	}
+	c.Assert(notes, Equals, 1)
+}
+
+func (s *collinsSuite) TestGetAssetLogsStatusFailure(c *C) {
+	srvr, httpC := getHTTPTestClientAndServer(failureReturner)
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	errStr := ".*unexpected. Response body.*test failure.*"
+	_, err := client.GetAssetLogs("test")
+	c.Assert(err, ErrorMatches, errStr)
+}
+
+func (s *collinsSuite) TestAssetTransitions(c *C) {
+	tag := "test"
+	t1 := inventory.AssetTransition{
+		Time:       time.Date(2016, 3, 1, 10, 0, 0, 0, time.UTC),
+		FromStatus: "Unallocated",
+		ToStatus:   "Provisioning",
+		TransitionCause: inventory.TransitionCause{
+			Trigger: "job 1",
+			Actor:   "user",
+		},
+	}
+	t2 := t1
+	t2.Time = time.Date(2016, 3, 2, 10, 0, 0, 0, time.UTC)
+	fake := &fakeAssetLogs{tag: tag}
+	srvr, httpC := getHTTPTestClientAndServer(fake.ServeHTTP)
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	c.Assert(client.AddAssetTransition(tag, t1), IsNil)
+	c.Assert(fake.logs[0]["TYPE"], Equals, transitionLogType)
+	c.Assert(strings.HasPrefix(fake.logs[0]["MESSAGE"], transitionLogPrefix), Equals, true)
+	// the notes by the operator are not transitions
+	fake.add(t1.Time, transitionLogType, "disk replaced")
+	c.Assert(client.AddAssetTransition(tag, t2), IsNil)
+	transitions, err := client.GetAssetTransitions(tag, time.Time{}, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(transitions, HasLen, 2)
+	c.Assert(transitions[0].Time.Equal(t1.Time), Equals, true)
+	c.Assert(transitions[0].TransitionCause, DeepEquals, t1.TransitionCause)
+
+	transitions, err = client.GetAssetTransitions(tag, t2.Time, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(transitions, HasLen, 1)
+	c.Assert(transitions[0].Time.Equal(t2.Time), Equals, true)
+}
+
+func (s *collinsSuite) TestAssetTransitionsPages(c *C) {
+	tag := "test"
+	start := time.Date(2016, 3, 1, 10, 0, 0, 0, time.UTC)
+	fake := &fakeAssetLogs{tag: tag}
+	// the transitions span more than two pages
+	for i := 0; i < 2*assetLogsPageSize+10; i++ {
+		t := inventory.AssetTransition{Time: start.Add(time.Duration(i) * time.Minute)}
+		msg, err := json.Marshal(t)
+		c.Assert(err, IsNil)
+		fake.add(t.Time, transitionLogType, transitionLogPrefix+string(msg))
+	}
+	srvr, httpC := getHTTPTestClientAndServer(fake.ServeHTTP)
+	defer srvr.Close()
+	client := &Client{
+		config: DefaultConfig(),
+		client: httpC,
+	}
+
+	transitions, err := client.GetAssetTransitions(tag, time.Time{}, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(transitions, HasLen, 2*assetLogsPageSize+10)
+	c.Assert(transitions[len(transitions)-1].Time.Equal(start.Add(time.Duration(2*assetLogsPageSize+9)*time.Minute)),
+		Equals, true)
+
+	// the range is looked up across the pages
+	from := start.Add(time.Duration(assetLogsPageSize-5) * time.Minute)
+	to := start.Add(time.Duration(assetLogsPageSize+4) * time.Minute)
+	transitions, err = client.GetAssetTransitions(tag, from, to)
+	c.Assert(err, IsNil)
+	c.Assert(transitions, HasLen, 10)
+	c.Assert(transitions[0].Time.Equal(from), Equals, true)
+	c.Assert(transitions[9].Time.Equal(to), Equals, true)
+}
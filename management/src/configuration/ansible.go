@@ -17,8 +17,11 @@ type AnsibleSubsysConfig struct {
 	ConfigurePlaybook string `json:"configure_playbook"`
 	CleanupPlaybook   string `json:"cleanup_playbook"`
 	UpgradePlaybook   string `json:"upgrade_playbook"`
-	PlaybookLocation  string `json:"playbook_location"`
-	ExtraVariables    string `json:"extra_variables"`
+	// VerifyPlaybook is run to verify the configuration of a commissioned node
+	// when it reappears. The verification is not done when it is empty.
+	VerifyPlaybook   string `json:"verify_playbook"`
+	PlaybookLocation string `json:"playbook_location"`
+	ExtraVariables   string `json:"extra_variables"`
 	// XXX: revisit the user credential configuration. We may need to allow other provisions.
 	User        string `json:"user"`
 	PrivKeyFile string `json:"priv_key_file"`
@@ -58,6 +61,20 @@ func (h *AnsibleHost) GetGroup() string {
 	return h.group
 }
 
+// GetAddr return the address of the host used by ansible to reach it
+func (h *AnsibleHost) GetAddr() string {
+	return h.addr
+}
+
+// GetVars returns a copy of the host variables
+func (h *AnsibleHost) GetVars() map[string]string {
+	vars := map[string]string{}
+	for k, v := range h.vars {
+		vars[k] = v
+	}
+	return vars
+}
+
 // SetVar sets a host variable value
 func (h *AnsibleHost) SetVar(key, val string) {
 	h.vars[key] = val
@@ -151,7 +168,13 @@ func (a *AnsibleSubsys) ansibleRunner(nodes []*AnsibleHost, playbook, extraVars
 	r, w := io.Pipe()
 	go func(outStream io.Writer, errCh chan error) {
 		defer r.Close()
-		if err := runner.Run(outStream, outStream); err != nil {
+		// parse the play recap from the output to report the failures per host
+		recap := ansible.NewRecapParser()
+		if err := runner.Run(io.MultiWriter(outStream, recap), outStream); err != nil {
+			if results := hostResults(nodes, recap.Recaps()); results != nil {
+				errCh <- &HostsError{Err: err, Results: results}
+				return
+			}
 			errCh <- err
 			return
 		}
@@ -179,6 +202,12 @@ func (a *AnsibleSubsys) Upgrade(nodes SubsysHosts, extraVars string) (io.Reader,
 		a.config.UpgradePlaybook}, "/"), extraVars)
 }
 
+// Verify triggers the ansible playbook for verification on specified nodes
+func (a *AnsibleSubsys) Verify(nodes SubsysHosts, extraVars string) (io.Reader, context.CancelFunc, chan error) {
+	return a.ansibleRunner(nodes.([]*AnsibleHost), strings.Join([]string{a.config.PlaybookLocation,
+		a.config.VerifyPlaybook}, "/"), extraVars)
+}
+
 // SetGlobals sets the extra vars at a ansible subsys level
 func (a *AnsibleSubsys) SetGlobals(extraVars string) error {
 	a.globalExtraVars = extraVars
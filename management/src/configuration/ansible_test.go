@@ -6,6 +6,9 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/contiv/cluster/management/src/ansible"
+	"github.com/contiv/errored"
+
 	. "gopkg.in/check.v1"
 )
 
@@ -69,3 +72,29 @@ func (s *ansibleSuite) TestMergeExtraVarsInvalidJSON(c *C) {
 	c.Assert(err, ErrorMatches, "failed to unmarshal src extra vars.*",
 		Commentf("output string: %s", out))
 }
+
+func (s *ansibleSuite) TestHostResults(c *C) {
+	nodes := []*AnsibleHost{
+		NewAnsibleHost("n1", "a1", "g", nil),
+		NewAnsibleHost("n2", "a2", "g", nil),
+		NewAnsibleHost("n3", "a3", "g", nil),
+		NewAnsibleHost("n4", "a4", "g", nil),
+	}
+	recaps := map[string]ansible.HostRecap{
+		"n1": {OK: 2, Changed: 1},
+		"n2": {OK: 1, Failed: 1},
+		"n3": {Unreachable: 1},
+	}
+	exptdResults := map[string]HostResult{
+		"n1": HostResultOK,
+		"n2": HostResultFailed,
+		"n3": HostResultUnreachable,
+		"n4": HostResultFailed,
+	}
+
+	c.Assert(hostResults(nodes, recaps), DeepEquals, exptdResults)
+	c.Assert(hostResults(nodes, map[string]ansible.HostRecap{}), IsNil)
+
+	err := &HostsError{Err: errored.Errorf("test failure"), Results: exptdResults}
+	c.Assert(err.FailedHosts(), DeepEquals, []string{"n2", "n3", "n4"})
+}
@@ -5,11 +5,16 @@ import (
 	"io"
 
 	"golang.org/x/net/context"
+
+	"github.com/contiv/cluster/management/src/inventory"
 )
 
 // Subsys provides the following services to the cluster manager:
 // - Interface to trigger configuration action on one or more nodes, with
-//   possible actions being configure, cleanup and upgrade.
+//   possible actions being configure, cleanup, upgrade and verify.
+// - Interface to gather the hardware facts of one or more nodes.
+// When an action fails on some of the nodes, the error received on the error
+// channel is a *HostsError that carries the outcome of the action per node.
 type Subsys interface {
 	// Configure triggers the configuration logic on specified set of nodes.
 	// It return a error channel that the caller can wait on to get completion status.
@@ -20,6 +25,13 @@ type Subsys interface {
 	// Cleanup triggers the configuration upgrade on specified set of nodes.
 	// It return a error channel that the caller can wait on to get completion status.
 	Upgrade(nodes SubsysHosts, extraVars string) (io.Reader, context.CancelFunc, chan error)
+	// Verify triggers the verification of the configuration on specified set of nodes.
+	// It return a error channel that the caller can wait on to get completion status.
+	Verify(nodes SubsysHosts, extraVars string) (io.Reader, context.CancelFunc, chan error)
+	// GatherFacts gathers the hardware facts, like cpus, memory, disks and nics, of specified
+	// set of nodes. It returns the facts of the nodes where they were gathered, keyed by the
+	// node's tag, along with the error if the gathering failed on some nodes.
+	GatherFacts(nodes SubsysHosts) (map[string]inventory.AssetFacts, error)
 	// SetGlobals sets the extra vars at a configuration subsys level
 	SetGlobals(extraVars string) error
 	// GetGlobals return the value of extra vars at a configuration subsys level
@@ -0,0 +1,112 @@
+package configuration
+
+import (
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/contiv/cluster/management/src/ansible"
+	"github.com/contiv/cluster/management/src/inventory"
+)
+
+// gatherFactsTimeout is the time after which the facts gathering is cancelled
+const gatherFactsTimeout = 5 * time.Minute
+
+// GatherFacts gathers the hardware facts of the specified nodes using ansible's
+// setup module
+func (a *AnsibleSubsys) GatherFacts(nodes SubsysHosts) (map[string]inventory.AssetFacts, error) {
+	iNodes := []ansible.InventoryHost{}
+	for _, n := range nodes.([]*AnsibleHost) {
+		iNodes = append(iNodes, ansible.NewInventoryHost(n.tag, n.addr, n.group, n.vars))
+	}
+
+	ctxt, cancelFunc := context.WithTimeout(context.Background(), gatherFactsTimeout)
+	defer cancelFunc()
+	runner := ansible.NewFactsRunner(ansible.NewInventory(iNodes), a.config.User, a.config.PrivKeyFile, ctxt)
+	hostsFacts, err := runner.Run(ioutil.Discard, ioutil.Discard)
+
+	facts := map[string]inventory.AssetFacts{}
+	for name, hostFacts := range hostsFacts {
+		facts[name] = parseFacts(hostFacts)
+	}
+	return facts, err
+}
+
+// parseFacts returns the hardware facts of a host from the facts gathered by
+// ansible. The facts that are missing are left as zero values.
+func parseFacts(f ansible.Facts) inventory.AssetFacts {
+	facts := inventory.AssetFacts{
+		CPUs:       factInt(f, "ansible_processor_vcpus"),
+		MemoryMB:   factInt(f, "ansible_memtotal_mb"),
+		OS:         factString(f, "ansible_distribution"),
+		OSVersion:  factString(f, "ansible_distribution_version"),
+		Kernel:     factString(f, "ansible_kernel"),
+		GatheredAt: time.Now(),
+	}
+
+	// ansible_processor lists the index, vendor and model of each processor
+	if procs, ok := f["ansible_processor"].([]interface{}); ok && len(procs) > 0 {
+		facts.CPUModel, _ = procs[len(procs)-1].(string)
+	}
+
+	if devices, ok := f["ansible_devices"].(map[string]interface{}); ok {
+		for _, name := range sortedFactKeys(devices) {
+			device, _ := devices[name].(map[string]interface{})
+			facts.Disks = append(facts.Disks, inventory.DiskFacts{
+				Name:  name,
+				Size:  factString(device, "size"),
+				Model: factString(device, "model"),
+			})
+		}
+	}
+
+	if ifaces, ok := f["ansible_interfaces"].([]interface{}); ok {
+		names := []string{}
+		for _, i := range ifaces {
+			if name, ok := i.(string); ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			// the facts of an interface are keyed by it's name, with the dashes replaced
+			iface, ok := f["ansible_"+strings.Replace(name, "-", "_", -1)].(map[string]interface{})
+			if !ok || factString(iface, "macaddress") == "" || factString(iface, "type") == "loopback" {
+				continue
+			}
+			nic := inventory.NICFacts{
+				Name: name,
+				MAC:  factString(iface, "macaddress"),
+			}
+			if ipv4, ok := iface["ipv4"].(map[string]interface{}); ok {
+				nic.Addr = factString(ipv4, "address")
+			}
+			facts.NICs = append(facts.NICs, nic)
+		}
+	}
+
+	return facts
+}
+
+func factString(f map[string]interface{}, key string) string {
+	val, _ := f[key].(string)
+	return val
+}
+
+func factInt(f map[string]interface{}, key string) int {
+	// json numbers are decoded as float64
+	val, _ := f[key].(float64)
+	return int(val)
+}
+
+func sortedFactKeys(m map[string]interface{}) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
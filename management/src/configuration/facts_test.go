@@ -0,0 +1,55 @@
+// +build unittest
+
+package configuration
+
+import (
+	"encoding/json"
+
+	"github.com/contiv/cluster/management/src/ansible"
+	"github.com/contiv/cluster/management/src/inventory"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *ansibleSuite) TestParseFacts(c *C) {
+	f := ansible.Facts{}
+	c.Assert(json.Unmarshal([]byte(`{
+		"ansible_processor": ["0", "GenuineIntel", "Intel(R) Xeon(R) CPU E5-2650 v2 @ 2.60GHz"],
+		"ansible_processor_vcpus": 2,
+		"ansible_memtotal_mb": 3791,
+		"ansible_distribution": "CentOS",
+		"ansible_distribution_version": "7.2.1511",
+		"ansible_kernel": "3.10.0-327.el7.x86_64",
+		"ansible_devices": {
+			"sdb": {"size": "10.00 GB", "model": "VBOX HARDDISK"},
+			"sda": {"size": "40.00 GB", "model": "VBOX HARDDISK"}
+		},
+		"ansible_interfaces": ["lo", "eth1", "docker-0"],
+		"ansible_lo": {"type": "loopback", "ipv4": {"address": "127.0.0.1"}},
+		"ansible_eth1": {"type": "ether", "macaddress": "08:00:27:a8:bd:1e", "ipv4": {"address": "192.168.2.10"}},
+		"ansible_docker_0": {"type": "bridge", "macaddress": "02:42:6b:3e:5f:7a"}
+	}`), &f), IsNil)
+
+	facts := parseFacts(f)
+	c.Assert(facts.GatheredAt.IsZero(), Equals, false)
+	facts.GatheredAt = inventory.AssetFacts{}.GatheredAt
+	c.Assert(facts, DeepEquals, inventory.AssetFacts{
+		CPUs:      2,
+		CPUModel:  "Intel(R) Xeon(R) CPU E5-2650 v2 @ 2.60GHz",
+		MemoryMB:  3791,
+		OS:        "CentOS",
+		OSVersion: "7.2.1511",
+		Kernel:    "3.10.0-327.el7.x86_64",
+		Disks: []inventory.DiskFacts{
+			{Name: "sda", Size: "40.00 GB", Model: "VBOX HARDDISK"},
+			{Name: "sdb", Size: "10.00 GB", Model: "VBOX HARDDISK"},
+		},
+		NICs: []inventory.NICFacts{
+			{Name: "docker-0", MAC: "02:42:6b:3e:5f:7a"},
+			{Name: "eth1", MAC: "08:00:27:a8:bd:1e", Addr: "192.168.2.10"},
+		},
+	})
+
+	// missing facts are left empty
+	c.Assert(parseFacts(ansible.Facts{}).CPUs, Equals, 0)
+}
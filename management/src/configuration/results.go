@@ -0,0 +1,69 @@
+package configuration
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/contiv/cluster/management/src/ansible"
+)
+
+// HostResult is the outcome of a configuration action on a host
+type HostResult string
+
+const (
+	// HostResultOK is the result of a host where the action succeeded
+	HostResultOK HostResult = "ok"
+	// HostResultFailed is the result of a host where the action failed
+	HostResultFailed HostResult = "failed"
+	// HostResultUnreachable is the result of a host that couldn't be reached
+	HostResultUnreachable HostResult = "unreachable"
+)
+
+// HostsError is the error returned by a configuration action that failed on
+// one or more hosts. It carries the outcome of the action on each host, so that
+// the hosts where the action succeeded can be told apart from the failed ones.
+type HostsError struct {
+	Err     error
+	Results map[string]HostResult
+}
+
+func (e *HostsError) Error() string {
+	return fmt.Sprintf("%v. Failed hosts: %v", e.Err, e.FailedHosts())
+}
+
+// FailedHosts returns the tags of the hosts where the action didn't succeed
+func (e *HostsError) FailedHosts() []string {
+	hosts := []string{}
+	for tag, result := range e.Results {
+		if result != HostResultOK {
+			hosts = append(hosts, tag)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// hostResults returns the outcome of a playbook run on each of the hosts, as
+// per the play recap. A host that is missing in the recap is treated as failed.
+// It returns nil if the recap is not available.
+func hostResults(nodes []*AnsibleHost, recaps map[string]ansible.HostRecap) map[string]HostResult {
+	if len(recaps) == 0 {
+		return nil
+	}
+
+	results := map[string]HostResult{}
+	for _, n := range nodes {
+		recap, ok := recaps[n.tag]
+		switch {
+		case !ok:
+			results[n.tag] = HostResultFailed
+		case recap.Unreachable > 0:
+			results[n.tag] = HostResultUnreachable
+		case recap.Failed > 0:
+			results[n.tag] = HostResultFailed
+		default:
+			results[n.tag] = HostResultOK
+		}
+	}
+	return results
+}
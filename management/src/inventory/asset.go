@@ -2,7 +2,10 @@ package inventory
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/contiv/errored"
@@ -13,6 +16,7 @@ var StateDescription = map[AssetState]string{
 	Unknown:     "Node is in unknown state. This is the first state before initialization.",
 	Discovered:  "Node is alive and discovered in monitoring subsystem",
 	Disappeared: "Node has disappeared from monitoring subsystem. Check for possible hardware or network issues",
+	Degraded:    "Node is alive, but the verification of it's configuration failed. Check the node's services and update the node",
 }
 
 var (
@@ -38,6 +42,7 @@ var AssetStateVals = map[string]AssetState{
 	strings.ToUpper(Unknown.String()):     Unknown,
 	strings.ToUpper(Discovered.String()):  Discovered,
 	strings.ToUpper(Disappeared.String()): Disappeared,
+	strings.ToUpper(Degraded.String()):    Degraded,
 }
 
 var lifecycleStatus = map[AssetStatus]map[AssetStatus]bool{
@@ -49,10 +54,13 @@ var lifecycleStatus = map[AssetStatus]map[AssetStatus]bool{
 		Provisioning: true,
 	},
 	Provisioning: {
+		Unallocated: true,
+		Provisioned: true,
+	},
+	Provisioned: {
 		Unallocated: true,
 		Allocated:   true,
 	},
-	Provisioned: {},
 	Allocated: {
 		Cancelled:   true,
 		Maintenance: true,
@@ -75,38 +83,135 @@ var lifecycleStates = map[AssetStatus]map[AssetState]bool{
 	Unallocated: {
 		Discovered:  true,
 		Disappeared: true,
+		Degraded:    true,
 	},
 	Provisioning: {
 		Discovered:  true,
 		Disappeared: true,
+		Degraded:    true,
+	},
+	Provisioned: {
+		Discovered:  true,
+		Disappeared: true,
+		Degraded:    true,
 	},
-	Provisioned: {},
 	Allocated: {
 		Discovered:  true,
 		Disappeared: true,
+		Degraded:    true,
 	},
 	Cancelled: {
 		Discovered:  true,
 		Disappeared: true,
+		Degraded:    true,
 	},
 	Decommissioned: {
 		Discovered:  true,
 		Disappeared: true,
+		Degraded:    true,
 	},
 	Maintenance: {
 		Discovered:  true,
 		Disappeared: true,
+		Degraded:    true,
 	},
 }
 
+// AssetConfig is the configuration state of an asset i.e. it's host group,
+// address and variables in configuration management. It is kept in the
+// inventory so that it can be restored across restarts of cluster manager.
+type AssetConfig struct {
+	Group string
+	Addr  string
+	Vars  map[string]string
+}
+
+// AssetFacts are the hardware facts of an asset, like it's cpus, memory,
+// disks and nics, as gathered from the host by configuration management. They
+// are kept in the inventory to aid capacity reports and placement decisions.
+type AssetFacts struct {
+	CPUs       int         `json:"cpus"`
+	CPUModel   string      `json:"cpu_model,omitempty"`
+	MemoryMB   int         `json:"memory_mb"`
+	Disks      []DiskFacts `json:"disks,omitempty"`
+	NICs       []NICFacts  `json:"nics,omitempty"`
+	OS         string      `json:"os"`
+	OSVersion  string      `json:"os_version"`
+	Kernel     string      `json:"kernel,omitempty"`
+	GatheredAt time.Time   `json:"gathered_at"`
+}
+
+// DiskFacts are the facts of a disk of an asset
+type DiskFacts struct {
+	Name  string `json:"name"`
+	Size  string `json:"size"`
+	Model string `json:"model,omitempty"`
+}
+
+// NICFacts are the facts of a network interface of an asset
+type NICFacts struct {
+	Name string `json:"name"`
+	MAC  string `json:"mac"`
+	Addr string `json:"addr,omitempty"`
+}
+
+// The types of the asset log entries, as defined by collins
+const (
+	// LogTypeInfo is the type of the log entries for the routine events, like a status change
+	LogTypeInfo = "INFORMATIONAL"
+	// LogTypeWarning is the type of the log entries for the events that may need
+	// attention, like an asset disappearing
+	LogTypeWarning = "WARNING"
+	// LogTypeError is the type of the log entries for the failures, like a failed job
+	LogTypeError = "ERROR"
+)
+
+// AssetLog is an entry in the logs of an asset. The logs record the events of an
+// asset, like it's status transitions and the jobs run on it, along with their
+// failures.
+type AssetLog struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Message string    `json:"message"`
+}
+
+// AssetTransition is an entry in the transition history of an asset, recorded
+// for every change of it's status and/or state
+type AssetTransition struct {
+	Time       time.Time `json:"time"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	FromState  string    `json:"from_state"`
+	ToState    string    `json:"to_state"`
+	Reason     string    `json:"reason,omitempty"`
+	TransitionCause
+}
+
+// TransitionCause identifies what caused a transition of an asset i.e. the
+// triggering event or job, like "job 12", and the actor behind it, like the
+// user or the monitoring subsystem
+type TransitionCause struct {
+	Trigger string `json:"trigger,omitempty"`
+	Actor   string `json:"actor,omitempty"`
+}
+
+// TransitionCauser returns the cause of a transition of the named asset, at
+// the time of the transition
+type TransitionCauser func(name string) TransitionCause
+
 // Asset denotes a host or vm that is managed by the inventory susystem
 type Asset struct {
 	client     SubsysClient
 	name       string
+	mutex      sync.RWMutex // protects the fields below, as the jobs update the asset concurrently
 	status     AssetStatus
 	prevStatus AssetStatus
 	state      AssetState
 	prevState  AssetState
+	config     AssetConfig
+	facts      *AssetFacts
+	attributes map[string]string
+	causer     TransitionCauser
 }
 
 // NewAssetWithState creates a new asset in the inventory in a discovered state and returns it.
@@ -123,6 +228,12 @@ func NewAssetWithState(client SubsysClient, name string, status AssetStatus, sta
 
 // NewAsset creates a new asset in the inventory in a discovered state and returns it.
 func NewAsset(client SubsysClient, name string) (*Asset, error) {
+	return newAssetWithCauser(client, name, nil)
+}
+
+// newAssetWithCauser is like NewAsset, except that the cause of the asset's
+// transitions is identified by the specified causer
+func newAssetWithCauser(client SubsysClient, name string, causer TransitionCauser) (*Asset, error) {
 	a := &Asset{
 		client:     client,
 		name:       name,
@@ -130,6 +241,7 @@ func NewAsset(client SubsysClient, name string) (*Asset, error) {
 		prevStatus: Incomplete,
 		state:      Discovered,
 		prevState:  Unknown,
+		causer:     causer,
 	}
 
 	if err := a.client.CreateAsset(name, a.status.String()); err != nil {
@@ -141,6 +253,8 @@ func NewAsset(client SubsysClient, name string) (*Asset, error) {
 		return nil, err
 	}
 
+	a.recordTransition(StateDescription[a.state])
+	a.log(LogTypeInfo, fmt.Sprintf("asset created in status: %q and state: %q", a.status, a.state))
 	logrus.Debugf("created asset: %+v", a)
 	return a, nil
 }
@@ -148,6 +262,39 @@ func NewAsset(client SubsysClient, name string) (*Asset, error) {
 // SetStatus updates the status and/or state of an asset in the inventory after
 // performing lifecyslce related validations.
 func (a *Asset) SetStatus(status AssetStatus, state AssetState) error {
+	return a.SetStatusWithReason(status, state, "")
+}
+
+// SetStatusWithReason is like SetStatus, except that the specified reason is
+// recorded in the inventory instead of the state's description. An empty reason
+// records the state's description.
+func (a *Asset) SetStatusWithReason(status AssetStatus, state AssetState, reason string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.setStatusAndState(status, state, reason)
+}
+
+// setStatus updates the status of an asset, keeping it's current state
+func (a *Asset) setStatus(status AssetStatus, reason string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.setStatusAndState(status, a.state, reason)
+}
+
+// setState updates the state of an asset, keeping it's current status
+func (a *Asset) setState(state AssetState) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.setStatusAndState(a.status, state, "")
+}
+
+// setStatusAndState does the work of SetStatusWithReason. It is called with the
+// asset locked.
+func (a *Asset) setStatusAndState(status AssetStatus, state AssetState, reason string) error {
+	if reason == "" {
+		reason = StateDescription[state]
+	}
+
 	if a.status == status && a.state == state {
 		logrus.Infof("asset already in status: %q and state: %q, no action required", status, state)
 		return nil
@@ -161,7 +308,7 @@ func (a *Asset) SetStatus(status AssetStatus, state AssetState) error {
 		return errored.Errorf("%q is not a valid state when asset is in %q status", state, status)
 	}
 
-	if err := a.client.SetAssetStatus(a.name, status.String(), state.String(), StateDescription[state]); err != nil {
+	if err := a.client.SetAssetStatus(a.name, status.String(), state.String(), reason); err != nil {
 		return err
 	}
 
@@ -170,11 +317,166 @@ func (a *Asset) SetStatus(status AssetStatus, state AssetState) error {
 	a.status = status
 	a.state = state
 
+	a.recordTransition(reason)
+
+	changes := []string{}
+	if a.prevStatus != a.status {
+		changes = append(changes, fmt.Sprintf("status changed from %q to %q", a.prevStatus, a.status))
+	}
+	mtype := LogTypeInfo
+	if a.prevState != a.state {
+		changes = append(changes, fmt.Sprintf("state changed from %q to %q", a.prevState, a.state))
+		if state == Disappeared || state == Degraded {
+			mtype = LogTypeWarning
+		}
+	}
+	a.log(mtype, fmt.Sprintf("%s. %s", strings.Join(changes, " and "), reason))
+
+	return nil
+}
+
+// recordTransition records the last transition in the transition history of the
+// asset, if the inventory client supports it. Like the logs the history is best
+// effort, a failure to record it is logged and ignored.
+func (a *Asset) recordTransition(reason string) {
+	recorder, ok := a.client.(SubsysTransitionRecorder)
+	if !ok {
+		return
+	}
+	t := AssetTransition{
+		Time:       time.Now(),
+		FromStatus: a.prevStatus.String(),
+		ToStatus:   a.status.String(),
+		FromState:  a.prevState.String(),
+		ToState:    a.state.String(),
+		Reason:     reason,
+	}
+	if a.causer != nil {
+		t.TransitionCause = a.causer(a.name)
+	}
+	if err := recorder.AddAssetTransition(a.name, t); err != nil {
+		logrus.Errorf("failed to record transition for asset %q. Error: %v", a.name, err)
+	}
+}
+
+// log records an entry in the logs of the asset. The logs are best effort, a
+// failure to record an entry is logged and ignored.
+func (a *Asset) log(mtype, message string) {
+	if err := a.client.AddAssetLog(a.name, mtype, message); err != nil {
+		logrus.Errorf("failed to add log for asset %q. Error: %v", a.name, err)
+	}
+}
+
+// SetConfig updates the configuration state of an asset in the inventory.
+func (a *Asset) SetConfig(config AssetConfig) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if err := a.client.SetAssetConfig(a.name, config.Group, config.Addr, config.Vars); err != nil {
+		return err
+	}
+
+	a.config = config
 	return nil
 }
 
+// RestoreConfig sets the configuration state of an asset, as read from the
+// inventory. Unlike SetConfig it doesn't update the inventory.
+func (a *Asset) RestoreConfig(config AssetConfig) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.config = config
+}
+
+// GetConfig returns the configuration state of an asset.
+func (a *Asset) GetConfig() AssetConfig {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.config
+}
+
+// SetFacts updates the hardware facts of an asset in the inventory, if the
+// inventory client supports it.
+func (a *Asset) SetFacts(facts AssetFacts) error {
+	recorder, ok := a.client.(SubsysFactsRecorder)
+	if !ok {
+		return errored.Errorf("the hardware facts of an asset are not supported by the inventory")
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if err := recorder.SetAssetFacts(a.name, facts); err != nil {
+		return err
+	}
+
+	a.facts = &facts
+	return nil
+}
+
+// RestoreFacts sets the hardware facts of an asset, as read from the
+// inventory. Unlike SetFacts it doesn't update the inventory.
+func (a *Asset) RestoreFacts(facts *AssetFacts) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.facts = facts
+}
+
+// GetFacts returns the hardware facts of an asset, nil if they were not gathered.
+func (a *Asset) GetFacts() *AssetFacts {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.facts
+}
+
+// SetAttributes updates the attributes of an asset, like the labels set by the
+// operator, in the inventory. The specified attributes are merged with the
+// existing ones, an attribute with an empty value is removed.
+func (a *Asset) SetAttributes(attrs map[string]string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	merged := a.copyAttributes()
+	for k, v := range attrs {
+		if v == "" {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+	if err := a.client.SetAssetAttributes(a.name, merged); err != nil {
+		return err
+	}
+
+	a.attributes = merged
+	return nil
+}
+
+// RestoreAttributes sets the attributes of an asset, as read from the
+// inventory. Unlike SetAttributes it doesn't update the inventory.
+func (a *Asset) RestoreAttributes(attrs map[string]string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.attributes = attrs
+}
+
+// GetAttributes returns a copy of the attributes of an asset.
+func (a *Asset) GetAttributes() map[string]string {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.copyAttributes()
+}
+
+// copyAttributes returns a copy of the attributes of an asset. It is called with
+// the asset locked.
+func (a *Asset) copyAttributes() map[string]string {
+	attrs := map[string]string{}
+	for k, v := range a.attributes {
+		attrs[k] = v
+	}
+	return attrs
+}
+
 // GetStatus returns the current status and state of an asset.
 func (a *Asset) GetStatus() (AssetStatus, AssetState) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
 	return a.status, a.state
 }
 
@@ -186,17 +488,23 @@ func (a *Asset) GetTag() string {
 // MarshalJSON implements the json marshaller for asset. It is done this way
 // than making the fields public inorder to safeguard against direct state interpolation.
 func (a *Asset) MarshalJSON() ([]byte, error) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
 	return json.Marshal(struct {
-		Name       string `json:"name"`
-		Status     string `json:"status"`
-		PrevStatus string `json:"prev_status"`
-		State      string `json:"state"`
-		PrevState  string `json:"prev_state"`
+		Name       string            `json:"name"`
+		Status     string            `json:"status"`
+		PrevStatus string            `json:"prev_status"`
+		State      string            `json:"state"`
+		PrevState  string            `json:"prev_state"`
+		Facts      *AssetFacts       `json:"facts,omitempty"`
+		Attributes map[string]string `json:"attributes,omitempty"`
 	}{
 		Name:       a.name,
 		Status:     a.status.String(),
 		PrevStatus: a.prevStatus.String(),
 		State:      a.state.String(),
 		PrevState:  a.prevState.String(),
+		Facts:      a.facts,
+		Attributes: a.attributes,
 	})
 }
@@ -4,6 +4,7 @@ package inventory
 
 import (
 	"testing"
+	"time"
 
 	"github.com/contiv/cluster/management/src/mock"
 	"github.com/contiv/errored"
@@ -35,6 +36,8 @@ func (s *inventorySuite) TestNewAsset(c *C) {
 	mClient.EXPECT().CreateAsset(eAsset.name, eAsset.status.String())
 	mClient.EXPECT().SetAssetStatus(eAsset.name, eAsset.status.String(),
 		eAsset.state.String(), StateDescription[eAsset.state])
+	mClient.EXPECT().AddAssetLog(eAsset.name, LogTypeInfo,
+		`asset created in status: "Unallocated" and state: "Discovered"`)
 	rAsset, err := NewAsset(mClient, eAsset.name)
 	c.Assert(err, IsNil)
 	c.Assert(rAsset, DeepEquals, eAsset)
@@ -102,6 +105,9 @@ func (s *inventorySuite) TestSetStatus(c *C) {
 	}
 	mClient.EXPECT().SetAssetStatus(asset.name, eAsset.status.String(),
 		eAsset.state.String(), StateDescription[eAsset.state])
+	mClient.EXPECT().AddAssetLog(asset.name, LogTypeWarning,
+		`status changed from "Unallocated" to "Provisioning" and state changed from "Discovered" to "Disappeared". `+
+			StateDescription[eAsset.state])
 	err := asset.SetStatus(eAsset.status, eAsset.state)
 	c.Assert(err, IsNil)
 	c.Assert(asset, DeepEquals, eAsset)
@@ -200,3 +206,258 @@ func (s *inventorySuite) TestSetStatusSetFailure(c *C) {
 	c.Assert(err, NotNil)
 	c.Assert(asset, DeepEquals, eAsset)
 }
+
+func (s *inventorySuite) TestSetConfig(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mClient := mock.NewMockSubsysClient(ctrl)
+	asset := NewAssetWithState(mClient, "foo", Allocated, Discovered)
+	config := AssetConfig{
+		Group: "service-worker",
+		Addr:  "1.1.1.1",
+		Vars:  map[string]string{"node_name": "foo"},
+	}
+	mClient.EXPECT().SetAssetConfig(asset.name, config.Group, config.Addr, config.Vars)
+	err := asset.SetConfig(config)
+	c.Assert(err, IsNil)
+	c.Assert(asset.GetConfig(), DeepEquals, config)
+}
+
+func (s *inventorySuite) TestSetConfigFailure(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mClient := mock.NewMockSubsysClient(ctrl)
+	asset := NewAssetWithState(mClient, "foo", Allocated, Discovered)
+	restored := AssetConfig{Group: "service-master", Addr: "1.1.1.1"}
+	asset.RestoreConfig(restored)
+	mClient.EXPECT().SetAssetConfig(asset.name, "service-worker", "1.1.1.1",
+		nil).Return(errored.Errorf("test failure"))
+	err := asset.SetConfig(AssetConfig{Group: "service-worker", Addr: "1.1.1.1"})
+	c.Assert(err, NotNil)
+	c.Assert(asset.GetConfig(), DeepEquals, restored)
+}
+
+func (s *inventorySuite) TestSetAttributes(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mClient := mock.NewMockSubsysClient(ctrl)
+	inv := NewGeneralSubsys(mClient)
+	asset := NewAssetWithState(mClient, "foo", Unallocated, Discovered)
+	asset.RestoreAttributes(map[string]string{"rack": "r1", "owner": "team1"})
+	c.Assert(inv.RestoreAsset("foo", asset), IsNil)
+
+	// the attributes are merged and the ones with empty value are removed
+	exptd := map[string]string{"rack": "r2", "zone": "z1"}
+	mClient.EXPECT().SetAssetAttributes("foo", exptd)
+	c.Assert(inv.SetAssetAttributes("foo", map[string]string{"rack": "r2", "zone": "z1", "owner": ""}), IsNil)
+	attrs, err := inv.GetAssetAttributes("foo")
+	c.Assert(err, IsNil)
+	c.Assert(attrs, DeepEquals, exptd)
+
+	// the attributes are left as is on failure
+	mClient.EXPECT().SetAssetAttributes("foo", gomock.Any()).Return(errored.Errorf("test failure"))
+	c.Assert(inv.SetAssetAttributes("foo", map[string]string{"rack": "r3"}), ErrorMatches, "test failure")
+	attrs, err = inv.GetAssetAttributes("foo")
+	c.Assert(err, IsNil)
+	c.Assert(attrs, DeepEquals, exptd)
+
+	_, err = inv.GetAssetAttributes("bar")
+	c.Assert(err, ErrorMatches, ".*doesn't exists")
+	c.Assert(inv.SetAssetAttributes("bar", exptd), ErrorMatches, ".*doesn't exists")
+}
+
+func (s *inventorySuite) TestSetAssetDegraded(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mClient := mock.NewMockSubsysClient(ctrl)
+	inv := NewGeneralSubsys(mClient)
+	c.Assert(inv.RestoreAsset("foo", NewAssetWithState(mClient, "foo", Allocated, Discovered)), IsNil)
+	mClient.EXPECT().SetAssetStatus("foo", Allocated.String(), Degraded.String(), StateDescription[Degraded])
+	mClient.EXPECT().AddAssetLog("foo", LogTypeWarning,
+		`state changed from "Discovered" to "Degraded". `+StateDescription[Degraded])
+	c.Assert(inv.SetAssetDegraded("foo"), IsNil)
+	status, state := inv.GetAsset("foo").GetStatus()
+	c.Assert(status, Equals, Allocated)
+	c.Assert(state, Equals, Degraded)
+
+	c.Assert(inv.SetAssetDegraded("bar"), ErrorMatches, ".*doesn't exists")
+}
+
+func (s *inventorySuite) TestSetStatusLogFailure(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	// a failure to record the log entry doesn't fail the transition
+	mClient := mock.NewMockSubsysClient(ctrl)
+	inv := NewGeneralSubsys(mClient)
+	c.Assert(inv.RestoreAsset("foo", NewAssetWithState(mClient, "foo", Unallocated, Discovered)), IsNil)
+	mClient.EXPECT().SetAssetStatus("foo", Provisioning.String(), Discovered.String(), StateDescription[Discovered])
+	mClient.EXPECT().AddAssetLog("foo", LogTypeInfo, gomock.Any()).Return(errored.Errorf("test failure"))
+	c.Assert(inv.SetAssetProvisioning("foo"), IsNil)
+	status, _ := inv.GetAsset("foo").GetStatus()
+	c.Assert(status, Equals, Provisioning)
+}
+
+// logReaderClient is an inventory client that can read back the asset logs
+type logReaderClient struct {
+	*mock.MockSubsysClient
+	logs []AssetLog
+}
+
+func (l *logReaderClient) GetAssetLogs(tag string) ([]AssetLog, error) {
+	return l.logs, nil
+}
+
+func (s *inventorySuite) TestGetAssetLogs(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mClient := mock.NewMockSubsysClient(ctrl)
+	inv := NewGeneralSubsys(mClient)
+	c.Assert(inv.RestoreAsset("foo", NewAssetWithState(mClient, "foo", Unallocated, Discovered)), IsNil)
+	_, err := inv.GetAssetLogs("foo")
+	c.Assert(err, ErrorMatches, ".*not supported.*")
+
+	logs := []AssetLog{{Type: LogTypeError, Message: "test failure"}}
+	inv = NewGeneralSubsys(&logReaderClient{MockSubsysClient: mClient, logs: logs})
+	c.Assert(inv.RestoreAsset("foo", NewAssetWithState(mClient, "foo", Unallocated, Discovered)), IsNil)
+	rcvdLogs, err := inv.GetAssetLogs("foo")
+	c.Assert(err, IsNil)
+	c.Assert(rcvdLogs, DeepEquals, logs)
+	_, err = inv.GetAssetLogs("bar")
+	c.Assert(err, ErrorMatches, ".*doesn't exists")
+}
+
+// transitionRecorderClient is an inventory client that records the transition
+// history of the assets
+type transitionRecorderClient struct {
+	*mock.MockSubsysClient
+	transitions []AssetTransition
+}
+
+func (t *transitionRecorderClient) AddAssetTransition(tag string, tr AssetTransition) error {
+	t.transitions = append(t.transitions, tr)
+	return nil
+}
+
+func (t *transitionRecorderClient) GetAssetTransitions(tag string, from, to time.Time) ([]AssetTransition, error) {
+	return t.transitions, nil
+}
+
+func (s *inventorySuite) TestAssetTransitions(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mClient := mock.NewMockSubsysClient(ctrl)
+	inv := NewGeneralSubsys(mClient)
+	c.Assert(inv.RestoreAsset("foo", NewAssetWithState(mClient, "foo", Unallocated, Discovered)), IsNil)
+	_, err := inv.GetAssetTransitions("foo", time.Time{}, time.Time{})
+	c.Assert(err, ErrorMatches, ".*not supported.*")
+
+	rClient := &transitionRecorderClient{MockSubsysClient: mClient}
+	inv = NewGeneralSubsys(rClient)
+	inv.SetTransitionCauser(func(name string) TransitionCause {
+		return TransitionCause{Trigger: "job 1", Actor: "user"}
+	})
+	c.Assert(inv.RestoreAsset("foo", NewAssetWithState(rClient, "foo", Cancelled, Discovered)), IsNil)
+	mClient.EXPECT().SetAssetStatus("foo", Decommissioned.String(), Discovered.String(), "test reason")
+	mClient.EXPECT().AddAssetLog("foo", LogTypeInfo, gomock.Any())
+	c.Assert(inv.SetAssetDecommissionedWithReason("foo", "test reason"), IsNil)
+	transitions, err := inv.GetAssetTransitions("foo", time.Time{}, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(transitions, HasLen, 1)
+	c.Assert(transitions[0].Time.IsZero(), Equals, false)
+	transitions[0].Time = time.Time{}
+	c.Assert(transitions[0], DeepEquals, AssetTransition{
+		FromStatus:      Cancelled.String(),
+		ToStatus:        Decommissioned.String(),
+		FromState:       Discovered.String(),
+		ToState:         Discovered.String(),
+		Reason:          "test reason",
+		TransitionCause: TransitionCause{Trigger: "job 1", Actor: "user"},
+	})
+	_, err = inv.GetAssetTransitions("bar", time.Time{}, time.Time{})
+	c.Assert(err, ErrorMatches, ".*doesn't exists")
+}
+
+func (s *inventorySuite) TestDeleteAsset(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mClient := mock.NewMockSubsysClient(ctrl)
+	inv := NewGeneralSubsys(mClient)
+	c.Assert(inv.RestoreAsset("foo", NewAssetWithState(mClient, "foo", Decommissioned, Disappeared)), IsNil)
+	c.Assert(inv.RestoreAsset("bar", NewAssetWithState(mClient, "bar", Decommissioned, Disappeared)), IsNil)
+	gomock.InOrder(
+		mClient.EXPECT().DeleteAsset("foo"),
+		mClient.EXPECT().DeleteAsset("bar").Return(errored.Errorf("test failure")),
+	)
+	c.Assert(inv.DeleteAsset("foo"), IsNil)
+	c.Assert(inv.GetAsset("foo"), IsNil)
+
+	// the asset is retained when the client fails to delete it
+	c.Assert(inv.DeleteAsset("bar"), ErrorMatches, ".*test failure.*")
+	c.Assert(inv.GetAsset("bar"), NotNil)
+
+	c.Assert(inv.DeleteAsset("foo"), ErrorMatches, ".*doesn't exists")
+}
+
+func (s *inventorySuite) TestSetAssetDecommissionedWithReason(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mClient := mock.NewMockSubsysClient(ctrl)
+	inv := NewGeneralSubsys(mClient)
+	c.Assert(inv.RestoreAsset("foo", NewAssetWithState(mClient, "foo", Cancelled, Disappeared)), IsNil)
+	mClient.EXPECT().SetAssetStatus("foo", Decommissioned.String(), Disappeared.String(), "forced")
+	mClient.EXPECT().AddAssetLog("foo", LogTypeInfo, `status changed from "Cancelled" to "Decommissioned". forced`)
+	c.Assert(inv.SetAssetDecommissionedWithReason("foo", "forced"), IsNil)
+	status, state := inv.GetAsset("foo").GetStatus()
+	c.Assert(status, Equals, Decommissioned)
+	c.Assert(state, Equals, Disappeared)
+
+	c.Assert(inv.SetAssetDecommissionedWithReason("bar", "forced"), ErrorMatches, ".*doesn't exists")
+}
+
+func (s *inventorySuite) TestConcurrentStatusUpdates(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	// a status change and a state change done concurrently, like by a job and
+	// the monitoring subsystem, don't undo each other
+	mClient := mock.NewMockSubsysClient(ctrl)
+	inv := NewGeneralSubsys(mClient)
+	c.Assert(inv.RestoreAsset("foo", NewAssetWithState(mClient, "foo", Allocated, Discovered)), IsNil)
+	mClient.EXPECT().SetAssetStatus("foo", gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+	mClient.EXPECT().AddAssetLog("foo", gomock.Any(), gomock.Any()).Times(2)
+	errCh := make(chan error, 2)
+	go func() { errCh <- inv.SetAssetInMaintenance("foo") }()
+	go func() { errCh <- inv.SetAssetDisappeared("foo") }()
+	c.Assert(<-errCh, IsNil)
+	c.Assert(<-errCh, IsNil)
+	status, state := inv.GetAsset("foo").GetStatus()
+	c.Assert(status, Equals, Maintenance)
+	c.Assert(state, Equals, Disappeared)
+}
+
+func (s *inventorySuite) TestSetAssetUnallocatedWithReason(c *C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+
+	mClient := mock.NewMockSubsysClient(ctrl)
+	inv := NewGeneralSubsys(mClient)
+	c.Assert(inv.RestoreAsset("foo", NewAssetWithState(mClient, "foo", Maintenance, Disappeared)), IsNil)
+	mClient.EXPECT().SetAssetStatus("foo", Unallocated.String(), Disappeared.String(), "skipped")
+	mClient.EXPECT().AddAssetLog("foo", LogTypeInfo, `status changed from "Maintenance" to "Unallocated". skipped`)
+	c.Assert(inv.SetAssetUnallocatedWithReason("foo", "skipped"), IsNil)
+	status, state := inv.GetAsset("foo").GetStatus()
+	c.Assert(status, Equals, Unallocated)
+	c.Assert(state, Equals, Disappeared)
+
+	c.Assert(inv.SetAssetUnallocatedWithReason("bar", "skipped"), ErrorMatches, ".*doesn't exists")
+}
+
@@ -12,6 +12,13 @@ func NewBoltdbSubsys(config boltdb.Config) (*inventory.GeneralSubsys, error) {
 	if err != nil {
 		return nil, err
 	}
+	return NewBoltdbSubsysFromClient(client)
+}
+
+// NewBoltdbSubsysFromClient initializes and return an instance of boltdb based
+// inventory subsystem using an already initialized boltdb client. This allows the
+// boltdb to be shared with other users.
+func NewBoltdbSubsysFromClient(client *boltdb.Client) (*inventory.GeneralSubsys, error) {
 	subsys := inventory.NewGeneralSubsys(client)
 
 	// restore any previously added hosts
@@ -23,6 +30,13 @@ func NewBoltdbSubsys(config boltdb.Config) (*inventory.GeneralSubsys, error) {
 	for _, asset := range assets1 {
 		a := inventory.NewAssetWithState(client, asset.Name, inventory.AssetStatusVals[asset.Status],
 			inventory.AssetStateVals[asset.State])
+		a.RestoreConfig(inventory.AssetConfig{
+			Group: asset.HostGroup,
+			Addr:  asset.HostAddr,
+			Vars:  asset.HostVars,
+		})
+		a.RestoreFacts(asset.Facts)
+		a.RestoreAttributes(asset.Attributes)
 		if err := subsys.RestoreAsset(asset.Name, a); err != nil {
 			logrus.Infof("failed to restore asset %q. Error: %v", asset.Name, err)
 			continue
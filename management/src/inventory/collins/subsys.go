@@ -28,6 +28,13 @@ func NewCollinsSubsys(config collins.Config) (*inventory.GeneralSubsys, error) {
 	for _, asset := range assets1 {
 		a := inventory.NewAssetWithState(client, asset.Tag, inventory.AssetStatusVals[asset.Status],
 			inventory.AssetStateVals[asset.State.Name])
+		a.RestoreConfig(inventory.AssetConfig{
+			Group: asset.HostGroup,
+			Addr:  asset.HostAddr,
+			Vars:  asset.HostVars,
+		})
+		a.RestoreFacts(asset.Facts)
+		a.RestoreAttributes(asset.Attributes)
 		if err := subsys.RestoreAsset(asset.Tag, a); err != nil {
 			logrus.Infof("failed to restore asset %q. Error: %v", asset.Tag, err)
 			continue
@@ -20,10 +20,11 @@ const (
 	// admin or automatically. The configuration for infrastructure is pushed at this status.
 	Provisioning
 	// Provisioned status in collins implies that Host has finished provisioning and is awaiting final
-	// automated verification. This is not yet used in contiv cluster.
+	// automated verification. In contiv cluster this status is set when the host configuration was
+	// successful during commission and the host is being verified, before it is used in production.
 	Provisioned
 	// Allocated status in collins implies that this asset is in what should likely be considered a production
-	// state. In contiv cluster this status is set when the host configuration was successful.
+	// state. In contiv cluster this status is set when the host configuration and verification was successful.
 	Allocated
 	// Cancelled status in collins implies that asset is no longer needed and is awaiting decommissioning.
 	// In contiv cluster this status is set when a host is signalled to be decommissioned by the
@@ -50,4 +51,7 @@ const (
 	Discovered
 	// Disappeared state denotes that host has disappeared from monitoring subsystem.
 	Disappeared
+	// Degraded state denotes that host is alive in monitoring subsystem, but the
+	// verification of it's configuration failed after it reappeared.
+	Degraded
 )
@@ -2,7 +2,10 @@
 
 package inventory
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Subsys provides the following services to the cluster manager:
 // - Interface to perform CRUD operations on the asset inventory.
@@ -13,22 +16,54 @@ type Subsys interface {
 	SetAssetDiscovered(name string) error
 	//SetAssetDisappeared sets an asset state to disappeared
 	SetAssetDisappeared(name string) error
+	//SetAssetDegraded sets an asset state to degraded
+	SetAssetDegraded(name string) error
 	//SetAssetProvisioning sets an asset state to provisioning
 	SetAssetProvisioning(name string) error
+	//SetAssetProvisioned sets an asset state to provisioned
+	SetAssetProvisioned(name string) error
 	//SetAssetCommissioned sets an asset state to commissioned (aka allocated)
 	SetAssetCommissioned(name string) error
 	//SetAssetCancelled sets an asset state to cancelled
 	SetAssetCancelled(name string) error
 	//SetAssetDecommissioned sets an asset state to decommissioned
 	SetAssetDecommissioned(name string) error
+	//SetAssetDecommissionedWithReason sets an asset state to decommissioned
+	//and records the reason in the inventory
+	SetAssetDecommissionedWithReason(name, reason string) error
 	//SetAssetInMaintenance sets an asset state to maintenance
 	SetAssetInMaintenance(name string) error
 	//SetAssetUnallocated sets an asset status to unallocated
 	SetAssetUnallocated(name string) error
+	//SetAssetUnallocatedWithReason sets an asset status to unallocated
+	//and records the reason in the inventory
+	SetAssetUnallocatedWithReason(name, reason string) error
+	//SetAssetConfig sets the configuration state of an asset
+	SetAssetConfig(name string, config AssetConfig) error
+	//SetAssetFacts sets the hardware facts of an asset
+	SetAssetFacts(name string, facts AssetFacts) error
+	//SetAssetAttributes merges the specified attributes, like the labels set
+	//by the operator, with the attributes of an asset. An attribute with an
+	//empty value is removed.
+	SetAssetAttributes(name string, attrs map[string]string) error
+	//GetAssetAttributes returns the attributes of an asset
+	GetAssetAttributes(name string) (map[string]string, error)
+	//DeleteAsset deletes an asset from the inventory
+	DeleteAsset(name string) error
 	//GetAsset finds and returns the asset in inventory
 	GetAsset(name string) SubsysAsset
 	//GetAllAssets returns all the assets in inventory
 	GetAllAssets() SubsysAssets
+	//AddAssetLog records an event, like a failure, in the logs of an asset
+	AddAssetLog(name, mtype, message string) error
+	//GetAssetLogs returns the logs of an asset, oldest first
+	GetAssetLogs(name string) ([]AssetLog, error)
+	//GetAssetTransitions returns the transitions of an asset within the time
+	//range, oldest first. A zero time leaves that end of the range open.
+	GetAssetTransitions(name string, from, to time.Time) ([]AssetTransition, error)
+	//SetTransitionCauser sets the callback that identifies the cause of the
+	//transitions of the assets, for their transition history
+	SetTransitionCauser(causer TransitionCauser)
 }
 
 // SubsysClient provides the client interface for the inventory subsystem
@@ -38,6 +73,28 @@ type SubsysClient interface {
 	CreateState(name, description, status string) error
 	AddAssetLog(tag, mtype, message string) error
 	SetAssetStatus(tag, status, state, reason string) error
+	SetAssetConfig(tag, group, addr string, vars map[string]string) error
+	SetAssetAttributes(tag string, attrs map[string]string) error
+	DeleteAsset(tag string) error
+}
+
+// SubsysLogReader is implemented by the inventory subsystem clients that can
+// read back the log entries of an asset
+type SubsysLogReader interface {
+	GetAssetLogs(tag string) ([]AssetLog, error)
+}
+
+// SubsysTransitionRecorder is implemented by the inventory subsystem clients
+// that can persist the transition history of the assets
+type SubsysTransitionRecorder interface {
+	AddAssetTransition(tag string, t AssetTransition) error
+	GetAssetTransitions(tag string, from, to time.Time) ([]AssetTransition, error)
+}
+
+// SubsysFactsRecorder is implemented by the inventory subsystem clients that
+// can persist the hardware facts of the assets
+type SubsysFactsRecorder interface {
+	SetAssetFacts(tag string, facts AssetFacts) error
 }
 
 // SubsysAsset denotes a single asset in inventory subsystem
@@ -46,6 +103,12 @@ type SubsysAsset interface {
 	GetStatus() (AssetStatus, AssetState)
 	//GetTag returns the inventory tag of the asset
 	GetTag() string
+	//GetConfig returns the configuration state of the asset
+	GetConfig() AssetConfig
+	//GetFacts returns the hardware facts of the asset, nil if they were not gathered
+	GetFacts() *AssetFacts
+	//GetAttributes returns the attributes of the asset
+	GetAttributes() map[string]string
 	//SubsysAsset shall satisfy the json marshaller interface to encode asset's info in json
 	json.Marshaler
 }
@@ -1,10 +1,19 @@
 package inventory
 
+import (
+	"sync"
+	"time"
+
+	"github.com/contiv/errored"
+)
+
 // GeneralSubsys implements the inventory sub-system. It is instantiated using
 // the New* methods of specific subsystems like collins, boltdb and so on
 type GeneralSubsys struct {
 	client SubsysClient
+	mutex  sync.RWMutex // protects the assets, as the jobs update them concurrently
 	assets map[string]*Asset
+	causer TransitionCauser
 }
 
 // NewGeneralSubsys returns a instance of GeneralSubsys initialized with a subsystem client
@@ -17,10 +26,13 @@ func NewGeneralSubsys(client SubsysClient) *GeneralSubsys {
 
 // RestoreAsset makes the subsystem update asset info
 func (ci *GeneralSubsys) RestoreAsset(name string, asset *Asset) error {
+	ci.mutex.Lock()
+	defer ci.mutex.Unlock()
 	if _, ok := ci.assets[name]; ok {
 		return errAssetExists(name)
 	}
 
+	asset.causer = ci.transitionCause
 	ci.assets[name] = asset
 
 	return nil
@@ -28,11 +40,13 @@ func (ci *GeneralSubsys) RestoreAsset(name string, asset *Asset) error {
 
 //AddAsset adds an asset to collins in 'Discovered' status
 func (ci *GeneralSubsys) AddAsset(name string) error {
+	ci.mutex.Lock()
+	defer ci.mutex.Unlock()
 	if _, ok := ci.assets[name]; ok {
 		return errAssetExists(name)
 	}
 
-	host, err := NewAsset(ci.client, name)
+	host, err := newAssetWithCauser(ci.client, name, ci.transitionCause)
 	if err != nil {
 		return err
 	}
@@ -43,94 +57,263 @@ func (ci *GeneralSubsys) AddAsset(name string) error {
 
 //SetAssetDiscovered sets an asset state to discovered
 func (ci *GeneralSubsys) SetAssetDiscovered(name string) error {
-	if _, ok := ci.assets[name]; !ok {
-		return errAssetNotExists(name)
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
 	}
 
-	status, _ := ci.assets[name].GetStatus()
-	return ci.assets[name].SetStatus(status, Discovered)
+	return asset.setState(Discovered)
 }
 
 //SetAssetDisappeared sets an asset state to disappeared
 func (ci *GeneralSubsys) SetAssetDisappeared(name string) error {
-	if _, ok := ci.assets[name]; !ok {
-		return errAssetNotExists(name)
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
+	}
+
+	return asset.setState(Disappeared)
+}
+
+//SetAssetDegraded sets an asset state to degraded
+func (ci *GeneralSubsys) SetAssetDegraded(name string) error {
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
 	}
 
-	status, _ := ci.assets[name].GetStatus()
-	return ci.assets[name].SetStatus(status, Disappeared)
+	return asset.setState(Degraded)
 }
 
 //SetAssetProvisioning sets an asset state to provisioning
 func (ci *GeneralSubsys) SetAssetProvisioning(name string) error {
-	if _, ok := ci.assets[name]; !ok {
-		return errAssetNotExists(name)
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
+	}
+
+	return asset.setStatus(Provisioning, "")
+}
+
+//SetAssetProvisioned sets an asset state to provisioned
+func (ci *GeneralSubsys) SetAssetProvisioned(name string) error {
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
 	}
 
-	_, state := ci.assets[name].GetStatus()
-	return ci.assets[name].SetStatus(Provisioning, state)
+	return asset.setStatus(Provisioned, "")
 }
 
 //SetAssetCommissioned sets an asset status to unallocated
 func (ci *GeneralSubsys) SetAssetCommissioned(name string) error {
-	if _, ok := ci.assets[name]; !ok {
-		return errAssetNotExists(name)
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
 	}
 
-	_, state := ci.assets[name].GetStatus()
 	// collins equivalent of commissioned status in allocated
-	return ci.assets[name].SetStatus(Allocated, state)
+	return asset.setStatus(Allocated, "")
 }
 
 //SetAssetCancelled sets an asset state to cancelled
 func (ci *GeneralSubsys) SetAssetCancelled(name string) error {
-	if _, ok := ci.assets[name]; !ok {
-		return errAssetNotExists(name)
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
 	}
 
-	_, state := ci.assets[name].GetStatus()
-	return ci.assets[name].SetStatus(Cancelled, state)
+	return asset.setStatus(Cancelled, "")
 }
 
 //SetAssetDecommissioned sets an asset status to decommissioned
 func (ci *GeneralSubsys) SetAssetDecommissioned(name string) error {
-	if _, ok := ci.assets[name]; !ok {
-		return errAssetNotExists(name)
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
+	}
+
+	return asset.setStatus(Decommissioned, "")
+}
+
+//SetAssetDecommissionedWithReason sets an asset status to decommissioned and
+//records the reason in the inventory, like the reason a node was decommissioned
+//by force
+func (ci *GeneralSubsys) SetAssetDecommissionedWithReason(name, reason string) error {
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
 	}
 
-	_, state := ci.assets[name].GetStatus()
-	return ci.assets[name].SetStatus(Decommissioned, state)
+	return asset.setStatus(Decommissioned, reason)
 }
 
 //SetAssetInMaintenance sets an asset state to decommissioned
 func (ci *GeneralSubsys) SetAssetInMaintenance(name string) error {
-	if _, ok := ci.assets[name]; !ok {
-		return errAssetNotExists(name)
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
 	}
 
-	_, state := ci.assets[name].GetStatus()
-	return ci.assets[name].SetStatus(Maintenance, state)
+	return asset.setStatus(Maintenance, "")
 }
 
 //SetAssetUnallocated sets an asset status to unallocated
 func (ci *GeneralSubsys) SetAssetUnallocated(name string) error {
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
+	}
+
+	return asset.setStatus(Unallocated, "")
+}
+
+//SetAssetUnallocatedWithReason sets an asset status to unallocated and records
+//the reason in the inventory, like the reason a node was not updated by a
+//forced update
+func (ci *GeneralSubsys) SetAssetUnallocatedWithReason(name, reason string) error {
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
+	}
+
+	return asset.setStatus(Unallocated, reason)
+}
+
+//SetAssetConfig sets the configuration state of an asset
+func (ci *GeneralSubsys) SetAssetConfig(name string, config AssetConfig) error {
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
+	}
+
+	return asset.SetConfig(config)
+}
+
+//SetAssetFacts sets the hardware facts of an asset
+func (ci *GeneralSubsys) SetAssetFacts(name string, facts AssetFacts) error {
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
+	}
+
+	return asset.SetFacts(facts)
+}
+
+//SetAssetAttributes merges the specified attributes with the attributes of an asset
+func (ci *GeneralSubsys) SetAssetAttributes(name string, attrs map[string]string) error {
+	asset, err := ci.asset(name)
+	if err != nil {
+		return err
+	}
+
+	return asset.SetAttributes(attrs)
+}
+
+//GetAssetAttributes returns the attributes of an asset
+func (ci *GeneralSubsys) GetAssetAttributes(name string) (map[string]string, error) {
+	asset, err := ci.asset(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return asset.GetAttributes(), nil
+}
+
+//DeleteAsset deletes an asset from the inventory
+func (ci *GeneralSubsys) DeleteAsset(name string) error {
+	ci.mutex.Lock()
+	defer ci.mutex.Unlock()
 	if _, ok := ci.assets[name]; !ok {
 		return errAssetNotExists(name)
 	}
 
-	_, state := ci.assets[name].GetStatus()
-	return ci.assets[name].SetStatus(Unallocated, state)
+	if err := ci.client.DeleteAsset(name); err != nil {
+		return err
+	}
+	delete(ci.assets, name)
+
+	return nil
 }
 
 //GetAsset finds and returns the asset in inventory
 func (ci *GeneralSubsys) GetAsset(name string) SubsysAsset {
-	if a, ok := ci.assets[name]; ok {
+	if a, err := ci.asset(name); err == nil {
 		return a
 	}
 	return nil
 }
 
-//GetAllAssets returns all the assets in inventory
+//GetAllAssets returns all the assets in inventory. The assets are returned in a
+//new map, so that it can be ranged over while the assets are added or deleted.
 func (ci *GeneralSubsys) GetAllAssets() SubsysAssets {
-	return ci.assets
+	ci.mutex.RLock()
+	defer ci.mutex.RUnlock()
+	assets := make(map[string]*Asset, len(ci.assets))
+	for name, a := range ci.assets {
+		assets[name] = a
+	}
+	return assets
+}
+
+// asset returns the named asset in inventory
+func (ci *GeneralSubsys) asset(name string) (*Asset, error) {
+	ci.mutex.RLock()
+	defer ci.mutex.RUnlock()
+	a, ok := ci.assets[name]
+	if !ok {
+		return nil, errAssetNotExists(name)
+	}
+	return a, nil
+}
+
+//AddAssetLog records an event, like a failure, in the logs of an asset
+func (ci *GeneralSubsys) AddAssetLog(name, mtype, message string) error {
+	if _, err := ci.asset(name); err != nil {
+		return err
+	}
+
+	return ci.client.AddAssetLog(name, mtype, message)
+}
+
+//GetAssetLogs returns the logs of an asset, oldest first
+func (ci *GeneralSubsys) GetAssetLogs(name string) ([]AssetLog, error) {
+	if _, err := ci.asset(name); err != nil {
+		return nil, err
+	}
+
+	reader, ok := ci.client.(SubsysLogReader)
+	if !ok {
+		return nil, errored.Errorf("reading the logs of an asset is not supported by the inventory")
+	}
+	return reader.GetAssetLogs(name)
+}
+
+//SetTransitionCauser sets the callback that identifies the cause of the
+//transitions of the assets, for their transition history
+func (ci *GeneralSubsys) SetTransitionCauser(causer TransitionCauser) {
+	ci.causer = causer
+}
+
+// transitionCause returns the cause of a transition of the named asset, as
+// identified by the causer if one is set
+func (ci *GeneralSubsys) transitionCause(name string) TransitionCause {
+	if ci.causer == nil {
+		return TransitionCause{}
+	}
+	return ci.causer(name)
+}
+
+//GetAssetTransitions returns the transitions of an asset within the time
+//range, oldest first. A zero time leaves that end of the range open.
+func (ci *GeneralSubsys) GetAssetTransitions(name string, from, to time.Time) ([]AssetTransition, error) {
+	if _, err := ci.asset(name); err != nil {
+		return nil, err
+	}
+
+	recorder, ok := ci.client.(SubsysTransitionRecorder)
+	if !ok {
+		return nil, errored.Errorf("the transition history of an asset is not supported by the inventory")
+	}
+	return recorder.GetAssetTransitions(name, from, to)
 }
@@ -33,10 +33,33 @@ func (s *SystemTestSuite) TestClustermRestart(c *C) {
 	s.checkProvisionStatus(c, s.tbn1, nodeName2, "Decommissioned")
 }
 
-func (s *SystemTestSuite) TestClustermFailureActiveJob(c *C) {
+func (s *SystemTestSuite) TestClustermRestartHostGroup(c *C) {
+	if strings.Contains(testDataDir, "/collins") {
+		c.Skip("skipping clusterm restart test with collins, due to collins issue: https://github.com/tumblr/collins/issues/436")
+	}
+
 	nodeName1 := validNodeNames[0]
 	nodeName2 := validNodeNames[1]
 
+	// commission the nodes. First node is master, second node is worker
+	s.commissionNode(c, nodeName1, ansibleMasterGroupName, s.tbn1)
+	s.commissionNode(c, nodeName2, ansibleWorkerGroupName, s.tbn2)
+
+	// restart clusterm
+	s.restartClusterm(c, s.tbn1, 30)
+
+	// verify that the host group of the nodes is restored, once they are discovered
+	s.getNodeInfoSuccess(c, nodeName2)
+	s.checkHostGroup(c, nodeName1, ansibleMasterGroupName)
+	s.checkHostGroup(c, nodeName2, ansibleWorkerGroupName)
+
+	// the worker can be decommissioned as master stays commissioned
+	s.decommissionNode(c, nodeName2, s.tbn2)
+}
+
+func (s *SystemTestSuite) TestClustermQueuedJob(c *C) {
+	nodeName1 := validNodeNames[0]
+
 	// launch commission on a node
 	done := make(chan struct{})
 	go func() {
@@ -44,12 +67,15 @@ func (s *SystemTestSuite) TestClustermFailureActiveJob(c *C) {
 		done <- struct{}{}
 	}()
 
-	// try to start another commission job
+	// another job on the same node is queued
 	time.Sleep(time.Second)
-	cmdStr := fmt.Sprintf("clusterctl node commission %s --host-group %s", nodeName2, ansibleWorkerGroupName)
+	cmdStr := fmt.Sprintf("clusterctl node decommission %s", nodeName1)
 	out, err := s.tbn1.RunCommandWithOutput(cmdStr)
-	s.Assert(c, err, NotNil, Commentf("output: %s", out))
-	exptStr := ".*there is already an active job.*"
+	s.Assert(c, err, IsNil, Commentf("output: %s", out))
+	cmdStr = fmt.Sprintf("clusterctl job queue")
+	out, err = s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, IsNil, Commentf("output: %s", out))
+	exptStr := fmt.Sprintf(".*decommissionEvent.*%s.*", nodeName1)
 	s.assertMatch(c, exptStr, out)
 
 	// wait for job to finish
@@ -59,8 +85,68 @@ func (s *SystemTestSuite) TestClustermFailureActiveJob(c *C) {
 		s.Assert(c, false, Equals, true, Commentf("timeout waiting for job to finish"))
 	}
 
-	// try a decommission job once previous job is done
-	s.decommissionNode(c, nodeName1, s.tbn2)
+	// the queued decommission job runs once previous job is done
+	s.checkProvisionStatus(c, s.tbn1, nodeName1, "Decommissioned")
+	s.waitForStatToFail(c, s.tbn1, dummyAnsibleFile)
+}
+
+func (s *SystemTestSuite) TestClustermDequeueJob(c *C) {
+	nodeName1 := validNodeNames[0]
+
+	// launch commission on a node
+	done := make(chan struct{})
+	go func() {
+		s.commissionNode(c, nodeName1, ansibleMasterGroupName, s.tbn1)
+		done <- struct{}{}
+	}()
+
+	// queue another job on the same node and remove it from the queue
+	time.Sleep(time.Second)
+	cmdStr := fmt.Sprintf("clusterctl node decommission %s", nodeName1)
+	out, err := s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, IsNil, Commentf("output: %s", out))
+	cmdStr = fmt.Sprintf("clusterctl job list --status Queued")
+	out, err = s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, IsNil, Commentf("output: %s", out))
+	s.assertMatch(c, ".*Total: 1.*", out)
+	id := strings.Fields(strings.Split(strings.TrimSpace(out), "\n")[1])[0]
+	cmdStr = fmt.Sprintf("clusterctl job dequeue %s", id)
+	out, err = s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, IsNil, Commentf("output: %s", out))
+
+	// wait for job to finish
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		s.Assert(c, false, Equals, true, Commentf("timeout waiting for job to finish"))
+	}
+
+	// the node stays commissioned
+	time.Sleep(time.Second)
+	s.checkProvisionStatus(c, s.tbn1, nodeName1, "Allocated")
+}
+
+func (s *SystemTestSuite) TestClustermConcurrentJobsDisjointNodes(c *C) {
+	nodeName1 := validNodeNames[0]
+	nodeName2 := validNodeNames[1]
+
+	// launch commission on a node
+	done := make(chan struct{})
+	go func() {
+		s.commissionNode(c, nodeName1, ansibleMasterGroupName, s.tbn1)
+		done <- struct{}{}
+	}()
+
+	// a job on a different node is allowed to run concurrently
+	time.Sleep(time.Second)
+	s.commissionNode(c, nodeName2, ansibleMasterGroupName, s.tbn2)
+
+	// wait for first job to finish
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		s.Assert(c, false, Equals, true, Commentf("timeout waiting for job to finish"))
+	}
 }
 
 func (s *SystemTestSuite) TestSerfFailureOnClustermHost(c *C) {
@@ -139,7 +139,7 @@ func (s *SystemTestSuite) TestCommissionWorkerNodesWithoutMasterFailure(c *C) {
 	cmdStr := fmt.Sprintf("clusterctl node commission %s --host-group %s", nodeName, ansibleWorkerGroupName)
 	out, err := s.tbn1.RunCommandWithOutput(cmdStr)
 	s.Assert(c, err, NotNil, Commentf("output: %s", out))
-	exptdOut := ".*Cannot commission a worker node without existence of a master node in the cluster, make sure atleast one master node is commissioned.*"
+	exptdOut := ".*Cannot commission the node\\(s\\) in host-group.*service-worker.* without existence of a node in host-group.*service-master.* in the cluster.*"
 	s.assertMatch(c, exptdOut, out)
 }
 
@@ -160,7 +160,7 @@ func (s *SystemTestSuite) TestCommissionWorkerNodesWithMasterDisappearedFailure(
 	cmdStr := fmt.Sprintf("clusterctl node commission %s --host-group %s", nodeName, ansibleWorkerGroupName)
 	out, err := s.tbn1.RunCommandWithOutput(cmdStr)
 	s.Assert(c, err, NotNil, Commentf("output: %s", out))
-	exptStr := ".*Cannot commission a worker node without existence of a master node in the cluster, make sure atleast one master node is commissioned.*"
+	exptStr := ".*Cannot commission the node\\(s\\) in host-group.*service-worker.* without existence of a node in host-group.*service-master.* in the cluster.*"
 	s.assertMatch(c, exptStr, out)
 }
 
@@ -172,3 +172,15 @@ func (s *SystemTestSuite) TestCommissionNodesSuccess(c *C) {
 		s.checkHostGroup(c, name, "service-master")
 	}
 }
+
+func (s *SystemTestSuite) TestCommissionNodeWaitSuccess(c *C) {
+	nodeName := validNodeNames[0]
+
+	// the command returns once the job is done and prints it's final status
+	cmdStr := fmt.Sprintf("clusterctl node commission %s --host-group %s --wait", nodeName, ansibleMasterGroupName)
+	out, err := s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, IsNil, Commentf("output: %s", out))
+	s.assertMatch(c, ".*Status: Complete.*", out)
+	s.checkProvisionStatus(c, s.tbn1, nodeName, "Allocated")
+	s.checkHostGroup(c, nodeName, "service-master")
+}
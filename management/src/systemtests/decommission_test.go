@@ -54,7 +54,7 @@ func (s *SystemTestSuite) TestDecommissionNodeFailureRemainingWorker(c *C) {
 	cmdStr := fmt.Sprintf("clusterctl node decommission %s", nodeName1)
 	out, err := s.tbn1.RunCommandWithOutput(cmdStr)
 	s.Assert(c, err, NotNil, Commentf("output: %s", out))
-	exptdOut := ".*decommissioning.*will leave only worker nodes.*all worker nodes are decommissioned before last master node.*"
+	exptdOut := ".*Cannot decommission the node\\(s\\) as it will leave nodes in host-group.*service-worker.* without a node in host-group.*service-master.*"
 	s.assertMatch(c, exptdOut, out)
 }
 
@@ -32,14 +32,15 @@ var _ = Suite(&SystemTestSuite{
 })
 
 var (
-	validNodeNames         = []string{"cluster-node1-0", "cluster-node2-0"}
-	validNodeAddrs         = []string{}
-	invalidNodeName        = "invalid-test-node"
-	dummyAnsibleFile       = "/tmp/yay"
-	dummyUpdateAnsibleFile = "/tmp/yay.updated"
-	testDataDir            = os.Getenv("TESTDATA_DIR")
-	ansibleMasterGroupName = "service-master"
-	ansibleWorkerGroupName = "service-worker"
+	validNodeNames          = []string{"cluster-node1-0", "cluster-node2-0"}
+	validNodeAddrs          = []string{}
+	invalidNodeName         = "invalid-test-node"
+	dummyAnsibleFile        = "/tmp/yay"
+	dummyUpdateAnsibleFile  = "/tmp/yay.updated"
+	dummyUpgradeAnsibleFile = "/tmp/yay.upgraded"
+	testDataDir             = os.Getenv("TESTDATA_DIR")
+	ansibleMasterGroupName  = "service-master"
+	ansibleWorkerGroupName  = "service-worker"
 )
 
 func (s *SystemTestSuite) SetUpSuite(c *C) {
@@ -60,3 +60,32 @@ func (s *SystemTestSuite) TestJobStatusFailureInvalidJobLabel(c *C) {
 	exptdOut := fmt.Sprintf(`.*Invalid or empty job label specified:.*%s.*`, "foo")
 	s.assertMatch(c, exptdOut, out)
 }
+
+func (s *SystemTestSuite) TestJobCancel(c *C) {
+	nodeName := validNodeNames[0]
+
+	// launch commission on a node and cancel it while it is running
+	cmdStr := fmt.Sprintf("clusterctl node commission %s --host-group %s", nodeName, ansibleMasterGroupName)
+	out, err := s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, IsNil, Commentf("output: %s", out))
+	cmdStr = fmt.Sprintf("clusterctl job cancel active")
+	out, err = s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, IsNil, Commentf("output: %s", out))
+
+	// the node shall be cleaned up and set as unallocated
+	s.checkProvisionStatus(c, s.tbn1, nodeName, "Unallocated")
+	s.waitForStatToFail(c, s.tbn1, dummyAnsibleFile)
+
+	cmdStr = fmt.Sprintf("clusterctl job get last")
+	out, err = s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, IsNil, Commentf("output: %s", out))
+	s.assertMatch(c, ".*job was cancelled.*", out)
+}
+
+func (s *SystemTestSuite) TestJobCancelFailureNonExistentJob(c *C) {
+	cmdStr := fmt.Sprintf("clusterctl job cancel active")
+	out, err := s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, NotNil, Commentf("output: %s", out))
+	exptdOut := fmt.Sprintf(`.*info for.*%s.*job doesn't exist.*`, "active")
+	s.assertMatch(c, exptdOut, out)
+}
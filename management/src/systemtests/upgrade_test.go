@@ -0,0 +1,71 @@
+// +build systemtest
+
+package systemtests
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *SystemTestSuite) TestUpgradeNodesSuccess(c *C) {
+	s.commissionNodes(c, validNodeNames, ansibleMasterGroupName)
+
+	nodesStr := strings.Join(validNodeNames, " ")
+	cmdStr := fmt.Sprintf("clusterctl nodes upgrade %s --batch-size 1 --max-unavailable 1 --wait", nodesStr)
+	out, err := s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, IsNil, Commentf("output: %s", out))
+	s.assertMatch(c, ".*Status: Complete.*", out)
+	for _, name := range validNodeNames {
+		s.checkProvisionStatus(c, s.tbn1, name, "Allocated")
+	}
+	s.waitForStatToSucceed(c, s.tbn1, dummyUpgradeAnsibleFile)
+	s.waitForStatToSucceed(c, s.tbn2, dummyUpgradeAnsibleFile)
+}
+
+func (s *SystemTestSuite) TestUpgradeNodesFailureUnallocatedNode(c *C) {
+	nodeName := validNodeNames[0]
+
+	cmdStr := fmt.Sprintf("clusterctl nodes upgrade %s", nodeName)
+	out, err := s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, NotNil, Commentf("output: %s", out))
+	exptStr := fmt.Sprintf(".*node.*%s.*is not commissioned.*", nodeName)
+	s.assertMatch(c, exptStr, out)
+}
+
+func (s *SystemTestSuite) TestUpgradeNodesFailureInvalidBatchSize(c *C) {
+	s.commissionNodes(c, validNodeNames, ansibleMasterGroupName)
+
+	nodesStr := strings.Join(validNodeNames, " ")
+	cmdStr := fmt.Sprintf("clusterctl nodes upgrade %s --batch-size 2 --max-unavailable 1", nodesStr)
+	out, err := s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, NotNil, Commentf("output: %s", out))
+	s.assertMatch(c, ".*invalid max-unavailable specified.*", out)
+}
+
+func (s *SystemTestSuite) TestUpgradeNodesFailureStopsRollout(c *C) {
+	s.commissionNodes(c, validNodeNames, ansibleMasterGroupName)
+
+	// temporarily move the rolling-upgrade.yml file to sitmulate a failure
+	pwd, err := os.Getwd()
+	s.Assert(c, err, IsNil)
+	src := fmt.Sprintf("%s/../demo/files/rolling-upgrade.yml", pwd)
+	dst := fmt.Sprintf("%s/../demo/files/rolling-upgrade.yml.1", pwd)
+	out, err := s.tbn1.RunCommandWithOutput(fmt.Sprintf("sudo mv %s %s", src, dst))
+	s.Assert(c, err, IsNil, Commentf("output: %s", out))
+	defer func() {
+		out, err := s.tbn1.RunCommandWithOutput(fmt.Sprintf("sudo mv %s %s", dst, src))
+		s.Assert(c, err, IsNil, Commentf("output: %s", out))
+	}()
+
+	// the first batch fails and the second batch is not upgraded
+	nodesStr := strings.Join(validNodeNames, " ")
+	cmdStr := fmt.Sprintf("clusterctl nodes upgrade %s --batch-size 1 --wait", nodesStr)
+	out, err = s.tbn1.RunCommandWithOutput(cmdStr)
+	s.Assert(c, err, NotNil, Commentf("output: %s", out))
+	s.assertMatch(c, ".*Status: Errored.*", out)
+	s.checkProvisionStatus(c, s.tbn1, validNodeNames[0], "Unallocated")
+	s.checkProvisionStatus(c, s.tbn1, validNodeNames[1], "Allocated")
+}